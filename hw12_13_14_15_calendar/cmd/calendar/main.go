@@ -16,8 +16,7 @@ import (
 	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/server/grpc"
 	internalhttp "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/server/http"
 	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
-	memorystorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage/memory"
-	sqlstorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage/pgsql"
+	_ "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage/all"
 )
 
 var (
@@ -55,15 +54,14 @@ func main() {
 		os.Exit(1)
 	}
 
-	var s storage.Storage
-
-	switch c.StorageType() {
-	case memorystorage.Type:
-		s = memorystorage.New()
-	case sqlstorage.Type:
-		s = sqlstorage.New()
+	backend, err := storage.Lookup(c.StorageType())
+	if err != nil {
+		logg.Error(err)
+		os.Exit(1)
 	}
 
+	s := backend.New(c.StorageOptions())
+
 	err = s.Connect(ctx, c.StorageDsn())
 	if err != nil {
 		logg.Error(err)
@@ -77,7 +75,12 @@ func main() {
 		}
 	}()
 
-	calendar := app.New(s)
+	calendar := app.New(
+		s,
+		app.WithFirstWeekday(c.FirstWeekday()),
+		app.WithLocationRooms(c.LocationRooms()),
+		app.WithLogger(logg),
+	)
 
 	server := internalhttp.NewServer(calendar, logg, c)
 	grpcServer := grpc.NewServer(calendar, logg, c)
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"database/sql"
 	"errors"
 	"flag"
 	"fmt"
@@ -9,15 +8,14 @@ import (
 
 	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/config"
 	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/logger"
-	sqlstorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage/pgsql"
-	_ "github.com/lib/pq"
-	"github.com/pressly/goose"
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+	_ "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage/all"
 )
 
 var (
 	configFile                string
 	configFormat              string
-	ErrUnsupportedStorageType = errors.New("unsupported storage type")
+	ErrUnsupportedStorageType = errors.New("storage type does not support migrations")
 )
 
 func init() {
@@ -44,25 +42,16 @@ func main() {
 		os.Exit(1)
 	}
 
-	var db *sql.DB
-
-	switch c.StorageType() {
-	case sqlstorage.Type:
-		if db, err = goose.OpenDBWithDriver("postgres", c.StorageDsn()); err != nil {
-			logg.Fatal(err)
-		}
+	backend, err := storage.Lookup(c.StorageType())
+	if err != nil {
+		logg.Fatal(err)
+	}
 
-		defer func() {
-			err := db.Close()
-			if err != nil {
-				logg.Error(err)
-			}
-		}()
-	default:
+	if backend.Migrate == nil {
 		logg.Fatal(ErrUnsupportedStorageType)
 	}
 
-	if err := goose.Up(db, c.MigrationPath()); err != nil {
+	if err := backend.Migrate(c.StorageDsn(), c.MigrationPath()); err != nil {
 		logg.Fatal(err)
 	}
 }
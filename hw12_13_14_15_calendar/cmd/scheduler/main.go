@@ -13,10 +13,8 @@ import (
 	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/config"
 	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/logger"
 	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
-	memorystorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage/memory"
-	sqlstorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage/pgsql"
+	_ "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage/all"
 	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/pkg/producer"
-	_ "github.com/lib/pq"
 	"github.com/rabbitmq/amqp091-go"
 )
 
@@ -52,14 +50,17 @@ func main() {
 	}
 
 	ctx := context.Background()
-	var s storage.Storage
-	switch c.StorageType() {
-	case memorystorage.Type:
-		s = memorystorage.New()
-	case sqlstorage.Type:
-		s = sqlstorage.New()
+	backend, err := storage.Lookup(c.StorageType())
+	if err != nil {
+		logg.Error(err)
+		os.Exit(1)
 	}
 
+	// Файлы inMemory хранилища принадлежат процессу calendar.
+	opts := c.StorageOptions()
+	opts.Path = ""
+	s := backend.New(opts)
+
 	err = s.Connect(ctx, c.StorageDsn())
 	if err != nil {
 		logg.Error(err)
@@ -72,6 +73,9 @@ func main() {
 		os.Exit(1)
 	}
 	publisher = producer.New(c.RabbitExchange(), c.RabbitExchangeType(), conn)
+	n := newNotifier(func(ctx context.Context, notification storage.Notification) error {
+		return publish(ctx, notification, c.RabbitRoutingKey())
+	})
 
 	ctx, cancel := signal.NotifyContext(ctx,
 		syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
@@ -81,28 +85,33 @@ func main() {
 		defer cancel()
 		timer := time.NewTicker(time.Duration(c.SchedulerInterval()) * time.Second)
 		timerForClear := time.NewTicker(time.Duration(c.ClearStorageInterval()) * time.Second)
+		retention := time.Duration(c.TrashRetention() * float64(time.Second))
 		for {
 			select {
 			case <-ctx.Done():
 				return
 			case <-timer.C:
-				events, err := s.EventsForNotification(ctx)
+				reminders, err := s.RemindersForNotification(ctx)
 				if err != nil {
 					logg.Error(err)
 					continue
 				}
-				ids, err := publishEvents(ctx, events, c.RabbitRoutingKey())
+				sent, err := n.publishReminders(ctx, reminders)
 				if err != nil {
 					logg.Error(err)
-					continue
 				}
-				if err := s.ClearNotificationDates(ctx, ids); err != nil {
+				if err := s.MarkRemindersSent(ctx, sent); err != nil {
 					logg.Error(err)
+					continue
 				}
+				n.forget(sent)
 			case <-timerForClear.C:
 				if err := s.ClearOldEvents(ctx); err != nil {
 					logg.Error(err)
 				}
+				if err := s.PurgeDeleted(ctx, time.Now().Add(-retention)); err != nil {
+					logg.Error(err)
+				}
 			}
 		}
 	}()
@@ -112,20 +121,8 @@ func main() {
 	logg.Info("scheduler is shutdown...")
 }
 
-func publishEvents(ctx context.Context, events []storage.Event, routingKey string) ([]string, error) {
-	eventsForClearNotification := make([]string, 0)
-	for _, event := range events {
-		if err := publish(ctx, event, routingKey); err != nil {
-			return nil, err
-		}
-		eventsForClearNotification = append(eventsForClearNotification, event.ID)
-	}
-
-	return eventsForClearNotification, nil
-}
-
-func publish(ctx context.Context, event storage.Event, routingKey string) error {
-	t, err := json.Marshal(event)
+func publish(ctx context.Context, notification storage.Notification, routingKey string) error {
+	t, err := json.Marshal(notification)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+// notifier публикует уведомления о напоминаниях. Отправка отмечается в хранилище
+// для напоминания целиком, поэтому участники, которым уведомление уже ушло,
+// запоминаются до отметки, и при сбое на следующем участнике не получают его повторно.
+type notifier struct {
+	publish func(ctx context.Context, notification storage.Notification) error
+	sent    map[string]struct{}
+}
+
+func newNotifier(publish func(ctx context.Context, notification storage.Notification) error) *notifier {
+	return &notifier{publish: publish, sent: make(map[string]struct{})}
+}
+
+// publishReminders публикует по одному уведомлению каждому участнику события
+// и возвращает напоминания, уведомления по которым отправлены полностью.
+func (n *notifier) publishReminders(ctx context.Context, reminders []storage.Reminder) ([]storage.Reminder, error) {
+	sent := make([]storage.Reminder, 0, len(reminders))
+	for _, r := range reminders {
+		for _, notification := range r.Notifications() {
+			key := notificationKey(notification)
+			if _, ok := n.sent[key]; ok {
+				continue
+			}
+
+			if err := n.publish(ctx, notification); err != nil {
+				return sent, err
+			}
+			n.sent[key] = struct{}{}
+		}
+		sent = append(sent, r)
+	}
+
+	return sent, nil
+}
+
+// forget удаляет участников напоминаний, отправка которых отмечена в хранилище.
+func (n *notifier) forget(reminders []storage.Reminder) {
+	for _, r := range reminders {
+		for _, notification := range r.Notifications() {
+			delete(n.sent, notificationKey(notification))
+		}
+	}
+}
+
+// notificationKey различает уведомления по событию, времени срабатывания и получателю.
+func notificationKey(n storage.Notification) string {
+	return n.ID + ":" + strconv.FormatInt(n.Date.UnixNano(), 10) + ":" + n.UserID
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_PublishReminders(t *testing.T) {
+	ctx := context.Background()
+	event := storage.Event{
+		ID:       "7c0c7f4e-4b8a-4f4e-9d55-4f6f8a1c2b3d",
+		Title:    "Планерка",
+		StartAt:  time.Date(2024, 9, 2, 10, 0, 0, 0, time.UTC),
+		AuthorID: "512b922c-822a-4a05-b52b-85b85ab7a00c",
+		Attendees: []storage.Attendee{
+			{UserID: "7c2e4b1a-0d3f-4e5a-9b6c-8d7e6f5a4b3d", Status: storage.RSVPAccepted},
+			{UserID: "3f8d2c6e-1b4a-4c9d-8e7f-6a5b4c3d2e1f", Status: storage.RSVPNeedsAction},
+		},
+	}
+	event.EndAt = event.StartAt.Add(time.Hour)
+	reminders := []storage.Reminder{
+		{Event: event, Offset: time.Hour},
+		{Event: event, Offset: 10 * time.Minute},
+	}
+
+	// Публикация третьего уведомления завершается ошибкой.
+	published := make([]string, 0)
+	calls, failOn := 0, 3
+	n := newNotifier(func(_ context.Context, notification storage.Notification) error {
+		calls++
+		if calls == failOn {
+			return errors.New("брокер недоступен")
+		}
+		published = append(published, notificationKey(notification))
+
+		return nil
+	})
+
+	sent, err := n.publishReminders(ctx, reminders)
+	require.Error(t, err)
+	require.Equal(t, 0, len(sent))
+	require.Equal(t, 2, len(published))
+
+	// Следующая попытка не повторяет уже отправленные уведомления.
+	sent, err = n.publishReminders(ctx, reminders)
+	require.NoError(t, err)
+	require.Equal(t, reminders, sent)
+	require.Equal(t, 6, len(published))
+	require.ElementsMatch(t, published, uniq(published))
+
+	// После отметки в хранилище напоминание снова отправляется целиком.
+	n.forget(sent)
+	_, err = n.publishReminders(ctx, reminders[:1])
+	require.NoError(t, err)
+	require.Equal(t, 9, len(published))
+}
+
+func uniq(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			result = append(result, v)
+		}
+	}
+
+	return result
+}
@@ -2,124 +2,643 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/logger"
 	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
 	"github.com/google/uuid"
 )
 
 type App interface {
-	CreateEvent(
+	CreateEvent(ctx context.Context, authorID string, input EventInput) (storage.Event, error)
+	UpdateEvent(ctx context.Context, userID string, id string, input EventInput, version int64) (storage.Event, error)
+	DeleteEvent(ctx context.Context, userID string, id string, version int64) error
+	GetEvent(ctx context.Context, userID string, id string) (storage.Event, error)
+	RestoreEvent(ctx context.Context, userID string, id string) (storage.Event, error)
+	ListDeleted(ctx context.Context, userID string) ([]storage.Event, error)
+	EventHistory(ctx context.Context, userID string, id string) ([]storage.AuditRecord, error)
+	InviteAttendees(ctx context.Context, userID string, id string, attendees []storage.Attendee) (storage.Event, error)
+	RespondInvitation(ctx context.Context, userID string, id string, status storage.RSVPStatus) (storage.Event, error)
+	EventByDay(
 		ctx context.Context,
-		title string,
-		startAt time.Time,
+		userID string,
+		calendarID string,
+		tag string,
+		day time.Time,
+	) ([]storage.Event, error)
+	EventByWeek(
+		ctx context.Context,
+		userID string,
+		calendarID string,
+		tag string,
+		day time.Time,
+		aligned bool,
+	) ([]storage.Event, error)
+	EventByMonth(
+		ctx context.Context,
+		userID string,
+		calendarID string,
+		tag string,
+		day time.Time,
+		aligned bool,
+	) ([]storage.Event, error)
+	ListEvents(ctx context.Context, userID string, filter storage.ListFilter) (storage.Page, error)
+	SearchEvents(ctx context.Context, userID string, filter storage.SearchFilter) ([]storage.SearchResult, error)
+	EventsForNotification(ctx context.Context) ([]storage.Notification, error)
+	ImportEvents(ctx context.Context, userID string, events []storage.Event) []ImportResult
+	FreeBusy(
+		ctx context.Context,
+		userID string,
+		targetID string,
+		from time.Time,
+		to time.Time,
 		duration time.Duration,
-		description string,
-		authorID string,
-		NotificationAt time.Time,
-	) error
-	UpdateEvent(
+	) (FreeBusy, error)
+	CreateCalendar(ctx context.Context, userID string, name string) (storage.Calendar, error)
+	UpdateCalendar(ctx context.Context, userID string, id string, name string) (storage.Calendar, error)
+	DeleteCalendar(ctx context.Context, userID string, id string) error
+	GetCalendar(ctx context.Context, userID string, id string) (storage.Calendar, error)
+	ListCalendars(ctx context.Context, userID string) ([]storage.Calendar, error)
+	ShareCalendar(ctx context.Context, userID string, id string, share storage.CalendarShare) (storage.Calendar, error)
+	UnshareCalendar(ctx context.Context, userID string, id string, targetID string) (storage.Calendar, error)
+	CreateResource(
 		ctx context.Context,
+		userID string,
+		name string,
+		kind storage.ResourceKind,
+		capacity int,
+	) (storage.Resource, error)
+	UpdateResource(
+		ctx context.Context,
+		userID string,
 		id string,
-		title string,
-		startAt time.Time,
-		duration time.Duration,
-		description string,
-		authorID string,
-		NotificationAt time.Time,
-	) error
-	DeleteEvent(ctx context.Context, id string) error
-	EventByDay(ctx context.Context, day time.Time) ([]storage.Event, error)
-	EventByWeek(ctx context.Context, day time.Time) ([]storage.Event, error)
-	EventByMonth(ctx context.Context, day time.Time) ([]storage.Event, error)
-	EventsForNotification(ctx context.Context) ([]storage.Notification, error)
+		name string,
+		kind storage.ResourceKind,
+		capacity int,
+	) (storage.Resource, error)
+	DeleteResource(ctx context.Context, userID string, id string) error
+	GetResource(ctx context.Context, userID string, id string) (storage.Resource, error)
+	ListResources(ctx context.Context, userID string) ([]storage.Resource, error)
+	ResourceAvailability(ctx context.Context, userID string, id string, day time.Time) (FreeBusy, error)
+}
+
+// MaxListRange ограничивает интервал выборки событий: повторения разворачиваются
+// на весь интервал.
+const MaxListRange = 366 * 24 * time.Hour
+
+var (
+	ErrUserNotSpecified = errors.New("не указан пользователь")
+	ErrInvalidUserID    = errors.New("невалидный идентификатор пользователя")
+	ErrInvalidRange     = errors.New("невалидный интервал времени")
+	ErrInvalidLimit     = errors.New("невалидный размер страницы")
+)
+
+// ImportResult результат импорта одного события, UID - идентификатор события во внешнем календаре.
+type ImportResult struct {
+	UID   string
+	Title string
+	Err   error
 }
 
 type app struct {
-	storage storage.Storage
+	storage       storage.Storage
+	logger        logger.Logger
+	firstWeekday  time.Weekday
+	locationRooms bool
 }
 
-func New(storage storage.Storage) App {
-	return &app{
-		storage: storage,
+// Option настройка приложения.
+type Option func(*app)
+
+// WithFirstWeekday задает первый день календарной недели, по умолчанию понедельник.
+func WithFirstWeekday(day time.Weekday) Option {
+	return func(a *app) {
+		a.firstWeekday = day
 	}
 }
 
-func (a *app) CreateEvent(
-	ctx context.Context,
-	title string,
-	startAt time.Time,
-	duration time.Duration,
-	description string,
-	authorID string,
-	notificationAt time.Time,
-) error {
-	id := uuid.NewString()
-	return a.storage.CreateEvent(ctx, storage.Event{
-		ID:               id,
-		Title:            title,
-		StartAt:          startAt,
-		EndAt:            startAt.Add(duration),
-		Description:      description,
-		AuthorID:         authorID,
-		NotificationDate: notificationAt,
-	})
+// WithLocationRooms включает бронирование переговорной, название которой
+// совпадает с местом проведения события. По умолчанию выключено: произвольное
+// место вроде "Кухня" не должно занимать одноименный ресурс.
+func WithLocationRooms(enabled bool) Option {
+	return func(a *app) {
+		a.locationRooms = enabled
+	}
 }
 
+// WithLogger задает логгер для ошибок, которые не возвращаются клиенту,
+// например ошибок записи в журнал изменений.
+func WithLogger(l logger.Logger) Option {
+	return func(a *app) {
+		a.logger = l
+	}
+}
+
+func New(storage storage.Storage, opts ...Option) App {
+	a := &app{
+		storage:      storage,
+		firstWeekday: time.Monday,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// CreateEvent создает событие из input. В чужой календарь автор должен иметь право записи.
+func (a *app) CreateEvent(ctx context.Context, authorID string, input EventInput) (storage.Event, error) {
+	authorID, err := normalizeUserID(authorID)
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	if input, err = input.normalize(); err != nil {
+		return storage.Event{}, err
+	}
+
+	if input.Resources, err = a.placeResources(ctx, input, storage.Location{}); err != nil {
+		return storage.Event{}, err
+	}
+
+	if input.CalendarID, err = a.writableCalendar(ctx, authorID, input.CalendarID); err != nil {
+		return storage.Event{}, err
+	}
+
+	event := input.event(uuid.NewString(), authorID)
+	if err := a.storage.CreateEvent(ctx, event); err != nil {
+		return storage.Event{}, err
+	}
+	event.Version = storage.InitialVersion
+
+	a.record(ctx, storage.AuditCreate, authorID, nil, &event)
+
+	return event, nil
+}
+
+// UpdateEvent заменяет поля события на input, если текущая версия события
+// совпадает с version (0 - без проверки), и возвращает сохраненное событие
+// с новой версией. Изменять событие может автор или пользователь с правом записи
+// в календарь события, автор события при этом не меняется. Перенести событие
+// в личный календарь (пустой CalendarID) может только автор.
 func (a *app) UpdateEvent(
 	ctx context.Context,
+	userID string,
 	id string,
-	title string,
-	startAt time.Time,
-	duration time.Duration,
-	description string,
-	authorID string,
-	notificationAt time.Time,
-) error {
-	return a.storage.UpdateEvent(ctx, storage.Event{
-		ID:               id,
-		Title:            title,
-		StartAt:          startAt,
-		EndAt:            startAt.Add(duration),
-		Description:      description,
-		AuthorID:         authorID,
-		NotificationDate: notificationAt,
-	})
+	input EventInput,
+	version int64,
+) (storage.Event, error) {
+	userID, err := normalizeUserID(userID)
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	if input, err = input.normalize(); err != nil {
+		return storage.Event{}, err
+	}
+
+	before, err := a.GetEvent(ctx, userID, id)
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	authorID, err := a.eventAuthor(ctx, userID, before)
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	if input.Resources, err = a.placeResources(ctx, input, before.Place); err != nil {
+		return storage.Event{}, err
+	}
+
+	if input.CalendarID, err = a.writableCalendar(ctx, userID, input.CalendarID); err != nil {
+		return storage.Event{}, err
+	}
+
+	if input.CalendarID == "" && authorID != userID {
+		return storage.Event{}, storage.ErrAccessDenied
+	}
+
+	event := input.event(before.ID, authorID)
+	event.Version = version
+	if err := a.storage.UpdateEvent(ctx, event); err != nil {
+		return storage.Event{}, err
+	}
+
+	after, err := a.storage.GetEvent(ctx, authorID, before.ID)
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	a.record(ctx, storage.AuditUpdate, userID, &before, &after)
+
+	return after, nil
 }
 
-func (a *app) DeleteEvent(ctx context.Context, id string) error {
-	return a.storage.DeleteEvent(ctx, id)
+// DeleteEvent перемещает событие в корзину, если его текущая версия совпадает с version (0 - без проверки).
+// Удалить событие может автор или пользователь с правом записи в календарь события.
+func (a *app) DeleteEvent(ctx context.Context, userID string, id string, version int64) error {
+	userID, err := normalizeUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	before, err := a.GetEvent(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	authorID, err := a.eventAuthor(ctx, userID, before)
+	if err != nil {
+		return err
+	}
+
+	if err := a.storage.DeleteEvent(ctx, authorID, before.ID, version); err != nil {
+		return err
+	}
+
+	a.record(ctx, storage.AuditDelete, userID, &before, nil)
+
+	return nil
 }
 
-func (a *app) EventByDay(ctx context.Context, day time.Time) ([]storage.Event, error) {
-	return a.storage.EventsDay(ctx, day)
+// GetEvent возвращает событие пользователя. Идентификатор, не являющийся UUID,
+// не может принадлежать событию, поэтому для него возвращается storage.ErrEventNotFound.
+func (a *app) GetEvent(ctx context.Context, userID string, id string) (storage.Event, error) {
+	userID, err := normalizeUserID(userID)
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	eventID, err := uuid.Parse(id)
+	if err != nil {
+		return storage.Event{}, storage.ErrEventNotFound
+	}
+
+	return a.storage.GetEvent(ctx, userID, eventID.String())
 }
 
-func (a *app) EventByWeek(ctx context.Context, day time.Time) ([]storage.Event, error) {
-	return a.storage.EventsWeek(ctx, day)
+// RestoreEvent достает событие из корзины и возвращает его с новой версией.
+func (a *app) RestoreEvent(ctx context.Context, userID string, id string) (storage.Event, error) {
+	userID, err := normalizeUserID(userID)
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	eventID, err := uuid.Parse(id)
+	if err != nil {
+		return storage.Event{}, storage.ErrEventNotFound
+	}
+
+	if err := a.storage.RestoreEvent(ctx, userID, eventID.String()); err != nil {
+		return storage.Event{}, err
+	}
+
+	after, err := a.storage.GetEvent(ctx, userID, eventID.String())
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	a.record(ctx, storage.AuditRestore, userID, nil, &after)
+
+	return after, nil
 }
 
-func (a *app) EventByMonth(ctx context.Context, day time.Time) ([]storage.Event, error) {
-	return a.storage.EventsMonth(ctx, day)
+// ListDeleted возвращает события пользователя из корзины.
+func (a *app) ListDeleted(ctx context.Context, userID string) ([]storage.Event, error) {
+	userID, err := normalizeUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.storage.ListDeleted(ctx, userID)
 }
 
-func (a *app) EventsForNotification(ctx context.Context) ([]storage.Notification, error) {
-	events, err := a.storage.EventsForNotification(ctx)
+// InviteAttendees приглашает участников на событие пользователя. Роль по
+// умолчанию - required, автор события не может быть приглашен.
+func (a *app) InviteAttendees(
+	ctx context.Context,
+	userID string,
+	id string,
+	attendees []storage.Attendee,
+) (storage.Event, error) {
+	userID, err := normalizeUserID(userID)
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	if len(attendees) == 0 {
+		return storage.Event{}, storage.ErrInvalidAttendee
+	}
+
+	invited := make([]storage.Attendee, 0, len(attendees))
+	for _, v := range attendees {
+		attendeeID, err := normalizeUserID(v.UserID)
+		if err != nil {
+			return storage.Event{}, err
+		}
+
+		if attendeeID == userID {
+			return storage.Event{}, storage.ErrInvalidAttendee
+		}
+
+		if v.Role == "" {
+			v.Role = storage.RoleRequired
+		}
+		if err := v.Role.Validate(); err != nil {
+			return storage.Event{}, err
+		}
+
+		invited = append(invited, storage.Attendee{UserID: attendeeID, Role: v.Role})
+	}
+
+	before, err := a.GetEvent(ctx, userID, id)
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	if err := a.storage.InviteAttendees(ctx, userID, before.ID, invited); err != nil {
+		return storage.Event{}, err
+	}
+
+	after, err := a.storage.GetEvent(ctx, userID, before.ID)
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	a.record(ctx, storage.AuditUpdate, userID, &before, &after)
+
+	return after, nil
+}
+
+// RespondInvitation сохраняет ответ приглашенного пользователя.
+func (a *app) RespondInvitation(
+	ctx context.Context,
+	userID string,
+	id string,
+	status storage.RSVPStatus,
+) (storage.Event, error) {
+	userID, err := normalizeUserID(userID)
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	if err := status.Validate(); err != nil {
+		return storage.Event{}, err
+	}
+
+	before, err := a.GetEvent(ctx, userID, id)
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	if err := a.storage.RespondInvitation(ctx, userID, before.ID, status); err != nil {
+		return storage.Event{}, err
+	}
+
+	after, err := a.storage.GetEvent(ctx, userID, before.ID)
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	a.record(ctx, storage.AuditUpdate, userID, &before, &after)
+
+	return after, nil
+}
+
+// EventHistory возвращает журнал изменений события от старых записей к новым.
+// Журнал доступен тем же пользователям, что и событие: автору, участникам и
+// пользователям с доступом к календарю события, в том числе после его удаления.
+func (a *app) EventHistory(ctx context.Context, userID string, id string) ([]storage.AuditRecord, error) {
+	userID, err := normalizeUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	eventID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, storage.ErrEventNotFound
+	}
+
+	records, err := a.storage.EventHistory(ctx, eventID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, storage.ErrEventNotFound
+	}
+
+	if !a.canRead(ctx, userID, *records[len(records)-1].Snapshot()) {
+		return nil, storage.ErrAccessDenied
+	}
+
+	return records, nil
+}
+
+// EventByDay возвращает события за сутки, содержащие day. Границы суток
+// определяются часовым поясом day, так же как в EventByWeek и EventByMonth.
+// Непустой calendarID ограничивает выборку событиями календаря, непустой tag -
+// событиями с этим тегом.
+func (a *app) EventByDay(
+	ctx context.Context,
+	userID string,
+	calendarID string,
+	tag string,
+	day time.Time,
+) ([]storage.Event, error) {
+	from, to := storage.DayRange(day)
+
+	return a.periodEvents(ctx, userID, calendarID, tag, from, to)
+}
+
+// EventByWeek возвращает события за 7 суток начиная с day, а при aligned -
+// за календарную неделю, содержащую day.
+func (a *app) EventByWeek(
+	ctx context.Context,
+	userID string,
+	calendarID string,
+	tag string,
+	day time.Time,
+	aligned bool,
+) ([]storage.Event, error) {
+	if aligned {
+		day = storage.StartOfWeek(day, a.firstWeekday)
+	}
+	from, to := storage.WeekRange(day)
+
+	return a.periodEvents(ctx, userID, calendarID, tag, from, to)
+}
+
+// EventByMonth возвращает события за месяц начиная с day, а при aligned -
+// за календарный месяц, содержащий day.
+func (a *app) EventByMonth(
+	ctx context.Context,
+	userID string,
+	calendarID string,
+	tag string,
+	day time.Time,
+	aligned bool,
+) ([]storage.Event, error) {
+	if aligned {
+		day = storage.StartOfMonth(day)
+	}
+	from, to := storage.MonthRange(day)
+
+	return a.periodEvents(ctx, userID, calendarID, tag, from, to)
+}
+
+// periodEvents возвращает все события, пересекающиеся с интервалом [from, to),
+// собирая страницы ListEvents.
+func (a *app) periodEvents(
+	ctx context.Context,
+	userID string,
+	calendarID string,
+	tag string,
+	from time.Time,
+	to time.Time,
+) ([]storage.Event, error) {
+	tag, err := tagFilter(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	list := func(ctx context.Context, filter storage.ListFilter) (storage.Page, error) {
+		return a.ListEvents(ctx, userID, filter)
+	}
+	filter := storage.ListFilter{CalendarID: calendarID, From: from, To: to, Overlapping: true}
+	events, err := storage.CollectEvents(ctx, list, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return withTag(events, tag), nil
+}
+
+// ListEvents возвращает страницу событий пользователя, пользователь в фильтре
+// заменяется на текущего. Интервал не может быть длиннее MaxListRange, лимит
+// больше storage.MaxListLimit уменьшается до максимума.
+func (a *app) ListEvents(ctx context.Context, userID string, filter storage.ListFilter) (storage.Page, error) {
+	userID, calendarID, err := a.readableCalendar(ctx, userID, filter.CalendarID)
+	if err != nil {
+		return storage.Page{}, err
+	}
+
+	if !filter.To.After(filter.From) || filter.To.Sub(filter.From) > MaxListRange {
+		return storage.Page{}, ErrInvalidRange
+	}
+
+	if filter.Limit < 0 {
+		return storage.Page{}, ErrInvalidLimit
+	}
+
+	filter.UserID, filter.CalendarID = userID, calendarID
+
+	return a.storage.ListEvents(ctx, filter)
+}
+
+// SearchEvents ищет события, видимые пользователю, по словам в названии и
+// описании. Нулевые границы интервала не ограничивают выборку.
+func (a *app) SearchEvents(
+	ctx context.Context,
+	userID string,
+	filter storage.SearchFilter,
+) ([]storage.SearchResult, error) {
+	userID, err := normalizeUserID(userID)
 	if err != nil {
 		return nil, err
 	}
-	notifications := make([]storage.Notification, len(events), 0)
 
+	if len(storage.SearchTerms(filter.Query)) == 0 {
+		return nil, storage.ErrInvalidQuery
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.To.After(filter.From) {
+		return nil, ErrInvalidRange
+	}
+
+	if filter.Limit < 0 {
+		return nil, ErrInvalidLimit
+	}
+
+	filter.UserID, filter.Calendars = userID, nil
+
+	return a.storage.SearchEvents(ctx, filter)
+}
+
+// ImportEvents создает события по одному, ошибка создания одного события не прерывает импорт остальных.
+func (a *app) ImportEvents(ctx context.Context, userID string, events []storage.Event) []ImportResult {
+	results := make([]ImportResult, 0, len(events))
 	for _, event := range events {
-		notification := storage.Notification{
-			ID:       event.ID,
-			Title:    event.Title,
-			Date:     event.NotificationDate,
-			AuthorID: event.AuthorID,
-		}
+		_, err := a.CreateEvent(ctx, userID, EventInput{
+			Title:       event.Title,
+			StartAt:     event.StartAt,
+			TimeZone:    event.TimeZone,
+			Duration:    event.EndAt.Sub(event.StartAt),
+			AllDay:      event.AllDay,
+			Description: event.Description,
+			CalendarID:  event.CalendarID,
+			Reminders:   event.Reminders,
+			Tags:        event.Tags,
+			Color:       event.Color,
+			Location:    event.Place,
+			MeetingURL:  event.MeetingURL,
+			Resources:   event.Resources,
+			Recurrence:  event.Recurrence,
+		})
+
+		results = append(results, ImportResult{UID: event.ID, Title: event.Title, Err: err})
+	}
+
+	return results
+}
+
+// EventsForNotification возвращает уведомления о сработавших напоминаниях
+// автору и каждому участнику, не отклонившему приглашение.
+func (a *app) EventsForNotification(ctx context.Context) ([]storage.Notification, error) {
+	reminders, err := a.storage.RemindersForNotification(ctx)
+	if err != nil {
+		return nil, err
+	}
+	notifications := make([]storage.Notification, 0, len(reminders))
 
-		notifications = append(notifications, notification)
+	for _, r := range reminders {
+		notifications = append(notifications, r.Notifications()...)
 	}
 
 	return notifications, nil
 }
+
+// record добавляет в журнал изменение события, выполненное пользователем actorID.
+// Изменение к этому моменту уже сохранено, поэтому ошибка записи в журнал только
+// логируется: иначе клиент повторил бы успешный запрос и получил дубликат или ErrDateBusy.
+func (a *app) record(
+	ctx context.Context,
+	action storage.AuditAction,
+	actorID string,
+	before *storage.Event,
+	after *storage.Event,
+) {
+	record := storage.AuditRecord{Action: action, ActorID: actorID, At: time.Now(), Before: before, After: after}
+	record.EventID = record.Snapshot().ID
+
+	if err := a.storage.AddAuditRecord(ctx, record); err != nil && a.logger != nil {
+		a.logger.Error(fmt.Errorf("запись в журнал изменений события %s: %w", record.EventID, err))
+	}
+}
+
+// normalizeUserID приводит идентификатор пользователя к каноничному виду UUID.
+func normalizeUserID(userID string) (string, error) {
+	if userID == "" {
+		return "", ErrUserNotSpecified
+	}
+
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return "", ErrInvalidUserID
+	}
+
+	return id.String(), nil
+}
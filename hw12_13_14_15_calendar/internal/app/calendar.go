@@ -0,0 +1,241 @@
+package app
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+	"github.com/google/uuid"
+)
+
+// CreateCalendar создает календарь пользователя. Название не может быть пустым.
+func (a *app) CreateCalendar(ctx context.Context, userID string, name string) (storage.Calendar, error) {
+	userID, err := normalizeUserID(userID)
+	if err != nil {
+		return storage.Calendar{}, err
+	}
+
+	name, err = calendarName(name)
+	if err != nil {
+		return storage.Calendar{}, err
+	}
+
+	calendar := storage.Calendar{ID: uuid.NewString(), Name: name, OwnerID: userID}
+	if err := a.storage.CreateCalendar(ctx, calendar); err != nil {
+		return storage.Calendar{}, err
+	}
+
+	return calendar, nil
+}
+
+// UpdateCalendar переименовывает календарь, изменять календарь может только владелец.
+func (a *app) UpdateCalendar(ctx context.Context, userID string, id string, name string) (storage.Calendar, error) {
+	userID, id, err := calendarKey(userID, id)
+	if err != nil {
+		return storage.Calendar{}, err
+	}
+
+	name, err = calendarName(name)
+	if err != nil {
+		return storage.Calendar{}, err
+	}
+
+	if err := a.storage.UpdateCalendar(ctx, storage.Calendar{ID: id, Name: name, OwnerID: userID}); err != nil {
+		return storage.Calendar{}, err
+	}
+
+	return a.storage.GetCalendar(ctx, userID, id)
+}
+
+// DeleteCalendar удаляет календарь владельца. Календарь с событиями вне
+// корзины удалить нельзя.
+func (a *app) DeleteCalendar(ctx context.Context, userID string, id string) error {
+	userID, id, err := calendarKey(userID, id)
+	if err != nil {
+		return err
+	}
+
+	return a.storage.DeleteCalendar(ctx, userID, id)
+}
+
+// GetCalendar возвращает календарь, доступный пользователю.
+func (a *app) GetCalendar(ctx context.Context, userID string, id string) (storage.Calendar, error) {
+	userID, id, err := calendarKey(userID, id)
+	if err != nil {
+		return storage.Calendar{}, err
+	}
+
+	return a.storage.GetCalendar(ctx, userID, id)
+}
+
+// ListCalendars возвращает собственные и открытые пользователю календари.
+func (a *app) ListCalendars(ctx context.Context, userID string) ([]storage.Calendar, error) {
+	userID, err := normalizeUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.storage.ListCalendars(ctx, userID)
+}
+
+// ShareCalendar открывает пользователю share.UserID доступ к календарю или
+// меняет уровень доступа. Владелец не может открыть доступ самому себе.
+func (a *app) ShareCalendar(
+	ctx context.Context,
+	userID string,
+	id string,
+	share storage.CalendarShare,
+) (storage.Calendar, error) {
+	userID, id, err := calendarKey(userID, id)
+	if err != nil {
+		return storage.Calendar{}, err
+	}
+
+	if share.UserID, err = normalizeUserID(share.UserID); err != nil {
+		return storage.Calendar{}, err
+	}
+
+	if share.UserID == userID {
+		return storage.Calendar{}, storage.ErrInvalidShare
+	}
+
+	if err := share.Permission.Validate(); err != nil {
+		return storage.Calendar{}, err
+	}
+
+	if err := a.storage.ShareCalendar(ctx, userID, id, share); err != nil {
+		return storage.Calendar{}, err
+	}
+
+	return a.storage.GetCalendar(ctx, userID, id)
+}
+
+// UnshareCalendar закрывает пользователю targetID доступ к календарю.
+func (a *app) UnshareCalendar(
+	ctx context.Context,
+	userID string,
+	id string,
+	targetID string,
+) (storage.Calendar, error) {
+	userID, id, err := calendarKey(userID, id)
+	if err != nil {
+		return storage.Calendar{}, err
+	}
+
+	if targetID, err = normalizeUserID(targetID); err != nil {
+		return storage.Calendar{}, err
+	}
+
+	if err := a.storage.UnshareCalendar(ctx, userID, id, targetID); err != nil {
+		return storage.Calendar{}, err
+	}
+
+	return a.storage.GetCalendar(ctx, userID, id)
+}
+
+// eventAuthor возвращает автора события, от имени которого хранилище изменяет
+// событие. Пользователь, не являющийся автором, должен иметь право записи в
+// календарь события.
+func (a *app) eventAuthor(ctx context.Context, userID string, event storage.Event) (string, error) {
+	if event.AuthorID == userID {
+		return userID, nil
+	}
+
+	if event.CalendarID == "" {
+		return "", storage.ErrAccessDenied
+	}
+
+	calendar, err := a.storage.GetCalendar(ctx, userID, event.CalendarID)
+	if err != nil || !calendar.CanWrite(userID) {
+		return "", storage.ErrAccessDenied
+	}
+
+	return event.AuthorID, nil
+}
+
+// canRead сообщает, что пользователь может читать событие: он автор или
+// участник события либо имеет доступ к календарю события.
+func (a *app) canRead(ctx context.Context, userID string, event storage.Event) bool {
+	if event.IsVisibleTo(userID) {
+		return true
+	}
+
+	if event.CalendarID == "" {
+		return false
+	}
+
+	calendar, err := a.storage.GetCalendar(ctx, userID, event.CalendarID)
+
+	return err == nil && calendar.CanRead(userID)
+}
+
+// writableCalendar проверяет право записи пользователя в календарь и
+// возвращает каноничный идентификатор календаря. Пустой идентификатор
+// означает личный календарь.
+func (a *app) writableCalendar(ctx context.Context, userID string, calendarID string) (string, error) {
+	if calendarID == "" {
+		return "", nil
+	}
+
+	id, err := uuid.Parse(calendarID)
+	if err != nil {
+		return "", storage.ErrCalendarNotFound
+	}
+
+	calendar, err := a.storage.GetCalendar(ctx, userID, id.String())
+	if err != nil {
+		return "", err
+	}
+
+	if !calendar.CanWrite(userID) {
+		return "", storage.ErrCalendarAccessDenied
+	}
+
+	return calendar.ID, nil
+}
+
+// readableCalendar приводит пользователя к каноничному виду и проверяет, что
+// календарь calendarID ему доступен. Пустой calendarID не ограничивает выборку.
+func (a *app) readableCalendar(ctx context.Context, userID string, calendarID string) (string, string, error) {
+	if calendarID == "" {
+		userID, err := normalizeUserID(userID)
+
+		return userID, "", err
+	}
+
+	userID, calendarID, err := calendarKey(userID, calendarID)
+	if err != nil {
+		return "", "", err
+	}
+
+	if _, err := a.storage.GetCalendar(ctx, userID, calendarID); err != nil {
+		return "", "", err
+	}
+
+	return userID, calendarID, nil
+}
+
+// calendarKey приводит идентификаторы пользователя и календаря к каноничному
+// виду. Идентификатор, не являющийся UUID, не может принадлежать календарю.
+func calendarKey(userID string, id string) (string, string, error) {
+	userID, err := normalizeUserID(userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	calendarID, err := uuid.Parse(id)
+	if err != nil {
+		return "", "", storage.ErrCalendarNotFound
+	}
+
+	return userID, calendarID.String(), nil
+}
+
+func calendarName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", storage.ErrInvalidCalendar
+	}
+
+	return name, nil
+}
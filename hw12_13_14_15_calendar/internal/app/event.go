@@ -0,0 +1,85 @@
+package app
+
+import (
+	"time"
+
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+// EventInput поля события, которые задает пользователь при создании и изменении.
+// TimeZone - часовой пояс IANA, в котором разворачиваются повторения, Reminders -
+// смещения напоминаний до начала события, Tags и Color - категории и цвет события,
+// Location и MeetingURL - место проведения и ссылка на онлайн-встречу, Resources -
+// бронируемые ресурсы (см. WithLocationRooms). Событие на весь день (AllDay)
+// выравнивается по суткам часового пояса события. Пустой CalendarID означает
+// личный календарь автора.
+type EventInput struct {
+	Title       string
+	StartAt     time.Time
+	TimeZone    string
+	Duration    time.Duration
+	AllDay      bool
+	Description string
+	CalendarID  string
+	Reminders   []time.Duration
+	Tags        []string
+	Color       string
+	Location    storage.Location
+	MeetingURL  string
+	Resources   []string
+	Recurrence  *storage.Recurrence
+}
+
+// normalize проверяет поля события и приводит их к виду, в котором они хранятся.
+func (in EventInput) normalize() (EventInput, error) {
+	var err error
+	if in.Reminders, err = storage.NormalizeReminders(in.Reminders); err != nil {
+		return EventInput{}, err
+	}
+
+	if in.Tags, in.Color, err = eventTags(in.Tags, in.Color); err != nil {
+		return EventInput{}, err
+	}
+
+	if in.Location, in.MeetingURL, err = eventPlace(in.Location, in.MeetingURL); err != nil {
+		return EventInput{}, err
+	}
+
+	if in.Resources, err = eventResources(in.Resources); err != nil {
+		return EventInput{}, err
+	}
+
+	if _, err := storage.LoadLocation(in.TimeZone); err != nil {
+		return EventInput{}, err
+	}
+
+	if in.Recurrence != nil {
+		if err := in.Recurrence.Validate(); err != nil {
+			return EventInput{}, err
+		}
+	}
+
+	return in, nil
+}
+
+// event собирает событие с идентификатором id и автором authorID.
+func (in EventInput) event(id string, authorID string) storage.Event {
+	return storage.Event{
+		ID:          id,
+		Title:       in.Title,
+		StartAt:     in.StartAt,
+		EndAt:       in.StartAt.Add(in.Duration),
+		Description: in.Description,
+		AuthorID:    authorID,
+		Recurrence:  in.Recurrence,
+		CalendarID:  in.CalendarID,
+		TimeZone:    in.TimeZone,
+		Reminders:   in.Reminders,
+		Tags:        in.Tags,
+		Color:       in.Color,
+		Place:       in.Location,
+		MeetingURL:  in.MeetingURL,
+		Resources:   in.Resources,
+		AllDay:      in.AllDay,
+	}.Localize().AlignAllDay()
+}
@@ -0,0 +1,201 @@
+package app
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+// MaxFreeBusyRange ограничивает интервал запроса занятости.
+const MaxFreeBusyRange = 62 * 24 * time.Hour
+
+// Interval полуинтервал времени [Start, End).
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// FreeBusy занятость пользователя. Free содержит свободные промежутки не короче
+// запрошенной длительности, Next - первый свободный слот этой длительности.
+type FreeBusy struct {
+	Busy []Interval
+	Free []Interval
+	Next *Interval
+}
+
+// FreeBusy возвращает занятость пользователя targetID в интервале [from, to).
+// Если targetID не указан, возвращается занятость самого пользователя userID.
+// Чужая занятость доступна пользователям с общим календарем и участникам событий
+// userID в этом интервале. Названия и описания событий не раскрываются.
+func (a *app) FreeBusy(
+	ctx context.Context,
+	userID string,
+	targetID string,
+	from time.Time,
+	to time.Time,
+	duration time.Duration,
+) (FreeBusy, error) {
+	userID, err := normalizeUserID(userID)
+	if err != nil {
+		return FreeBusy{}, err
+	}
+
+	if targetID == "" {
+		targetID = userID
+	}
+	if targetID, err = normalizeUserID(targetID); err != nil {
+		return FreeBusy{}, err
+	}
+
+	if !to.After(from) || to.Sub(from) > MaxFreeBusyRange || duration < 0 {
+		return FreeBusy{}, ErrInvalidRange
+	}
+
+	if err := a.canSeeBusy(ctx, userID, targetID, from, to); err != nil {
+		return FreeBusy{}, err
+	}
+
+	events, err := a.eventsOverlapping(ctx, targetID, from, to)
+	if err != nil {
+		return FreeBusy{}, err
+	}
+
+	busy := mergeBusy(events, from, to)
+	free := freeSlots(busy, from, to, duration)
+	result := FreeBusy{Busy: busy, Free: free}
+	if len(free) > 0 {
+		next := Interval{Start: free[0].Start, End: free[0].Start.Add(duration)}
+		result.Next = &next
+	}
+
+	return result, nil
+}
+
+// canSeeBusy проверяет, что пользователь userID может узнать занятость targetID
+// в интервале [from, to): это он сам, у них есть общий календарь или targetID
+// участвует в событии userID, пересекающемся с интервалом.
+func (a *app) canSeeBusy(ctx context.Context, userID string, targetID string, from time.Time, to time.Time) error {
+	if targetID == userID {
+		return nil
+	}
+
+	calendars, err := a.storage.ListCalendars(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range calendars {
+		if c.CanRead(targetID) {
+			return nil
+		}
+	}
+
+	events, err := a.eventsOverlapping(ctx, userID, from, to)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		if e.IsVisibleTo(targetID) {
+			return nil
+		}
+	}
+
+	return storage.ErrAccessDenied
+}
+
+// eventsOverlapping собирает события из суточных выборок хранилища. Событие,
+// занимающее несколько суток, попадает в каждую из них и учитывается один раз.
+func (a *app) eventsOverlapping(
+	ctx context.Context,
+	userID string,
+	from time.Time,
+	to time.Time,
+) ([]storage.Event, error) {
+	day, _ := storage.DayRange(from)
+	result := make([]storage.Event, 0)
+	seen := make(map[string]struct{})
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		events, err := a.storage.EventsDay(ctx, userID, day)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range events {
+			// Повторения одного события имеют общий ID.
+			key := e.ID + e.StartAt.UTC().String()
+			if _, ok := seen[key]; ok || !e.IsBusyFor(userID) || !storage.Overlaps(e.StartAt, e.EndAt, from, to) {
+				continue
+			}
+			seen[key] = struct{}{}
+			result = append(result, e)
+		}
+	}
+
+	return result, nil
+}
+
+// mergeBusy объединяет пересекающиеся и смежные события и обрезает их по [from, to).
+// Событие нулевой длительности занимает наносекунду, как и в storage.Overlaps.
+func mergeBusy(events []storage.Event, from time.Time, to time.Time) []Interval {
+	intervals := make([]Interval, 0, len(events))
+	for _, e := range events {
+		end := e.EndAt
+		if !end.After(e.StartAt) {
+			end = e.StartAt.Add(time.Nanosecond)
+		}
+		intervals = append(intervals, Interval{Start: maxTime(e.StartAt, from), End: minTime(end, to)})
+	}
+
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].Start.Before(intervals[j].Start)
+	})
+
+	result := make([]Interval, 0, len(intervals))
+	for _, v := range intervals {
+		if n := len(result); n > 0 && !v.Start.After(result[n-1].End) {
+			result[n-1].End = maxTime(result[n-1].End, v.End)
+			continue
+		}
+		result = append(result, v)
+	}
+
+	return result
+}
+
+// freeSlots возвращает промежутки между занятыми интервалами длиной не меньше duration.
+func freeSlots(busy []Interval, from time.Time, to time.Time, duration time.Duration) []Interval {
+	result := make([]Interval, 0)
+	cursor := from
+	add := func(end time.Time) {
+		if end.After(cursor) && end.Sub(cursor) >= duration {
+			result = append(result, Interval{Start: cursor, End: end})
+		}
+	}
+
+	for _, b := range busy {
+		add(b.Start)
+		cursor = maxTime(cursor, b.End)
+	}
+	add(to)
+
+	return result
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+// eventPlace приводит место проведения и ссылку на онлайн-встречу к каноничному виду.
+func eventPlace(location storage.Location, meetingURL string) (storage.Location, string, error) {
+	location, err := storage.NormalizeLocation(location)
+	if err != nil {
+		return storage.Location{}, "", err
+	}
+
+	meetingURL, err = storage.NormalizeMeetingURL(meetingURL)
+	if err != nil {
+		return storage.Location{}, "", err
+	}
+
+	return location, meetingURL, nil
+}
+
+// placeResources при включенной WithLocationRooms добавляет к бронируемым ресурсам
+// переговорную, название которой совпадает с местом проведения без учета регистра,
+// и снимает бронь переговорной прежнего места проведения previous. Так два события
+// в одной общей переговорной конфликтуют по ресурсу, даже если ее не забронировали явно.
+func (a *app) placeResources(ctx context.Context, input EventInput, previous storage.Location) ([]string, error) {
+	if !a.locationRooms || input.Location.Text == "" && previous.Text == "" {
+		return input.Resources, nil
+	}
+
+	resources, err := a.storage.ListResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := input.Resources
+	for _, r := range resources {
+		if r.Kind != storage.ResourceRoom {
+			continue
+		}
+
+		switch {
+		case strings.EqualFold(r.Name, input.Location.Text):
+			result = append(result, r.ID)
+		case strings.EqualFold(r.Name, previous.Text):
+			result = slices.DeleteFunc(result, func(id string) bool { return id == r.ID })
+		}
+	}
+
+	return storage.NormalizeResources(result)
+}
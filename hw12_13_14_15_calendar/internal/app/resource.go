@@ -0,0 +1,156 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+	"github.com/google/uuid"
+)
+
+// CreateResource создает ресурс, пользователь становится его владельцем.
+func (a *app) CreateResource(
+	ctx context.Context,
+	userID string,
+	name string,
+	kind storage.ResourceKind,
+	capacity int,
+) (storage.Resource, error) {
+	userID, err := normalizeUserID(userID)
+	if err != nil {
+		return storage.Resource{}, err
+	}
+
+	resource := storage.Resource{
+		ID:       uuid.NewString(),
+		Name:     strings.TrimSpace(name),
+		Kind:     kind,
+		Capacity: capacity,
+		OwnerID:  userID,
+	}
+	if err := resource.Validate(); err != nil {
+		return storage.Resource{}, err
+	}
+
+	if err := a.storage.CreateResource(ctx, resource); err != nil {
+		return storage.Resource{}, err
+	}
+
+	return resource, nil
+}
+
+// UpdateResource изменяет название, тип и вместимость ресурса, изменять ресурс может только владелец.
+func (a *app) UpdateResource(
+	ctx context.Context,
+	userID string,
+	id string,
+	name string,
+	kind storage.ResourceKind,
+	capacity int,
+) (storage.Resource, error) {
+	userID, id, err := resourceKey(userID, id)
+	if err != nil {
+		return storage.Resource{}, err
+	}
+
+	resource := storage.Resource{
+		ID:       id,
+		Name:     strings.TrimSpace(name),
+		Kind:     kind,
+		Capacity: capacity,
+		OwnerID:  userID,
+	}
+	if err := resource.Validate(); err != nil {
+		return storage.Resource{}, err
+	}
+
+	if err := a.storage.UpdateResource(ctx, resource); err != nil {
+		return storage.Resource{}, err
+	}
+
+	return a.storage.GetResource(ctx, id)
+}
+
+// DeleteResource удаляет ресурс владельца. Ресурс, забронированный событиями
+// вне корзины, удалить нельзя.
+func (a *app) DeleteResource(ctx context.Context, userID string, id string) error {
+	userID, id, err := resourceKey(userID, id)
+	if err != nil {
+		return err
+	}
+
+	return a.storage.DeleteResource(ctx, userID, id)
+}
+
+func (a *app) GetResource(ctx context.Context, userID string, id string) (storage.Resource, error) {
+	_, id, err := resourceKey(userID, id)
+	if err != nil {
+		return storage.Resource{}, err
+	}
+
+	return a.storage.GetResource(ctx, id)
+}
+
+func (a *app) ListResources(ctx context.Context, userID string) ([]storage.Resource, error) {
+	if _, err := normalizeUserID(userID); err != nil {
+		return nil, err
+	}
+
+	return a.storage.ListResources(ctx)
+}
+
+// ResourceAvailability возвращает занятость ресурса в сутки day в часовом
+// поясе day. Названия и описания бронирующих событий не раскрываются.
+func (a *app) ResourceAvailability(
+	ctx context.Context,
+	userID string,
+	id string,
+	day time.Time,
+) (FreeBusy, error) {
+	_, id, err := resourceKey(userID, id)
+	if err != nil {
+		return FreeBusy{}, err
+	}
+
+	from, to := storage.DayRange(day)
+	events, err := a.storage.ResourceEvents(ctx, id, from, to)
+	if err != nil {
+		return FreeBusy{}, err
+	}
+
+	busy := mergeBusy(events, from, to)
+
+	return FreeBusy{Busy: busy, Free: freeSlots(busy, from, to, 0)}, nil
+}
+
+// eventResources приводит идентификаторы бронируемых ресурсов к каноничному
+// виду. Идентификатор, не являющийся UUID, не может принадлежать ресурсу.
+func eventResources(ids []string) ([]string, error) {
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		resourceID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, storage.ErrResourceNotFound
+		}
+
+		result = append(result, resourceID.String())
+	}
+
+	return storage.NormalizeResources(result)
+}
+
+// resourceKey приводит идентификаторы пользователя и ресурса к каноничному виду.
+func resourceKey(userID string, id string) (string, string, error) {
+	userID, err := normalizeUserID(userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	resourceID, err := uuid.Parse(id)
+	if err != nil {
+		return "", "", storage.ErrResourceNotFound
+	}
+
+	return userID, resourceID.String(), nil
+}
@@ -0,0 +1,43 @@
+package app
+
+import "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+
+// eventTags приводит теги и цвет события к каноничному виду.
+func eventTags(tags []string, color string) ([]string, string, error) {
+	tags, err := storage.NormalizeTags(tags)
+	if err != nil {
+		return nil, "", err
+	}
+
+	color, err = storage.NormalizeColor(color)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return tags, color, nil
+}
+
+// tagFilter приводит тег фильтра к каноничному виду, пустой тег не ограничивает выборку.
+func tagFilter(tag string) (string, error) {
+	if tag == "" {
+		return "", nil
+	}
+
+	return storage.NormalizeTag(tag)
+}
+
+// withTag оставляет события с тегом tag, пустой tag не ограничивает выборку.
+func withTag(events []storage.Event, tag string) []storage.Event {
+	if tag == "" {
+		return events
+	}
+
+	result := make([]storage.Event, 0, len(events))
+	for _, e := range events {
+		if e.HasTag(tag) {
+			result = append(result, e)
+		}
+	}
+
+	return result
+}
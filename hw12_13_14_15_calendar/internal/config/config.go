@@ -6,16 +6,13 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
-	memorystorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage/memory"
-	sqlstorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage/pgsql"
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
 	"github.com/BurntSushi/toml"
 )
 
-var (
-	ErrNotValidFormat     = errors.New("невалидный формат конфигурационного файла")
-	ErrInvalidStorageType = errors.New("невалидный тип хранилища")
-)
+var ErrNotValidFormat = errors.New("невалидный формат конфигурационного файла")
 
 const (
 	DefaultPathForLogger        = "/dev/stdout"
@@ -26,6 +23,10 @@ const (
 	DefaultConsumerName         = "calendar-consumer"
 	DefaultQueueName            = "calendar-queue"
 	DefaultClearStorageInterval = 60 * 60
+	DefaultStoragePoolSize      = 10
+	DefaultCompactInterval      = 5 * 60
+	DefaultTrashRetention       = 30 * 24 * 60 * 60
+	DefaultFirstWeekday         = "monday"
 )
 
 type configLogger struct {
@@ -44,6 +45,18 @@ type storage struct {
 	Dsn                  string  `json:"dsn" toml:"dsn"`
 	MigrationPath        string  `json:"migrationPath" toml:"migrationPath"`
 	ClearStorageInterval float64 `json:"clearStorageInterval" toml:"clearStorageInterval"`
+	PoolSize             int32   `json:"poolSize" toml:"poolSize"`
+	Path                 string  `json:"path" toml:"path"`
+	CompactInterval      float64 `json:"compactInterval" toml:"compactInterval"`
+	TrashRetention       float64 `json:"trashRetention" toml:"trashRetention"`
+}
+
+// calendar настройки календаря. FirstWeekday задается кодом RFC 5545 (MO)
+// или английским названием дня (monday). LocationRooms включает бронирование
+// переговорной, название которой совпадает с местом проведения события.
+type calendar struct {
+	FirstWeekday  string `json:"firstWeekday" toml:"firstWeekday"`
+	LocationRooms bool   `json:"locationRooms" toml:"locationRooms"`
 }
 
 type config struct {
@@ -51,6 +64,9 @@ type config struct {
 	Storage  storage      `json:"storage" toml:"storage"`
 	Server   server       `json:"server" toml:"server"`
 	RabbitMQ rabbitmq     `json:"rabbitMq" toml:"rabbitMq"`
+	Calendar calendar     `json:"calendar" toml:"calendar"`
+
+	firstWeekday time.Weekday
 }
 
 type rabbitmq struct {
@@ -75,6 +91,8 @@ type Config interface {
 	StorageDsn() string
 	MigrationPath() string
 	ClearStorageInterval() float64
+	TrashRetention() float64
+	StorageOptions() internalStorage.Options
 
 	RabbitAddr() string
 	RabbitExchange() string
@@ -83,6 +101,9 @@ type Config interface {
 	SchedulerInterval() float64
 	ConsumerName() string
 	QueueName() string
+
+	FirstWeekday() time.Weekday
+	LocationRooms() bool
 }
 
 func (c *config) LoggerLevel() string {
@@ -121,6 +142,20 @@ func (c *config) ClearStorageInterval() float64 {
 	return c.Storage.ClearStorageInterval
 }
 
+// TrashRetention возвращает время хранения событий в корзине в секундах.
+func (c *config) TrashRetention() float64 {
+	return c.Storage.TrashRetention
+}
+
+func (c *config) StorageOptions() internalStorage.Options {
+	return internalStorage.Options{
+		Dsn:             c.Storage.Dsn,
+		PoolSize:        c.Storage.PoolSize,
+		Path:            c.Storage.Path,
+		CompactInterval: time.Duration(c.Storage.CompactInterval * float64(time.Second)),
+	}
+}
+
 func (c *config) RabbitAddr() string {
 	return c.RabbitMQ.Addr
 }
@@ -149,6 +184,16 @@ func (c *config) QueueName() string {
 	return c.RabbitMQ.QueueName
 }
 
+// FirstWeekday возвращает первый день недели для выборок по календарной неделе.
+func (c *config) FirstWeekday() time.Weekday {
+	return c.firstWeekday
+}
+
+// LocationRooms сообщает, что место проведения события бронирует одноименную переговорную.
+func (c *config) LocationRooms() bool {
+	return c.Calendar.LocationRooms
+}
+
 func NewConfig(path string, format string) (Config, error) {
 	var err error
 	cfg := &config{}
@@ -200,13 +245,15 @@ func newFromToml(value []byte) (*config, error) {
 	return c, nil
 }
 
+// validate разбирает первый день недели. Тип хранилища проверяют команды,
+// которые его открывают: хранилища регистрируются при импорте своих пакетов,
+// например internal/storage/all, и sender их не импортирует.
 func (c *config) validate() error {
-	switch c.StorageType() {
-	case memorystorage.Type, sqlstorage.Type:
-		break
-	default:
-		return ErrInvalidStorageType
+	firstWeekday, err := internalStorage.ParseWeekday(c.Calendar.FirstWeekday)
+	if err != nil {
+		return err
 	}
+	c.firstWeekday = firstWeekday
 
 	return nil
 }
@@ -222,6 +269,10 @@ func (c *config) setDefaultValues() {
 
 	if c.Storage.MigrationPath == "" {
 		c.Storage.MigrationPath = DefaultMigrationPath
+		backend, err := internalStorage.Lookup(c.Storage.StorageType)
+		if err == nil && backend.MigrationPath != "" {
+			c.Storage.MigrationPath = backend.MigrationPath
+		}
 	}
 
 	if c.RabbitMQ.ExchangeType == "" {
@@ -243,6 +294,22 @@ func (c *config) setDefaultValues() {
 	if c.Storage.ClearStorageInterval == 0 {
 		c.Storage.ClearStorageInterval = DefaultClearStorageInterval
 	}
+
+	if c.Storage.PoolSize == 0 {
+		c.Storage.PoolSize = DefaultStoragePoolSize
+	}
+
+	if c.Storage.CompactInterval == 0 {
+		c.Storage.CompactInterval = DefaultCompactInterval
+	}
+
+	if c.Storage.TrashRetention == 0 {
+		c.Storage.TrashRetention = DefaultTrashRetention
+	}
+
+	if c.Calendar.FirstWeekday == "" {
+		c.Calendar.FirstWeekday = DefaultFirstWeekday
+	}
 }
 
 func ParseFormatFile(path string) string {
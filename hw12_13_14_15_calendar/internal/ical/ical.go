@@ -0,0 +1,530 @@
+package ical
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+const (
+	ContentType = "text/calendar; charset=utf-8"
+
+	prodID         = "-//Al-Sher//Calendar//RU"
+	dateTimeLayout = "20060102T150405Z"
+	localLayout    = "20060102T150405"
+	dateLayout     = "20060102"
+	maxLineLength  = 75
+)
+
+var (
+	ErrInvalidCalendar = errors.New("невалидный формат iCalendar")
+	ErrInvalidDuration = errors.New("невалидная длительность")
+)
+
+// Encode формирует VCALENDAR из списка событий. Повторения событий
+// выгружаются отдельными VEVENT без RRULE.
+func Encode(w io.Writer, events []storage.Event) error {
+	b := &bytes.Buffer{}
+	now := time.Now()
+
+	writeLine(b, "BEGIN:VCALENDAR")
+	writeLine(b, "VERSION:2.0")
+	writeLine(b, "PRODID:"+prodID)
+	writeLine(b, "CALSCALE:GREGORIAN")
+
+	for _, e := range events {
+		writeLine(b, "BEGIN:VEVENT")
+		writeLine(b, "UID:"+uid(e))
+		writeLine(b, "DTSTAMP:"+formatDateTime(now))
+		if e.AllDay {
+			writeLine(b, "DTSTART;VALUE=DATE:"+e.StartAt.Format(dateLayout))
+			writeLine(b, "DTEND;VALUE=DATE:"+e.EndAt.Format(dateLayout))
+		} else {
+			writeLine(b, "DTSTART:"+formatDateTime(e.StartAt))
+			writeLine(b, "DTEND:"+formatDateTime(e.EndAt))
+		}
+		writeLine(b, "SUMMARY:"+escape(e.Title))
+		if e.Description != "" {
+			writeLine(b, "DESCRIPTION:"+escape(e.Description))
+		}
+		if len(e.Tags) > 0 {
+			writeLine(b, "CATEGORIES:"+escapeList(e.Tags))
+		}
+		if e.Place.Text != "" {
+			writeLine(b, "LOCATION:"+escape(e.Place.Text))
+		}
+		if c := e.Place.Coordinates; c != nil {
+			writeLine(b, "GEO:"+formatGeo(*c))
+		}
+		if e.MeetingURL != "" {
+			writeLine(b, "CONFERENCE;VALUE=URI:"+e.MeetingURL)
+		}
+
+		for _, offset := range e.Reminders {
+			writeLine(b, "BEGIN:VALARM")
+			writeLine(b, "ACTION:DISPLAY")
+			writeLine(b, "DESCRIPTION:"+escape(e.Title))
+			writeLine(b, "TRIGGER:"+FormatDuration(-offset))
+			writeLine(b, "END:VALARM")
+		}
+		writeLine(b, "END:VEVENT")
+	}
+
+	writeLine(b, "END:VCALENDAR")
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// Decode разбирает VEVENT из VCALENDAR. В поле ID возвращается UID события.
+func Decode(r io.Reader) ([]storage.Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]storage.Event, 0)
+	var (
+		current    *vevent
+		inCalendar bool
+		inAlarm    bool
+	)
+
+	for _, line := range lines {
+		name, params, value, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case name == "BEGIN" && value == "VCALENDAR":
+			inCalendar = true
+		case name == "END" && value == "VCALENDAR":
+			inCalendar = false
+		case !inCalendar:
+			return nil, fmt.Errorf("%w: свойство %s вне VCALENDAR", ErrInvalidCalendar, name)
+		case name == "BEGIN" && value == "VEVENT":
+			current = &vevent{}
+		case name == "END" && value == "VEVENT":
+			if current == nil {
+				return nil, fmt.Errorf("%w: END:VEVENT без BEGIN", ErrInvalidCalendar)
+			}
+			event, err := current.build()
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+			current = nil
+		case name == "BEGIN" && value == "VALARM":
+			inAlarm = true
+		case name == "END" && value == "VALARM":
+			inAlarm = false
+		case current == nil:
+			continue
+		case inAlarm:
+			if name == "TRIGGER" {
+				err = current.setTrigger(params, value)
+			}
+		default:
+			err = current.setProperty(name, params, value)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if current != nil {
+		return nil, fmt.Errorf("%w: VEVENT не закрыт", ErrInvalidCalendar)
+	}
+
+	return events, nil
+}
+
+// vevent собирает событие из свойств VEVENT. Абсолютное время VALARM
+// пересчитывается в смещение после разбора DTSTART.
+type vevent struct {
+	event    storage.Event
+	duration time.Duration
+	hasEnd   bool
+	triggers []time.Duration
+	alarms   []time.Time
+}
+
+func (v *vevent) build() (storage.Event, error) {
+	if v.event.StartAt.IsZero() {
+		return storage.Event{}, fmt.Errorf("%w: не указан DTSTART", ErrInvalidCalendar)
+	}
+
+	if !v.hasEnd {
+		v.event.EndAt = v.event.StartAt.Add(v.duration)
+	}
+
+	for _, t := range v.alarms {
+		v.triggers = append(v.triggers, t.Sub(v.event.StartAt))
+	}
+	// Напоминания после начала события не поддерживаются.
+	for _, trigger := range v.triggers {
+		if trigger <= 0 {
+			v.event.Reminders = append(v.event.Reminders, -trigger)
+		}
+	}
+
+	reminders, err := storage.NormalizeReminders(v.event.Reminders)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	v.event.Reminders = reminders
+
+	tags, err := storage.NormalizeTags(v.event.Tags)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	v.event.Tags = tags
+
+	if v.event.Place, err = storage.NormalizeLocation(v.event.Place); err != nil {
+		return storage.Event{}, err
+	}
+
+	if v.event.MeetingURL, err = storage.NormalizeMeetingURL(v.event.MeetingURL); err != nil {
+		return storage.Event{}, err
+	}
+
+	// EXDATE без RRULE не имеет смысла.
+	if v.event.Recurrence != nil && v.event.Recurrence.Frequency == "" {
+		v.event.Recurrence = nil
+	}
+
+	if v.event.Recurrence != nil {
+		if err := v.event.Recurrence.Validate(); err != nil {
+			return storage.Event{}, err
+		}
+	}
+
+	return v.event, nil
+}
+
+func (v *vevent) setProperty(name string, params map[string]string, value string) error {
+	var err error
+	e := &v.event
+	switch name {
+	case "UID":
+		e.ID = value
+	case "SUMMARY":
+		e.Title = unescape(value)
+	case "DESCRIPTION":
+		e.Description = unescape(value)
+	case "CATEGORIES":
+		e.Tags = append(e.Tags, unescapeList(value)...)
+	case "LOCATION":
+		e.Place.Text = unescape(value)
+	case "GEO":
+		e.Place.Coordinates, err = parseGeo(value)
+	case "CONFERENCE":
+		// Из нескольких подключений сохраняется первое.
+		if e.MeetingURL == "" {
+			e.MeetingURL = value
+		}
+	case "DTSTART":
+		e.StartAt, err = parseDateTime(params, value)
+		e.TimeZone = params["TZID"]
+		// Событие, заданное датой без времени, длится весь день.
+		e.AllDay = isDate(params, value)
+	case "DTEND":
+		e.EndAt, err = parseDateTime(params, value)
+		v.hasEnd = true
+	case "DURATION":
+		v.duration, err = ParseDuration(value)
+	case "RRULE":
+		var exceptions []time.Time
+		if e.Recurrence != nil {
+			exceptions = e.Recurrence.Exceptions
+		}
+		if e.Recurrence, err = storage.ParseRecurrence(value); err == nil && e.Recurrence != nil {
+			e.Recurrence.Exceptions = exceptions
+		}
+	case "EXDATE":
+		if e.Recurrence == nil {
+			e.Recurrence = &storage.Recurrence{}
+		}
+		for _, d := range strings.Split(value, ",") {
+			t, err := parseDateTime(params, d)
+			if err != nil {
+				return err
+			}
+			e.Recurrence.Exceptions = append(e.Recurrence.Exceptions, t)
+		}
+	}
+
+	return err
+}
+
+func (v *vevent) setTrigger(params map[string]string, value string) error {
+	if params["VALUE"] == "DATE-TIME" {
+		t, err := parseDateTime(params, value)
+		if err != nil {
+			return err
+		}
+		v.alarms = append(v.alarms, t)
+
+		return nil
+	}
+
+	d, err := ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	v.triggers = append(v.triggers, d)
+
+	return nil
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+func parseLine(line string) (string, map[string]string, string, error) {
+	i := strings.IndexByte(line, ':')
+	if i == -1 {
+		return "", nil, "", fmt.Errorf("%w: %q", ErrInvalidCalendar, line)
+	}
+
+	parts := strings.Split(line[:i], ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+
+	return strings.ToUpper(parts[0]), params, line[i+1:], nil
+}
+
+func isDate(params map[string]string, value string) bool {
+	return params["VALUE"] == "DATE" || len(value) == len(dateLayout)
+}
+
+func parseDateTime(params map[string]string, value string) (time.Time, error) {
+	if isDate(params, value) {
+		return time.Parse(dateLayout, value)
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(dateTimeLayout, value)
+	}
+
+	loc := time.UTC
+	if tzid, ok := params["TZID"]; ok {
+		var err error
+		if loc, err = storage.LoadLocation(tzid); err != nil {
+			return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidCalendar, err.Error())
+		}
+	}
+
+	return time.ParseInLocation(localLayout, value, loc)
+}
+
+// parseGeo разбирает значение GEO в формате "широта;долгота".
+func parseGeo(value string) (*storage.Coordinates, error) {
+	lat, lon, ok := strings.Cut(value, ";")
+	if !ok {
+		return nil, fmt.Errorf("%w: GEO %q", ErrInvalidCalendar, value)
+	}
+
+	latitude, err := strconv.ParseFloat(lat, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: GEO %q", ErrInvalidCalendar, value)
+	}
+
+	longitude, err := strconv.ParseFloat(lon, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: GEO %q", ErrInvalidCalendar, value)
+	}
+
+	return &storage.Coordinates{Latitude: latitude, Longitude: longitude}, nil
+}
+
+func formatGeo(c storage.Coordinates) string {
+	return strconv.FormatFloat(c.Latitude, 'f', -1, 64) + ";" + strconv.FormatFloat(c.Longitude, 'f', -1, 64)
+}
+
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format(dateTimeLayout)
+}
+
+func uid(e storage.Event) string {
+	if e.IsRecurring() {
+		return e.ID + "-" + e.StartAt.UTC().Format(dateTimeLayout)
+	}
+
+	return e.ID
+}
+
+// ParseDuration разбирает длительность в формате RFC 5545, например "-PT15M" или "P1DT2H".
+func ParseDuration(value string) (time.Duration, error) {
+	s := value
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidDuration, value)
+	}
+	s = s[1:]
+
+	var (
+		result time.Duration
+		inTime bool
+		num    string
+	)
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			num += string(c)
+			continue
+		case c == 'T':
+			inTime = true
+			continue
+		}
+
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidDuration, value)
+		}
+		num = ""
+
+		unit, ok := durationUnit(c, inTime)
+		if !ok {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidDuration, value)
+		}
+		result += time.Duration(n) * unit
+	}
+
+	if num != "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidDuration, value)
+	}
+
+	return sign * result, nil
+}
+
+func durationUnit(c rune, inTime bool) (time.Duration, bool) {
+	switch {
+	case c == 'W' && !inTime:
+		return 7 * 24 * time.Hour, true
+	case c == 'D' && !inTime:
+		return 24 * time.Hour, true
+	case c == 'H' && inTime:
+		return time.Hour, true
+	case c == 'M' && inTime:
+		return time.Minute, true
+	case c == 'S' && inTime:
+		return time.Second, true
+	default:
+		return 0, false
+	}
+}
+
+// FormatDuration форматирует длительность в формате RFC 5545.
+func FormatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+
+	result := sign + "P"
+	if days > 0 {
+		result += strconv.Itoa(int(days)) + "D"
+	}
+
+	if d > 0 || days == 0 {
+		result += "T"
+		h, m, s := d/time.Hour, (d%time.Hour)/time.Minute, (d%time.Minute)/time.Second
+		if h > 0 {
+			result += strconv.Itoa(int(h)) + "H"
+		}
+		if m > 0 {
+			result += strconv.Itoa(int(m)) + "M"
+		}
+		if s > 0 || (h == 0 && m == 0) {
+			result += strconv.Itoa(int(s)) + "S"
+		}
+	}
+
+	return result
+}
+
+var (
+	escaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	unescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+func unescape(s string) string {
+	return unescaper.Replace(s)
+}
+
+func escapeList(values []string) string {
+	escaped := make([]string, 0, len(values))
+	for _, v := range values {
+		escaped = append(escaped, escape(v))
+	}
+
+	return strings.Join(escaped, ",")
+}
+
+// unescapeList разбирает список значений через запятую. Теги не содержат
+// запятых, поэтому экранированная запятая тоже разделяет значения.
+func unescapeList(s string) []string {
+	return strings.Split(unescape(s), ",")
+}
+
+// writeLine записывает строку, перенося её по 75 октетов согласно RFC 5545.
+func writeLine(b *bytes.Buffer, line string) {
+	limit := maxLineLength
+	for len(line) > limit {
+		cut := limit
+		// Не разрываем многобайтовые символы UTF-8.
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Пробел в начале строки продолжения тоже входит в лимит.
+		limit = maxLineLength - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
@@ -0,0 +1,158 @@
+package ical
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	start, err := time.Parse(time.RFC3339, "2023-06-01T10:00:00Z")
+	require.NoError(t, err)
+
+	events := []storage.Event{
+		{
+			ID:          "1",
+			Title:       "Встреча; с командой, по плану",
+			StartAt:     start,
+			EndAt:       start.Add(time.Hour),
+			Description: "Первая строка\nВторая строка " + strings.Repeat("длинное описание ", 10),
+			Reminders:   []time.Duration{24 * time.Hour, 15 * time.Minute},
+			Tags:        []string{"backend", "работа; план"},
+			Place: storage.Location{
+				Text:        "Москва, Тверская ул., 1",
+				Coordinates: &storage.Coordinates{Latitude: 55.7575, Longitude: 37.6136},
+			},
+			MeetingURL: "https://meet.example.com/abc?pwd=1",
+		},
+		{
+			ID:      "2",
+			Title:   "test",
+			StartAt: start.AddDate(0, 0, 1),
+			EndAt:   start.AddDate(0, 0, 1).Add(30 * time.Minute),
+		},
+		{
+			ID:      "3",
+			Title:   "Конференция",
+			StartAt: start.Truncate(24*time.Hour).AddDate(0, 0, 4),
+			EndAt:   start.Truncate(24*time.Hour).AddDate(0, 0, 7),
+			AllDay:  true,
+		},
+	}
+
+	b := &bytes.Buffer{}
+	require.NoError(t, Encode(b, events))
+	require.Contains(t, b.String(), "DTSTART;VALUE=DATE:20230605\r\nDTEND;VALUE=DATE:20230608\r\n")
+
+	for _, line := range strings.Split(b.String(), "\r\n") {
+		require.LessOrEqual(t, len(line), maxLineLength)
+	}
+
+	decoded, err := Decode(b)
+	require.NoError(t, err)
+	require.Equal(t, len(events), len(decoded))
+	for i := range events {
+		require.Equal(t, events[i].ID, decoded[i].ID)
+		require.Equal(t, events[i].Title, decoded[i].Title)
+		require.Equal(t, events[i].Description, decoded[i].Description)
+		require.True(t, events[i].StartAt.Equal(decoded[i].StartAt))
+		require.True(t, events[i].EndAt.Equal(decoded[i].EndAt))
+		require.Equal(t, events[i].Reminders, decoded[i].Reminders)
+		require.Equal(t, events[i].Tags, decoded[i].Tags)
+		require.Equal(t, events[i].Place, decoded[i].Place)
+		require.Equal(t, events[i].MeetingURL, decoded[i].MeetingURL)
+		require.Equal(t, events[i].AllDay, decoded[i].AllDay)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	t.Run("recurrence, duration and time zone", func(t *testing.T) {
+		data := "BEGIN:VCALENDAR\r\n" +
+			"VERSION:2.0\r\n" +
+			"BEGIN:VEVENT\r\n" +
+			"UID:standup@example.com\r\n" +
+			"DTSTART;TZID=Europe/Moscow:20230601T100000\r\n" +
+			"DURATION:PT15M\r\n" +
+			"SUMMARY:Standup\r\n" +
+			"CATEGORIES:Work,Daily\r\n" +
+			"CATEGORIES:work\\, Team\r\n" +
+			"RRULE:FREQ=WEEKLY;BYDAY=MO,TH\r\n" +
+			"EXDATE;TZID=Europe/Moscow:20230605T100000\r\n" +
+			"BEGIN:VALARM\r\n" +
+			"TRIGGER:-PT5M\r\n" +
+			"END:VALARM\r\n" +
+			"BEGIN:VALARM\r\n" +
+			"TRIGGER;VALUE=DATE-TIME:20230531T070000Z\r\n" +
+			"END:VALARM\r\n" +
+			"BEGIN:VALARM\r\n" +
+			"TRIGGER:PT5M\r\n" +
+			"END:VALARM\r\n" +
+			"END:VEVENT\r\n" +
+			"END:VCALENDAR\r\n"
+
+		events, err := Decode(strings.NewReader(data))
+		require.NoError(t, err)
+		require.Equal(t, 1, len(events))
+
+		e := events[0]
+		start, err := time.Parse(time.RFC3339, "2023-06-01T07:00:00Z")
+		require.NoError(t, err)
+		require.Equal(t, "standup@example.com", e.ID)
+		require.True(t, start.Equal(e.StartAt))
+		require.Equal(t, "Europe/Moscow", e.TimeZone)
+		require.Equal(t, 15*time.Minute, e.EndAt.Sub(e.StartAt))
+		require.Equal(t, []time.Duration{24 * time.Hour, 5 * time.Minute}, e.Reminders)
+		require.Equal(t, []string{"daily", "team", "work"}, e.Tags)
+		require.Equal(t, "FREQ=WEEKLY;BYDAY=MO,TH", e.Recurrence.String())
+		require.Equal(t, 1, len(e.Recurrence.Exceptions))
+		require.True(t, start.AddDate(0, 0, 4).Equal(e.Recurrence.Exceptions[0]))
+	})
+
+	t.Run("invalid calendar", func(t *testing.T) {
+		data := []string{
+			"BEGIN:VEVENT\r\nEND:VEVENT\r\n",
+			"BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:test\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+			"BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART:20230601T100000Z\r\nEND:VCALENDAR\r\n",
+			"BEGIN:VCALENDAR\r\ninvalid line\r\nEND:VCALENDAR\r\n",
+			"BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART:20230601T100000Z\r\nGEO:55.75\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+		}
+
+		for _, v := range data {
+			_, err := Decode(strings.NewReader(v))
+			require.Truef(t, errors.Is(err, ErrInvalidCalendar), "actual error %q", err)
+		}
+	})
+}
+
+func TestDuration(t *testing.T) {
+	results := []struct {
+		value    string
+		duration time.Duration
+	}{
+		{"PT15M", 15 * time.Minute},
+		{"-PT1H30M", -90 * time.Minute},
+		{"P1DT2H", 26 * time.Hour},
+		{"P1W", 7 * 24 * time.Hour},
+		{"PT0S", 0},
+	}
+
+	for _, v := range results {
+		d, err := ParseDuration(v.value)
+		require.NoError(t, err)
+		require.Equal(t, v.duration, d)
+
+		d, err = ParseDuration(FormatDuration(v.duration))
+		require.NoError(t, err)
+		require.Equal(t, v.duration, d)
+	}
+
+	for _, v := range []string{"", "P", "PT", "1H", "PT1D", "P1H", "PT15"} {
+		_, err := ParseDuration(v)
+		require.Truef(t, errors.Is(err, ErrInvalidDuration), "value %q, actual error %q", v, err)
+	}
+}
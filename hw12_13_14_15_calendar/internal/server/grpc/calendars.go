@@ -0,0 +1,90 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/server/grpc/pb"
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *Server) CreateCalendar(ctx context.Context, e *pb.CreateCalendarRequest) (*pb.CalendarResult, error) {
+	calendar, err := s.app.CreateCalendar(ctx, userID(ctx), e.GetName())
+	if err != nil {
+		return &pb.CalendarResult{}, statusError(err)
+	}
+
+	return &pb.CalendarResult{Calendar: convertCalendar(calendar)}, nil
+}
+
+func (s *Server) UpdateCalendar(ctx context.Context, e *pb.UpdateCalendarRequest) (*pb.CalendarResult, error) {
+	calendar, err := s.app.UpdateCalendar(ctx, userID(ctx), e.GetId(), e.GetName())
+	if err != nil {
+		return &pb.CalendarResult{}, statusError(err)
+	}
+
+	return &pb.CalendarResult{Calendar: convertCalendar(calendar)}, nil
+}
+
+func (s *Server) DeleteCalendar(ctx context.Context, e *pb.CalendarRequest) (*pb.CalendarResult, error) {
+	if err := s.app.DeleteCalendar(ctx, userID(ctx), e.GetId()); err != nil {
+		return &pb.CalendarResult{}, statusError(err)
+	}
+
+	return &pb.CalendarResult{}, nil
+}
+
+func (s *Server) GetCalendar(ctx context.Context, e *pb.CalendarRequest) (*pb.CalendarResult, error) {
+	calendar, err := s.app.GetCalendar(ctx, userID(ctx), e.GetId())
+	if err != nil {
+		return &pb.CalendarResult{}, statusError(err)
+	}
+
+	return &pb.CalendarResult{Calendar: convertCalendar(calendar)}, nil
+}
+
+func (s *Server) ListCalendars(ctx context.Context, _ *pb.ListCalendarsRequest) (*pb.CalendarsResult, error) {
+	calendars, err := s.app.ListCalendars(ctx, userID(ctx))
+	if err != nil {
+		return &pb.CalendarsResult{}, statusError(err)
+	}
+
+	result := &pb.CalendarsResult{Calendars: make([]*pb.UserCalendar, 0, len(calendars))}
+	for _, calendar := range calendars {
+		result.Calendars = append(result.Calendars, convertCalendar(calendar))
+	}
+
+	return result, nil
+}
+
+func (s *Server) ShareCalendar(ctx context.Context, e *pb.ShareCalendarRequest) (*pb.CalendarResult, error) {
+	calendar, err := s.app.ShareCalendar(ctx, userID(ctx), e.GetId(), storage.CalendarShare{
+		UserID:     e.GetShare().GetUserId(),
+		Permission: storage.Permission(e.GetShare().GetPermission()),
+	})
+	if err != nil {
+		return &pb.CalendarResult{}, statusError(err)
+	}
+
+	return &pb.CalendarResult{Calendar: convertCalendar(calendar)}, nil
+}
+
+func (s *Server) UnshareCalendar(ctx context.Context, e *pb.UnshareCalendarRequest) (*pb.CalendarResult, error) {
+	calendar, err := s.app.UnshareCalendar(ctx, userID(ctx), e.GetId(), e.GetUserId())
+	if err != nil {
+		return &pb.CalendarResult{}, statusError(err)
+	}
+
+	return &pb.CalendarResult{Calendar: convertCalendar(calendar)}, nil
+}
+
+func convertCalendar(calendar storage.Calendar) *pb.UserCalendar {
+	result := &pb.UserCalendar{Id: calendar.ID, Name: calendar.Name, OwnerId: calendar.OwnerID}
+	for _, share := range calendar.Shares {
+		result.Shares = append(result.Shares, &pb.CalendarShare{
+			UserId:     share.UserID,
+			Permission: string(share.Permission),
+		})
+	}
+
+	return result
+}
@@ -52,3 +52,16 @@ func UnaryServerRequestLoggerMiddlewareInterceptor(logger logger.Logger) grpc.Un
 		return result, err
 	}
 }
+
+const userIDMetadata = "user-id"
+
+// userID возвращает идентификатор пользователя из метаданных запроса.
+func userID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(userIDMetadata); len(ids) > 0 {
+			return ids[0]
+		}
+	}
+
+	return ""
+}
@@ -19,12 +19,36 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	Calendar_Create_FullMethodName       = "/event.Calendar/Create"
-	Calendar_Update_FullMethodName       = "/event.Calendar/Update"
-	Calendar_Delete_FullMethodName       = "/event.Calendar/Delete"
-	Calendar_EventByDay_FullMethodName   = "/event.Calendar/EventByDay"
-	Calendar_EventByWeek_FullMethodName  = "/event.Calendar/EventByWeek"
-	Calendar_EventByMonth_FullMethodName = "/event.Calendar/EventByMonth"
+	Calendar_Create_FullMethodName               = "/event.Calendar/Create"
+	Calendar_Update_FullMethodName               = "/event.Calendar/Update"
+	Calendar_Delete_FullMethodName               = "/event.Calendar/Delete"
+	Calendar_GetEvent_FullMethodName             = "/event.Calendar/GetEvent"
+	Calendar_EventByDay_FullMethodName           = "/event.Calendar/EventByDay"
+	Calendar_EventByWeek_FullMethodName          = "/event.Calendar/EventByWeek"
+	Calendar_EventByMonth_FullMethodName         = "/event.Calendar/EventByMonth"
+	Calendar_Export_FullMethodName               = "/event.Calendar/Export"
+	Calendar_Import_FullMethodName               = "/event.Calendar/Import"
+	Calendar_FreeBusy_FullMethodName             = "/event.Calendar/FreeBusy"
+	Calendar_ListEvents_FullMethodName           = "/event.Calendar/ListEvents"
+	Calendar_RestoreEvent_FullMethodName         = "/event.Calendar/RestoreEvent"
+	Calendar_ListDeleted_FullMethodName          = "/event.Calendar/ListDeleted"
+	Calendar_EventHistory_FullMethodName         = "/event.Calendar/EventHistory"
+	Calendar_InviteAttendees_FullMethodName      = "/event.Calendar/InviteAttendees"
+	Calendar_RespondInvitation_FullMethodName    = "/event.Calendar/RespondInvitation"
+	Calendar_CreateCalendar_FullMethodName       = "/event.Calendar/CreateCalendar"
+	Calendar_UpdateCalendar_FullMethodName       = "/event.Calendar/UpdateCalendar"
+	Calendar_DeleteCalendar_FullMethodName       = "/event.Calendar/DeleteCalendar"
+	Calendar_GetCalendar_FullMethodName          = "/event.Calendar/GetCalendar"
+	Calendar_ListCalendars_FullMethodName        = "/event.Calendar/ListCalendars"
+	Calendar_ShareCalendar_FullMethodName        = "/event.Calendar/ShareCalendar"
+	Calendar_UnshareCalendar_FullMethodName      = "/event.Calendar/UnshareCalendar"
+	Calendar_Search_FullMethodName               = "/event.Calendar/Search"
+	Calendar_CreateResource_FullMethodName       = "/event.Calendar/CreateResource"
+	Calendar_UpdateResource_FullMethodName       = "/event.Calendar/UpdateResource"
+	Calendar_DeleteResource_FullMethodName       = "/event.Calendar/DeleteResource"
+	Calendar_GetResource_FullMethodName          = "/event.Calendar/GetResource"
+	Calendar_ListResources_FullMethodName        = "/event.Calendar/ListResources"
+	Calendar_ResourceAvailability_FullMethodName = "/event.Calendar/ResourceAvailability"
 )
 
 // CalendarClient is the client API for Calendar service.
@@ -34,9 +58,33 @@ type CalendarClient interface {
 	Create(ctx context.Context, in *CreateEvent, opts ...grpc.CallOption) (*Result, error)
 	Update(ctx context.Context, in *UpdateEvent, opts ...grpc.CallOption) (*Result, error)
 	Delete(ctx context.Context, in *DeleteEvent, opts ...grpc.CallOption) (*Result, error)
+	GetEvent(ctx context.Context, in *GetEventRequest, opts ...grpc.CallOption) (*Result, error)
 	EventByDay(ctx context.Context, in *EventDay, opts ...grpc.CallOption) (*EventsResult, error)
 	EventByWeek(ctx context.Context, in *EventDay, opts ...grpc.CallOption) (*EventsResult, error)
 	EventByMonth(ctx context.Context, in *EventDay, opts ...grpc.CallOption) (*EventsResult, error)
+	Export(ctx context.Context, in *ExportEvents, opts ...grpc.CallOption) (*ICalendar, error)
+	Import(ctx context.Context, in *ImportEvents, opts ...grpc.CallOption) (*ImportResult, error)
+	FreeBusy(ctx context.Context, in *FreeBusyRequest, opts ...grpc.CallOption) (*FreeBusyResult, error)
+	ListEvents(ctx context.Context, in *ListEventsRequest, opts ...grpc.CallOption) (*EventsPage, error)
+	RestoreEvent(ctx context.Context, in *RestoreEventRequest, opts ...grpc.CallOption) (*Result, error)
+	ListDeleted(ctx context.Context, in *ListDeletedRequest, opts ...grpc.CallOption) (*EventsResult, error)
+	EventHistory(ctx context.Context, in *EventHistoryRequest, opts ...grpc.CallOption) (*EventHistoryResult, error)
+	InviteAttendees(ctx context.Context, in *InviteAttendeesRequest, opts ...grpc.CallOption) (*Result, error)
+	RespondInvitation(ctx context.Context, in *RespondInvitationRequest, opts ...grpc.CallOption) (*Result, error)
+	CreateCalendar(ctx context.Context, in *CreateCalendarRequest, opts ...grpc.CallOption) (*CalendarResult, error)
+	UpdateCalendar(ctx context.Context, in *UpdateCalendarRequest, opts ...grpc.CallOption) (*CalendarResult, error)
+	DeleteCalendar(ctx context.Context, in *CalendarRequest, opts ...grpc.CallOption) (*CalendarResult, error)
+	GetCalendar(ctx context.Context, in *CalendarRequest, opts ...grpc.CallOption) (*CalendarResult, error)
+	ListCalendars(ctx context.Context, in *ListCalendarsRequest, opts ...grpc.CallOption) (*CalendarsResult, error)
+	ShareCalendar(ctx context.Context, in *ShareCalendarRequest, opts ...grpc.CallOption) (*CalendarResult, error)
+	UnshareCalendar(ctx context.Context, in *UnshareCalendarRequest, opts ...grpc.CallOption) (*CalendarResult, error)
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResult, error)
+	CreateResource(ctx context.Context, in *CreateResourceRequest, opts ...grpc.CallOption) (*ResourceResult, error)
+	UpdateResource(ctx context.Context, in *UpdateResourceRequest, opts ...grpc.CallOption) (*ResourceResult, error)
+	DeleteResource(ctx context.Context, in *ResourceRequest, opts ...grpc.CallOption) (*ResourceResult, error)
+	GetResource(ctx context.Context, in *ResourceRequest, opts ...grpc.CallOption) (*ResourceResult, error)
+	ListResources(ctx context.Context, in *ListResourcesRequest, opts ...grpc.CallOption) (*ResourcesResult, error)
+	ResourceAvailability(ctx context.Context, in *ResourceAvailabilityRequest, opts ...grpc.CallOption) (*FreeBusyResult, error)
 }
 
 type calendarClient struct {
@@ -74,6 +122,15 @@ func (c *calendarClient) Delete(ctx context.Context, in *DeleteEvent, opts ...gr
 	return out, nil
 }
 
+func (c *calendarClient) GetEvent(ctx context.Context, in *GetEventRequest, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, Calendar_GetEvent_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *calendarClient) EventByDay(ctx context.Context, in *EventDay, opts ...grpc.CallOption) (*EventsResult, error) {
 	out := new(EventsResult)
 	err := c.cc.Invoke(ctx, Calendar_EventByDay_FullMethodName, in, out, opts...)
@@ -101,6 +158,213 @@ func (c *calendarClient) EventByMonth(ctx context.Context, in *EventDay, opts ..
 	return out, nil
 }
 
+func (c *calendarClient) Export(ctx context.Context, in *ExportEvents, opts ...grpc.CallOption) (*ICalendar, error) {
+	out := new(ICalendar)
+	err := c.cc.Invoke(ctx, Calendar_Export_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) Import(ctx context.Context, in *ImportEvents, opts ...grpc.CallOption) (*ImportResult, error) {
+	out := new(ImportResult)
+	err := c.cc.Invoke(ctx, Calendar_Import_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) FreeBusy(ctx context.Context, in *FreeBusyRequest, opts ...grpc.CallOption) (*FreeBusyResult, error) {
+	out := new(FreeBusyResult)
+	err := c.cc.Invoke(ctx, Calendar_FreeBusy_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) ListEvents(ctx context.Context, in *ListEventsRequest, opts ...grpc.CallOption) (*EventsPage, error) {
+	out := new(EventsPage)
+	err := c.cc.Invoke(ctx, Calendar_ListEvents_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) RestoreEvent(ctx context.Context, in *RestoreEventRequest, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, Calendar_RestoreEvent_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) ListDeleted(ctx context.Context, in *ListDeletedRequest, opts ...grpc.CallOption) (*EventsResult, error) {
+	out := new(EventsResult)
+	err := c.cc.Invoke(ctx, Calendar_ListDeleted_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) EventHistory(ctx context.Context, in *EventHistoryRequest, opts ...grpc.CallOption) (*EventHistoryResult, error) {
+	out := new(EventHistoryResult)
+	err := c.cc.Invoke(ctx, Calendar_EventHistory_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) InviteAttendees(ctx context.Context, in *InviteAttendeesRequest, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, Calendar_InviteAttendees_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) RespondInvitation(ctx context.Context, in *RespondInvitationRequest, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, Calendar_RespondInvitation_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) CreateCalendar(ctx context.Context, in *CreateCalendarRequest, opts ...grpc.CallOption) (*CalendarResult, error) {
+	out := new(CalendarResult)
+	err := c.cc.Invoke(ctx, Calendar_CreateCalendar_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) UpdateCalendar(ctx context.Context, in *UpdateCalendarRequest, opts ...grpc.CallOption) (*CalendarResult, error) {
+	out := new(CalendarResult)
+	err := c.cc.Invoke(ctx, Calendar_UpdateCalendar_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) DeleteCalendar(ctx context.Context, in *CalendarRequest, opts ...grpc.CallOption) (*CalendarResult, error) {
+	out := new(CalendarResult)
+	err := c.cc.Invoke(ctx, Calendar_DeleteCalendar_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) GetCalendar(ctx context.Context, in *CalendarRequest, opts ...grpc.CallOption) (*CalendarResult, error) {
+	out := new(CalendarResult)
+	err := c.cc.Invoke(ctx, Calendar_GetCalendar_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) ListCalendars(ctx context.Context, in *ListCalendarsRequest, opts ...grpc.CallOption) (*CalendarsResult, error) {
+	out := new(CalendarsResult)
+	err := c.cc.Invoke(ctx, Calendar_ListCalendars_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) ShareCalendar(ctx context.Context, in *ShareCalendarRequest, opts ...grpc.CallOption) (*CalendarResult, error) {
+	out := new(CalendarResult)
+	err := c.cc.Invoke(ctx, Calendar_ShareCalendar_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) UnshareCalendar(ctx context.Context, in *UnshareCalendarRequest, opts ...grpc.CallOption) (*CalendarResult, error) {
+	out := new(CalendarResult)
+	err := c.cc.Invoke(ctx, Calendar_UnshareCalendar_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResult, error) {
+	out := new(SearchResult)
+	err := c.cc.Invoke(ctx, Calendar_Search_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) CreateResource(ctx context.Context, in *CreateResourceRequest, opts ...grpc.CallOption) (*ResourceResult, error) {
+	out := new(ResourceResult)
+	err := c.cc.Invoke(ctx, Calendar_CreateResource_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) UpdateResource(ctx context.Context, in *UpdateResourceRequest, opts ...grpc.CallOption) (*ResourceResult, error) {
+	out := new(ResourceResult)
+	err := c.cc.Invoke(ctx, Calendar_UpdateResource_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) DeleteResource(ctx context.Context, in *ResourceRequest, opts ...grpc.CallOption) (*ResourceResult, error) {
+	out := new(ResourceResult)
+	err := c.cc.Invoke(ctx, Calendar_DeleteResource_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) GetResource(ctx context.Context, in *ResourceRequest, opts ...grpc.CallOption) (*ResourceResult, error) {
+	out := new(ResourceResult)
+	err := c.cc.Invoke(ctx, Calendar_GetResource_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) ListResources(ctx context.Context, in *ListResourcesRequest, opts ...grpc.CallOption) (*ResourcesResult, error) {
+	out := new(ResourcesResult)
+	err := c.cc.Invoke(ctx, Calendar_ListResources_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) ResourceAvailability(ctx context.Context, in *ResourceAvailabilityRequest, opts ...grpc.CallOption) (*FreeBusyResult, error) {
+	out := new(FreeBusyResult)
+	err := c.cc.Invoke(ctx, Calendar_ResourceAvailability_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CalendarServer is the server API for Calendar service.
 // All implementations must embed UnimplementedCalendarServer
 // for forward compatibility
@@ -108,9 +372,33 @@ type CalendarServer interface {
 	Create(context.Context, *CreateEvent) (*Result, error)
 	Update(context.Context, *UpdateEvent) (*Result, error)
 	Delete(context.Context, *DeleteEvent) (*Result, error)
+	GetEvent(context.Context, *GetEventRequest) (*Result, error)
 	EventByDay(context.Context, *EventDay) (*EventsResult, error)
 	EventByWeek(context.Context, *EventDay) (*EventsResult, error)
 	EventByMonth(context.Context, *EventDay) (*EventsResult, error)
+	Export(context.Context, *ExportEvents) (*ICalendar, error)
+	Import(context.Context, *ImportEvents) (*ImportResult, error)
+	FreeBusy(context.Context, *FreeBusyRequest) (*FreeBusyResult, error)
+	ListEvents(context.Context, *ListEventsRequest) (*EventsPage, error)
+	RestoreEvent(context.Context, *RestoreEventRequest) (*Result, error)
+	ListDeleted(context.Context, *ListDeletedRequest) (*EventsResult, error)
+	EventHistory(context.Context, *EventHistoryRequest) (*EventHistoryResult, error)
+	InviteAttendees(context.Context, *InviteAttendeesRequest) (*Result, error)
+	RespondInvitation(context.Context, *RespondInvitationRequest) (*Result, error)
+	CreateCalendar(context.Context, *CreateCalendarRequest) (*CalendarResult, error)
+	UpdateCalendar(context.Context, *UpdateCalendarRequest) (*CalendarResult, error)
+	DeleteCalendar(context.Context, *CalendarRequest) (*CalendarResult, error)
+	GetCalendar(context.Context, *CalendarRequest) (*CalendarResult, error)
+	ListCalendars(context.Context, *ListCalendarsRequest) (*CalendarsResult, error)
+	ShareCalendar(context.Context, *ShareCalendarRequest) (*CalendarResult, error)
+	UnshareCalendar(context.Context, *UnshareCalendarRequest) (*CalendarResult, error)
+	Search(context.Context, *SearchRequest) (*SearchResult, error)
+	CreateResource(context.Context, *CreateResourceRequest) (*ResourceResult, error)
+	UpdateResource(context.Context, *UpdateResourceRequest) (*ResourceResult, error)
+	DeleteResource(context.Context, *ResourceRequest) (*ResourceResult, error)
+	GetResource(context.Context, *ResourceRequest) (*ResourceResult, error)
+	ListResources(context.Context, *ListResourcesRequest) (*ResourcesResult, error)
+	ResourceAvailability(context.Context, *ResourceAvailabilityRequest) (*FreeBusyResult, error)
 	mustEmbedUnimplementedCalendarServer()
 }
 
@@ -127,6 +415,9 @@ func (UnimplementedCalendarServer) Update(context.Context, *UpdateEvent) (*Resul
 func (UnimplementedCalendarServer) Delete(context.Context, *DeleteEvent) (*Result, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
 }
+func (UnimplementedCalendarServer) GetEvent(context.Context, *GetEventRequest) (*Result, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEvent not implemented")
+}
 func (UnimplementedCalendarServer) EventByDay(context.Context, *EventDay) (*EventsResult, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method EventByDay not implemented")
 }
@@ -136,6 +427,75 @@ func (UnimplementedCalendarServer) EventByWeek(context.Context, *EventDay) (*Eve
 func (UnimplementedCalendarServer) EventByMonth(context.Context, *EventDay) (*EventsResult, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method EventByMonth not implemented")
 }
+func (UnimplementedCalendarServer) Export(context.Context, *ExportEvents) (*ICalendar, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Export not implemented")
+}
+func (UnimplementedCalendarServer) Import(context.Context, *ImportEvents) (*ImportResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Import not implemented")
+}
+func (UnimplementedCalendarServer) FreeBusy(context.Context, *FreeBusyRequest) (*FreeBusyResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FreeBusy not implemented")
+}
+func (UnimplementedCalendarServer) ListEvents(context.Context, *ListEventsRequest) (*EventsPage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEvents not implemented")
+}
+func (UnimplementedCalendarServer) RestoreEvent(context.Context, *RestoreEventRequest) (*Result, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreEvent not implemented")
+}
+func (UnimplementedCalendarServer) ListDeleted(context.Context, *ListDeletedRequest) (*EventsResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDeleted not implemented")
+}
+func (UnimplementedCalendarServer) EventHistory(context.Context, *EventHistoryRequest) (*EventHistoryResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EventHistory not implemented")
+}
+func (UnimplementedCalendarServer) InviteAttendees(context.Context, *InviteAttendeesRequest) (*Result, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InviteAttendees not implemented")
+}
+func (UnimplementedCalendarServer) RespondInvitation(context.Context, *RespondInvitationRequest) (*Result, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RespondInvitation not implemented")
+}
+func (UnimplementedCalendarServer) CreateCalendar(context.Context, *CreateCalendarRequest) (*CalendarResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCalendar not implemented")
+}
+func (UnimplementedCalendarServer) UpdateCalendar(context.Context, *UpdateCalendarRequest) (*CalendarResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCalendar not implemented")
+}
+func (UnimplementedCalendarServer) DeleteCalendar(context.Context, *CalendarRequest) (*CalendarResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCalendar not implemented")
+}
+func (UnimplementedCalendarServer) GetCalendar(context.Context, *CalendarRequest) (*CalendarResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCalendar not implemented")
+}
+func (UnimplementedCalendarServer) ListCalendars(context.Context, *ListCalendarsRequest) (*CalendarsResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCalendars not implemented")
+}
+func (UnimplementedCalendarServer) ShareCalendar(context.Context, *ShareCalendarRequest) (*CalendarResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ShareCalendar not implemented")
+}
+func (UnimplementedCalendarServer) UnshareCalendar(context.Context, *UnshareCalendarRequest) (*CalendarResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnshareCalendar not implemented")
+}
+func (UnimplementedCalendarServer) Search(context.Context, *SearchRequest) (*SearchResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedCalendarServer) CreateResource(context.Context, *CreateResourceRequest) (*ResourceResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateResource not implemented")
+}
+func (UnimplementedCalendarServer) UpdateResource(context.Context, *UpdateResourceRequest) (*ResourceResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateResource not implemented")
+}
+func (UnimplementedCalendarServer) DeleteResource(context.Context, *ResourceRequest) (*ResourceResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteResource not implemented")
+}
+func (UnimplementedCalendarServer) GetResource(context.Context, *ResourceRequest) (*ResourceResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetResource not implemented")
+}
+func (UnimplementedCalendarServer) ListResources(context.Context, *ListResourcesRequest) (*ResourcesResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListResources not implemented")
+}
+func (UnimplementedCalendarServer) ResourceAvailability(context.Context, *ResourceAvailabilityRequest) (*FreeBusyResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResourceAvailability not implemented")
+}
 func (UnimplementedCalendarServer) mustEmbedUnimplementedCalendarServer() {}
 
 // UnsafeCalendarServer may be embedded to opt out of forward compatibility for this service.
@@ -203,6 +563,24 @@ func _Calendar_Delete_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Calendar_GetEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).GetEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_GetEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).GetEvent(ctx, req.(*GetEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Calendar_EventByDay_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(EventDay)
 	if err := dec(in); err != nil {
@@ -257,36 +635,546 @@ func _Calendar_EventByMonth_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
-// Calendar_ServiceDesc is the grpc.ServiceDesc for Calendar service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var Calendar_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "event.Calendar",
-	HandlerType: (*CalendarServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "Create",
-			Handler:    _Calendar_Create_Handler,
-		},
-		{
-			MethodName: "Update",
-			Handler:    _Calendar_Update_Handler,
-		},
-		{
-			MethodName: "Delete",
-			Handler:    _Calendar_Delete_Handler,
-		},
-		{
-			MethodName: "EventByDay",
-			Handler:    _Calendar_EventByDay_Handler,
-		},
-		{
-			MethodName: "EventByWeek",
-			Handler:    _Calendar_EventByWeek_Handler,
-		},
-		{
-			MethodName: "EventByMonth",
-			Handler:    _Calendar_EventByMonth_Handler,
+func _Calendar_Export_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportEvents)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).Export(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_Export_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).Export(ctx, req.(*ExportEvents))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_Import_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportEvents)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).Import(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_Import_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).Import(ctx, req.(*ImportEvents))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_FreeBusy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FreeBusyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).FreeBusy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_FreeBusy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).FreeBusy(ctx, req.(*FreeBusyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_ListEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).ListEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_ListEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).ListEvents(ctx, req.(*ListEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_RestoreEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).RestoreEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_RestoreEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).RestoreEvent(ctx, req.(*RestoreEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_ListDeleted_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeletedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).ListDeleted(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_ListDeleted_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).ListDeleted(ctx, req.(*ListDeletedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_EventHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EventHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).EventHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_EventHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).EventHistory(ctx, req.(*EventHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_InviteAttendees_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InviteAttendeesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).InviteAttendees(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_InviteAttendees_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).InviteAttendees(ctx, req.(*InviteAttendeesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_RespondInvitation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RespondInvitationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).RespondInvitation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_RespondInvitation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).RespondInvitation(ctx, req.(*RespondInvitationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_CreateCalendar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCalendarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).CreateCalendar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_CreateCalendar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).CreateCalendar(ctx, req.(*CreateCalendarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_UpdateCalendar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCalendarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).UpdateCalendar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_UpdateCalendar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).UpdateCalendar(ctx, req.(*UpdateCalendarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_DeleteCalendar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CalendarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).DeleteCalendar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_DeleteCalendar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).DeleteCalendar(ctx, req.(*CalendarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_GetCalendar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CalendarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).GetCalendar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_GetCalendar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).GetCalendar(ctx, req.(*CalendarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_ListCalendars_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCalendarsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).ListCalendars(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_ListCalendars_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).ListCalendars(ctx, req.(*ListCalendarsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_ShareCalendar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShareCalendarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).ShareCalendar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_ShareCalendar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).ShareCalendar(ctx, req.(*ShareCalendarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_UnshareCalendar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnshareCalendarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).UnshareCalendar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_UnshareCalendar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).UnshareCalendar(ctx, req.(*UnshareCalendarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_Search_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_CreateResource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateResourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).CreateResource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_CreateResource_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).CreateResource(ctx, req.(*CreateResourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_UpdateResource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateResourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).UpdateResource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_UpdateResource_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).UpdateResource(ctx, req.(*UpdateResourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_DeleteResource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).DeleteResource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_DeleteResource_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).DeleteResource(ctx, req.(*ResourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_GetResource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).GetResource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_GetResource_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).GetResource(ctx, req.(*ResourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_ListResources_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListResourcesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).ListResources(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_ListResources_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).ListResources(ctx, req.(*ListResourcesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_ResourceAvailability_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResourceAvailabilityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).ResourceAvailability(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_ResourceAvailability_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).ResourceAvailability(ctx, req.(*ResourceAvailabilityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Calendar_ServiceDesc is the grpc.ServiceDesc for Calendar service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Calendar_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "event.Calendar",
+	HandlerType: (*CalendarServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    _Calendar_Create_Handler,
+		},
+		{
+			MethodName: "Update",
+			Handler:    _Calendar_Update_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _Calendar_Delete_Handler,
+		},
+		{
+			MethodName: "GetEvent",
+			Handler:    _Calendar_GetEvent_Handler,
+		},
+		{
+			MethodName: "EventByDay",
+			Handler:    _Calendar_EventByDay_Handler,
+		},
+		{
+			MethodName: "EventByWeek",
+			Handler:    _Calendar_EventByWeek_Handler,
+		},
+		{
+			MethodName: "EventByMonth",
+			Handler:    _Calendar_EventByMonth_Handler,
+		},
+		{
+			MethodName: "Export",
+			Handler:    _Calendar_Export_Handler,
+		},
+		{
+			MethodName: "Import",
+			Handler:    _Calendar_Import_Handler,
+		},
+		{
+			MethodName: "FreeBusy",
+			Handler:    _Calendar_FreeBusy_Handler,
+		},
+		{
+			MethodName: "ListEvents",
+			Handler:    _Calendar_ListEvents_Handler,
+		},
+		{
+			MethodName: "RestoreEvent",
+			Handler:    _Calendar_RestoreEvent_Handler,
+		},
+		{
+			MethodName: "ListDeleted",
+			Handler:    _Calendar_ListDeleted_Handler,
+		},
+		{
+			MethodName: "EventHistory",
+			Handler:    _Calendar_EventHistory_Handler,
+		},
+		{
+			MethodName: "InviteAttendees",
+			Handler:    _Calendar_InviteAttendees_Handler,
+		},
+		{
+			MethodName: "RespondInvitation",
+			Handler:    _Calendar_RespondInvitation_Handler,
+		},
+		{
+			MethodName: "CreateCalendar",
+			Handler:    _Calendar_CreateCalendar_Handler,
+		},
+		{
+			MethodName: "UpdateCalendar",
+			Handler:    _Calendar_UpdateCalendar_Handler,
+		},
+		{
+			MethodName: "DeleteCalendar",
+			Handler:    _Calendar_DeleteCalendar_Handler,
+		},
+		{
+			MethodName: "GetCalendar",
+			Handler:    _Calendar_GetCalendar_Handler,
+		},
+		{
+			MethodName: "ListCalendars",
+			Handler:    _Calendar_ListCalendars_Handler,
+		},
+		{
+			MethodName: "ShareCalendar",
+			Handler:    _Calendar_ShareCalendar_Handler,
+		},
+		{
+			MethodName: "UnshareCalendar",
+			Handler:    _Calendar_UnshareCalendar_Handler,
+		},
+		{
+			MethodName: "Search",
+			Handler:    _Calendar_Search_Handler,
+		},
+		{
+			MethodName: "CreateResource",
+			Handler:    _Calendar_CreateResource_Handler,
+		},
+		{
+			MethodName: "UpdateResource",
+			Handler:    _Calendar_UpdateResource_Handler,
+		},
+		{
+			MethodName: "DeleteResource",
+			Handler:    _Calendar_DeleteResource_Handler,
+		},
+		{
+			MethodName: "GetResource",
+			Handler:    _Calendar_GetResource_Handler,
+		},
+		{
+			MethodName: "ListResources",
+			Handler:    _Calendar_ListResources_Handler,
+		},
+		{
+			MethodName: "ResourceAvailability",
+			Handler:    _Calendar_ResourceAvailability_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
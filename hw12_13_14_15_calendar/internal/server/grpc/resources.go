@@ -0,0 +1,97 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/server/grpc/pb"
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *Server) CreateResource(ctx context.Context, e *pb.CreateResourceRequest) (*pb.ResourceResult, error) {
+	resource, err := s.app.CreateResource(
+		ctx,
+		userID(ctx),
+		e.GetName(),
+		storage.ResourceKind(e.GetKind()),
+		int(e.GetCapacity()),
+	)
+	if err != nil {
+		return &pb.ResourceResult{}, statusError(err)
+	}
+
+	return &pb.ResourceResult{Resource: convertResource(resource)}, nil
+}
+
+func (s *Server) UpdateResource(ctx context.Context, e *pb.UpdateResourceRequest) (*pb.ResourceResult, error) {
+	resource, err := s.app.UpdateResource(
+		ctx,
+		userID(ctx),
+		e.GetId(),
+		e.GetName(),
+		storage.ResourceKind(e.GetKind()),
+		int(e.GetCapacity()),
+	)
+	if err != nil {
+		return &pb.ResourceResult{}, statusError(err)
+	}
+
+	return &pb.ResourceResult{Resource: convertResource(resource)}, nil
+}
+
+func (s *Server) DeleteResource(ctx context.Context, e *pb.ResourceRequest) (*pb.ResourceResult, error) {
+	if err := s.app.DeleteResource(ctx, userID(ctx), e.GetId()); err != nil {
+		return &pb.ResourceResult{}, statusError(err)
+	}
+
+	return &pb.ResourceResult{}, nil
+}
+
+func (s *Server) GetResource(ctx context.Context, e *pb.ResourceRequest) (*pb.ResourceResult, error) {
+	resource, err := s.app.GetResource(ctx, userID(ctx), e.GetId())
+	if err != nil {
+		return &pb.ResourceResult{}, statusError(err)
+	}
+
+	return &pb.ResourceResult{Resource: convertResource(resource)}, nil
+}
+
+func (s *Server) ListResources(ctx context.Context, _ *pb.ListResourcesRequest) (*pb.ResourcesResult, error) {
+	resources, err := s.app.ListResources(ctx, userID(ctx))
+	if err != nil {
+		return &pb.ResourcesResult{}, statusError(err)
+	}
+
+	result := &pb.ResourcesResult{Resources: make([]*pb.Resource, 0, len(resources))}
+	for _, resource := range resources {
+		result.Resources = append(result.Resources, convertResource(resource))
+	}
+
+	return result, nil
+}
+
+func (s *Server) ResourceAvailability(
+	ctx context.Context,
+	e *pb.ResourceAvailabilityRequest,
+) (*pb.FreeBusyResult, error) {
+	day, err := dateIn(e.GetDate(), e.GetTz())
+	if err != nil {
+		return &pb.FreeBusyResult{}, statusError(err)
+	}
+
+	fb, err := s.app.ResourceAvailability(ctx, userID(ctx), e.GetId(), day)
+	if err != nil {
+		return &pb.FreeBusyResult{}, statusError(err)
+	}
+
+	return &pb.FreeBusyResult{Busy: convertIntervals(fb.Busy), Free: convertIntervals(fb.Free)}, nil
+}
+
+func convertResource(resource storage.Resource) *pb.Resource {
+	return &pb.Resource{
+		Id:       resource.ID,
+		Name:     resource.Name,
+		Kind:     string(resource.Kind),
+		Capacity: int32(resource.Capacity),
+		OwnerId:  resource.OwnerID,
+	}
+}
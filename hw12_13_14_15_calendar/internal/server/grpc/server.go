@@ -1,12 +1,16 @@
 package grpc
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/app"
 	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/config"
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/ical"
 	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/logger"
 	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/server/grpc/pb"
 	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
@@ -60,101 +64,503 @@ func (s *Server) Stop() {
 }
 
 func (s *Server) Create(ctx context.Context, e *pb.CreateEvent) (*pb.Result, error) {
-	err := s.app.CreateEvent(
-		ctx,
-		e.GetTitle(),
-		e.GetStartAt().AsTime(),
-		e.GetDuration().AsDuration(),
-		e.GetDescription(),
-		e.GetAuthorId(),
-		e.GetNotificationAt().AsTime(),
-	)
+	recurrence, err := parseRecurrence(e.GetRrule(), e.GetExdates())
 	if err != nil {
-		return &pb.Result{}, status.Error(codes.Unknown, err.Error())
+		return &pb.Result{}, status.Error(codes.InvalidArgument, err.Error())
 	}
 
-	return &pb.Result{}, nil
+	event, err := s.app.CreateEvent(ctx, userID(ctx), eventInput(e, recurrence))
+	if err != nil {
+		return &pb.Result{}, statusError(err)
+	}
+
+	return &pb.Result{Event: convertEvent(event)}, nil
 }
 
 func (s *Server) Update(ctx context.Context, e *pb.UpdateEvent) (*pb.Result, error) {
-	err := s.app.UpdateEvent(
+	recurrence, err := parseRecurrence(e.GetEvent().GetRrule(), e.GetEvent().GetExdates())
+	if err != nil {
+		return &pb.Result{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	event, err := s.app.UpdateEvent(
 		ctx,
+		userID(ctx),
 		e.GetId(),
-		e.GetEvent().GetTitle(),
-		e.GetEvent().GetStartAt().AsTime(),
-		e.GetEvent().GetDuration().AsDuration(),
-		e.GetEvent().GetDescription(),
-		e.GetEvent().GetAuthorId(),
-		e.GetEvent().GetNotificationAt().AsTime(),
+		eventInput(e.GetEvent(), recurrence),
+		e.GetEvent().GetVersion(),
 	)
 	if err != nil {
-		return &pb.Result{}, status.Error(codes.Unknown, err.Error())
+		return &pb.Result{}, statusError(err)
 	}
 
-	return &pb.Result{}, nil
+	return &pb.Result{Event: convertEvent(event)}, nil
 }
 
 func (s *Server) Delete(ctx context.Context, e *pb.DeleteEvent) (*pb.Result, error) {
 	err := s.app.DeleteEvent(
 		ctx,
+		userID(ctx),
 		e.GetId(),
+		e.GetVersion(),
 	)
 	if err != nil {
-		return &pb.Result{}, status.Error(codes.Unknown, err.Error())
+		return &pb.Result{}, statusError(err)
 	}
 
 	return &pb.Result{}, nil
 }
 
+func (s *Server) GetEvent(ctx context.Context, e *pb.GetEventRequest) (*pb.Result, error) {
+	event, err := s.app.GetEvent(ctx, userID(ctx), e.GetId())
+	if err != nil {
+		return &pb.Result{}, statusError(err)
+	}
+
+	return &pb.Result{Event: convertEvent(event)}, nil
+}
+
+func (s *Server) RestoreEvent(ctx context.Context, e *pb.RestoreEventRequest) (*pb.Result, error) {
+	event, err := s.app.RestoreEvent(ctx, userID(ctx), e.GetId())
+	if err != nil {
+		return &pb.Result{}, statusError(err)
+	}
+
+	return &pb.Result{Event: convertEvent(event)}, nil
+}
+
+func (s *Server) ListDeleted(ctx context.Context, _ *pb.ListDeletedRequest) (*pb.EventsResult, error) {
+	events, err := s.app.ListDeleted(ctx, userID(ctx))
+	if err != nil {
+		return &pb.EventsResult{}, statusError(err)
+	}
+
+	return convert(events), nil
+}
+
+func (s *Server) EventHistory(ctx context.Context, e *pb.EventHistoryRequest) (*pb.EventHistoryResult, error) {
+	records, err := s.app.EventHistory(ctx, userID(ctx), e.GetId())
+	if err != nil {
+		return &pb.EventHistoryResult{}, statusError(err)
+	}
+
+	result := &pb.EventHistoryResult{Records: make([]*pb.HistoryRecord, 0, len(records))}
+	for _, v := range records {
+		record := &pb.HistoryRecord{
+			Id:      v.ID,
+			Action:  string(v.Action),
+			ActorId: v.ActorID,
+			At:      timestamppb.New(v.At),
+		}
+		if v.Before != nil {
+			record.Before = convertEvent(*v.Before)
+		}
+		if v.After != nil {
+			record.After = convertEvent(*v.After)
+		}
+		result.Records = append(result.Records, record)
+	}
+
+	return result, nil
+}
+
+func (s *Server) InviteAttendees(ctx context.Context, e *pb.InviteAttendeesRequest) (*pb.Result, error) {
+	attendees := make([]storage.Attendee, 0, len(e.GetAttendees()))
+	for _, v := range e.GetAttendees() {
+		attendees = append(attendees, storage.Attendee{UserID: v.GetUserId(), Role: storage.AttendeeRole(v.GetRole())})
+	}
+
+	event, err := s.app.InviteAttendees(ctx, userID(ctx), e.GetId(), attendees)
+	if err != nil {
+		return &pb.Result{}, statusError(err)
+	}
+
+	return &pb.Result{Event: convertEvent(event)}, nil
+}
+
+func (s *Server) RespondInvitation(ctx context.Context, e *pb.RespondInvitationRequest) (*pb.Result, error) {
+	event, err := s.app.RespondInvitation(ctx, userID(ctx), e.GetId(), storage.RSVPStatus(e.GetStatus()))
+	if err != nil {
+		return &pb.Result{}, statusError(err)
+	}
+
+	return &pb.Result{Event: convertEvent(event)}, nil
+}
+
 func (s *Server) EventByDay(ctx context.Context, e *pb.EventDay) (*pb.EventsResult, error) {
+	day, err := dateIn(e.GetDate(), e.GetTz())
+	if err != nil {
+		return &pb.EventsResult{}, statusError(err)
+	}
+
 	events, err := s.app.EventByDay(
 		ctx,
-		e.GetDate().AsTime(),
+		userID(ctx),
+		e.GetCalendarId(),
+		e.GetTag(),
+		day,
 	)
 	if err != nil {
-		return &pb.EventsResult{}, status.Error(codes.Unknown, err.Error())
+		return &pb.EventsResult{}, statusError(err)
 	}
 
 	return convert(events), nil
 }
 
 func (s *Server) EventByWeek(ctx context.Context, e *pb.EventDay) (*pb.EventsResult, error) {
+	day, err := dateIn(e.GetDate(), e.GetTz())
+	if err != nil {
+		return &pb.EventsResult{}, statusError(err)
+	}
+
 	events, err := s.app.EventByWeek(
 		ctx,
-		e.GetDate().AsTime(),
+		userID(ctx),
+		e.GetCalendarId(),
+		e.GetTag(),
+		day,
+		e.GetAligned(),
 	)
 	if err != nil {
-		return &pb.EventsResult{}, status.Error(codes.Unknown, err.Error())
+		return &pb.EventsResult{}, statusError(err)
 	}
 
 	return convert(events), nil
 }
 
 func (s *Server) EventByMonth(ctx context.Context, e *pb.EventDay) (*pb.EventsResult, error) {
+	day, err := dateIn(e.GetDate(), e.GetTz())
+	if err != nil {
+		return &pb.EventsResult{}, statusError(err)
+	}
+
 	events, err := s.app.EventByMonth(
 		ctx,
-		e.GetDate().AsTime(),
+		userID(ctx),
+		e.GetCalendarId(),
+		e.GetTag(),
+		day,
+		e.GetAligned(),
 	)
 	if err != nil {
-		return &pb.EventsResult{}, status.Error(codes.Unknown, err.Error())
+		return &pb.EventsResult{}, statusError(err)
 	}
 
 	return convert(events), nil
 }
 
+func (s *Server) ListEvents(ctx context.Context, e *pb.ListEventsRequest) (*pb.EventsPage, error) {
+	page, err := s.app.ListEvents(ctx, userID(ctx), storage.ListFilter{
+		From:            e.GetFrom().AsTime(),
+		To:              e.GetTo().AsTime(),
+		Query:           e.GetQuery(),
+		HasNotification: e.HasNotification,
+		Cursor:          e.GetCursor(),
+		Limit:           int(e.GetLimit()),
+		CalendarID:      e.GetCalendarId(),
+	})
+	if err != nil {
+		return &pb.EventsPage{}, statusError(err)
+	}
+
+	return &pb.EventsPage{Events: convert(page.Events).GetEvents(), NextCursor: page.NextCursor}, nil
+}
+
+func (s *Server) Search(ctx context.Context, e *pb.SearchRequest) (*pb.SearchResult, error) {
+	results, err := s.app.SearchEvents(ctx, userID(ctx), storage.SearchFilter{
+		Query: e.GetQuery(),
+		From:  optionalTime(e.GetFrom()),
+		To:    optionalTime(e.GetTo()),
+		Limit: int(e.GetLimit()),
+	})
+	if err != nil {
+		return &pb.SearchResult{}, statusError(err)
+	}
+
+	hits := make([]*pb.SearchHit, 0, len(results))
+	for _, r := range results {
+		hits = append(hits, &pb.SearchHit{Event: convertEvent(r.Event), Rank: r.Rank})
+	}
+
+	return &pb.SearchResult{Hits: hits}, nil
+}
+
+func (s *Server) Export(ctx context.Context, e *pb.ExportEvents) (*pb.ICalendar, error) {
+	day, err := dateIn(e.GetDate(), e.GetTz())
+	if err != nil {
+		return &pb.ICalendar{}, statusError(err)
+	}
+
+	var events []storage.Event
+	switch e.GetPeriod() {
+	case pb.Period_PERIOD_DAY:
+		events, err = s.app.EventByDay(ctx, userID(ctx), e.GetCalendarId(), e.GetTag(), day)
+	case pb.Period_PERIOD_WEEK:
+		events, err = s.app.EventByWeek(ctx, userID(ctx), e.GetCalendarId(), e.GetTag(), day, e.GetAligned())
+	case pb.Period_PERIOD_MONTH:
+		events, err = s.app.EventByMonth(ctx, userID(ctx), e.GetCalendarId(), e.GetTag(), day, e.GetAligned())
+	default:
+		return &pb.ICalendar{}, status.Error(codes.InvalidArgument, "unknown period")
+	}
+	if err != nil {
+		return &pb.ICalendar{}, statusError(err)
+	}
+
+	b := &bytes.Buffer{}
+	if err := ical.Encode(b, events); err != nil {
+		return &pb.ICalendar{}, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.ICalendar{Data: b.Bytes()}, nil
+}
+
+func (s *Server) Import(ctx context.Context, e *pb.ImportEvents) (*pb.ImportResult, error) {
+	events, err := ical.Decode(bytes.NewReader(e.GetData()))
+	if err != nil {
+		return &pb.ImportResult{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	for i := range events {
+		events[i].CalendarID = e.GetCalendarId()
+	}
+
+	result := &pb.ImportResult{}
+	for _, v := range s.app.ImportEvents(ctx, userID(ctx), events) {
+		imported := &pb.ImportedEvent{Uid: v.UID, Title: v.Title, Status: pb.ImportStatus_IMPORT_STATUS_CREATED}
+		switch {
+		case errors.Is(v.Err, storage.ErrDateBusy), errors.Is(v.Err, storage.ErrResourceBusy):
+			imported.Status, imported.Error = pb.ImportStatus_IMPORT_STATUS_CONFLICT, v.Err.Error()
+		case v.Err != nil:
+			imported.Status, imported.Error = pb.ImportStatus_IMPORT_STATUS_FAILED, v.Err.Error()
+		}
+		result.Events = append(result.Events, imported)
+	}
+
+	return result, nil
+}
+
+func (s *Server) FreeBusy(ctx context.Context, e *pb.FreeBusyRequest) (*pb.FreeBusyResult, error) {
+	fb, err := s.app.FreeBusy(
+		ctx,
+		userID(ctx),
+		e.GetUserId(),
+		e.GetFrom().AsTime(),
+		e.GetTo().AsTime(),
+		e.GetDuration().AsDuration(),
+	)
+	if err != nil {
+		return &pb.FreeBusyResult{}, statusError(err)
+	}
+
+	result := &pb.FreeBusyResult{Busy: convertIntervals(fb.Busy), Free: convertIntervals(fb.Free)}
+	if fb.Next != nil {
+		result.Next = &pb.Interval{Start: timestamppb.New(fb.Next.Start), End: timestamppb.New(fb.Next.End)}
+	}
+
+	return result, nil
+}
+
+func convertIntervals(intervals []app.Interval) []*pb.Interval {
+	result := make([]*pb.Interval, 0, len(intervals))
+	for _, v := range intervals {
+		result = append(result, &pb.Interval{Start: timestamppb.New(v.Start), End: timestamppb.New(v.End)})
+	}
+
+	return result
+}
+
 func convert(events []storage.Event) *pb.EventsResult {
 	result := make([]*pb.Event, 0, len(events))
 	for _, event := range events {
-		eventResult := &pb.Event{
-			Id:          event.ID,
-			Title:       event.Title,
-			StartAt:     timestamppb.New(event.StartAt),
-			Duration:    durationpb.New(event.EndAt.Sub(event.StartAt)),
-			Description: event.Description,
-			AuthorId:    event.AuthorID,
-		}
-		result = append(result, eventResult)
+		result = append(result, convertEvent(event))
 	}
 
 	return &pb.EventsResult{Events: result}
 }
+
+func convertEvent(event storage.Event) *pb.Event {
+	result := &pb.Event{
+		Id:          event.ID,
+		Title:       event.Title,
+		StartAt:     timestamppb.New(event.StartAt),
+		Duration:    durationpb.New(event.EndAt.Sub(event.StartAt)),
+		Description: event.Description,
+		AuthorId:    event.AuthorID,
+		Version:     event.Version,
+		TimeZone:    event.TimeZone,
+		CalendarId:  event.CalendarID,
+		Tags:        event.Tags,
+		Color:       event.Color,
+		Location:    convertLocation(event.Place),
+		MeetingUrl:  event.MeetingURL,
+		Resources:   event.Resources,
+		AllDay:      event.AllDay,
+	}
+	for _, offset := range event.Reminders {
+		result.Reminders = append(result.Reminders, durationpb.New(offset))
+	}
+	if len(event.Reminders) > 0 {
+		result.NotificationAt = timestamppb.New(event.StartAt.Add(-event.Reminders[0]))
+	}
+	if !event.DeletedAt.IsZero() {
+		result.DeletedAt = timestamppb.New(event.DeletedAt)
+	}
+	for _, a := range event.Attendees {
+		result.Attendees = append(result.Attendees, &pb.Attendee{
+			UserId: a.UserID,
+			Role:   string(a.Role),
+			Status: string(a.Status),
+		})
+	}
+	if event.IsRecurring() {
+		result.Rrule = event.Recurrence.String()
+		for _, exdate := range event.Recurrence.Exceptions {
+			result.Exdates = append(result.Exdates, timestamppb.New(exdate))
+		}
+	}
+
+	return result
+}
+
+// eventFields поля события, общие для pb.CreateEvent и pb.Event.
+type eventFields interface {
+	GetTitle() string
+	GetStartAt() *timestamppb.Timestamp
+	GetTimeZone() string
+	GetDuration() *durationpb.Duration
+	GetAllDay() bool
+	GetDescription() string
+	GetCalendarId() string
+	GetNotificationAt() *timestamppb.Timestamp
+	GetReminders() []*durationpb.Duration
+	GetTags() []string
+	GetColor() string
+	GetLocation() *pb.Location
+	GetMeetingUrl() string
+	GetResources() []string
+}
+
+// eventInput возвращает поля события для создания и изменения.
+func eventInput(e eventFields, recurrence *storage.Recurrence) app.EventInput {
+	return app.EventInput{
+		Title:       e.GetTitle(),
+		StartAt:     e.GetStartAt().AsTime(),
+		TimeZone:    e.GetTimeZone(),
+		Duration:    e.GetDuration().AsDuration(),
+		AllDay:      e.GetAllDay(),
+		Description: e.GetDescription(),
+		CalendarID:  e.GetCalendarId(),
+		Reminders:   parseReminders(e.GetStartAt(), e.GetNotificationAt(), e.GetReminders()),
+		Tags:        e.GetTags(),
+		Color:       e.GetColor(),
+		Location:    parseLocation(e.GetLocation()),
+		MeetingURL:  e.GetMeetingUrl(),
+		Resources:   e.GetResources(),
+		Recurrence:  recurrence,
+	}
+}
+
+// parseLocation возвращает место проведения события, отсутствующее поле location - место не указано.
+func parseLocation(l *pb.Location) storage.Location {
+	location := storage.Location{Text: l.GetText()}
+	if c := l.GetCoordinates(); c != nil {
+		location.Coordinates = &storage.Coordinates{Latitude: c.GetLatitude(), Longitude: c.GetLongitude()}
+	}
+
+	return location
+}
+
+func convertLocation(l storage.Location) *pb.Location {
+	if l.IsZero() {
+		return nil
+	}
+
+	location := &pb.Location{Text: l.Text}
+	if c := l.Coordinates; c != nil {
+		location.Coordinates = &pb.Coordinates{Latitude: c.Latitude, Longitude: c.Longitude}
+	}
+
+	return location
+}
+
+func statusError(err error) error {
+	switch {
+	case errors.Is(err, app.ErrUserNotSpecified):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, storage.ErrAccessDenied), errors.Is(err, storage.ErrNotInvited),
+		errors.Is(err, storage.ErrCalendarAccessDenied), errors.Is(err, storage.ErrResourceAccessDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, storage.ErrEventNotFound), errors.Is(err, storage.ErrCalendarNotFound),
+		errors.Is(err, storage.ErrResourceNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, storage.ErrCalendarNotEmpty), errors.Is(err, storage.ErrResourceInUse),
+		errors.Is(err, storage.ErrDateBusy), errors.Is(err, storage.ErrResourceBusy):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, storage.ErrVersionConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, app.ErrInvalidRange), errors.Is(err, app.ErrInvalidUserID),
+		errors.Is(err, app.ErrInvalidLimit), errors.Is(err, storage.ErrInvalidCursor),
+		errors.Is(err, storage.ErrInvalidAttendee), errors.Is(err, storage.ErrInvalidRSVP),
+		errors.Is(err, storage.ErrInvalidReminder), errors.Is(err, storage.ErrInvalidTimeZone),
+		errors.Is(err, storage.ErrInvalidCalendar), errors.Is(err, storage.ErrInvalidShare),
+		errors.Is(err, storage.ErrInvalidQuery), errors.Is(err, storage.ErrInvalidTag),
+		errors.Is(err, storage.ErrInvalidColor), errors.Is(err, storage.ErrInvalidLocation),
+		errors.Is(err, storage.ErrInvalidMeetingURL), errors.Is(err, storage.ErrInvalidResource),
+		errors.Is(err, storage.ErrInvalidRecurrence), errors.Is(err, storage.ErrInvalidWeekday),
+		errors.Is(err, ical.ErrInvalidCalendar), errors.Is(err, ical.ErrInvalidDuration):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Unknown, err.Error())
+	}
+}
+
+// optionalTime возвращает нулевое время для незаданной метки, а не начало эпохи.
+func optionalTime(t *timestamppb.Timestamp) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+
+	return t.AsTime()
+}
+
+func parseRecurrence(rule string, exdates []*timestamppb.Timestamp) (*storage.Recurrence, error) {
+	recurrence, err := storage.ParseRecurrence(rule)
+	if err != nil || recurrence == nil {
+		return nil, err
+	}
+
+	for _, exdate := range exdates {
+		recurrence.Exceptions = append(recurrence.Exceptions, exdate.AsTime())
+	}
+
+	return recurrence, nil
+}
+
+// parseReminders возвращает смещения напоминаний, notification_at оставлен
+// для совместимости и добавляет еще одно напоминание.
+func parseReminders(
+	startAt, notificationAt *timestamppb.Timestamp,
+	reminders []*durationpb.Duration,
+) []time.Duration {
+	result := make([]time.Duration, 0, len(reminders)+1)
+	for _, offset := range reminders {
+		result = append(result, offset.AsDuration())
+	}
+	if notificationAt != nil {
+		result = append(result, startAt.AsTime().Sub(notificationAt.AsTime()))
+	}
+
+	return result
+}
+
+// dateIn возвращает начало суток в часовом поясе tz для календарной даты date.
+// Дата берется из date в UTC: перевод метки в tz сдвигал бы день западнее UTC.
+func dateIn(date *timestamppb.Timestamp, tz string) (time.Time, error) {
+	loc, err := storage.LoadLocation(tz)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	y, m, d := date.AsTime().UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc), nil
+}
@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/app"
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/ical"
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{name: "user not specified", err: app.ErrUserNotSpecified, code: codes.Unauthenticated},
+		{name: "access denied", err: storage.ErrAccessDenied, code: codes.PermissionDenied},
+		{name: "not found", err: storage.ErrEventNotFound, code: codes.NotFound},
+		{name: "date busy", err: storage.ErrDateBusy, code: codes.FailedPrecondition},
+		{name: "version conflict", err: storage.ErrVersionConflict, code: codes.Aborted},
+		{name: "invalid range", err: app.ErrInvalidRange, code: codes.InvalidArgument},
+		{
+			name: "invalid recurrence",
+			err:  fmt.Errorf("%w: интервал должен быть больше 0", storage.ErrInvalidRecurrence),
+			code: codes.InvalidArgument,
+		},
+		{name: "invalid weekday", err: fmt.Errorf("%w: %q", storage.ErrInvalidWeekday, "XX"), code: codes.InvalidArgument},
+		{
+			name: "invalid icalendar",
+			err:  fmt.Errorf("%w: VEVENT не закрыт", ical.ErrInvalidCalendar),
+			code: codes.InvalidArgument,
+		},
+		{name: "invalid duration", err: fmt.Errorf("%w: %q", ical.ErrInvalidDuration, "PT"), code: codes.InvalidArgument},
+		{name: "unknown", err: errors.New("unknown"), code: codes.Unknown},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := statusError(tc.err)
+			require.Equal(t, tc.code, status.Code(err))
+			require.Equal(t, tc.err.Error(), status.Convert(err).Message())
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package internalhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+const (
+	calendarsPath = "/calendars"
+	sharesPath    = "shares"
+)
+
+type calendar struct {
+	ID      string  `json:"id,omitempty"`
+	Name    string  `json:"name"`
+	OwnerID string  `json:"ownerId,omitempty"`
+	Shares  []share `json:"shares,omitempty"`
+}
+
+type share struct {
+	UserID     string `json:"userId"`
+	Permission string `json:"permission"`
+}
+
+// calendars обрабатывает GET и POST /calendars, GET, PUT и DELETE /calendars/{id},
+// POST /calendars/{id}/shares и DELETE /calendars/{id}/shares/{userId}.
+func (h *Handler) calendars(ctx context.Context, r *http.Request) result {
+	userID := r.Header.Get(userIDHeader)
+	if r.URL.Path == calendarsPath {
+		switch r.Method {
+		case http.MethodGet:
+			calendars, err := h.app.ListCalendars(ctx, userID)
+
+			return result{Error: err, Calendars: convertCalendars(calendars)}
+		case http.MethodPost:
+			var body calendar
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				return result{Error: err}
+			}
+
+			c, err := h.app.CreateCalendar(ctx, userID, body.Name)
+			if err != nil {
+				return result{Error: err}
+			}
+
+			return result{Success: "Календарь создан", Calendar: convertCalendar(c)}
+		default:
+			return result{Error: ErrNotSupportedMethod}
+		}
+	}
+
+	path, ok := strings.CutPrefix(r.URL.Path, calendarsPath+"/")
+	if !ok {
+		return result{Error: ErrPageNotFound}
+	}
+
+	parts := strings.Split(path, "/")
+	switch {
+	case len(parts) == 1:
+		return h.calendar(ctx, r, parts[0])
+	case len(parts) == 2 && parts[1] == sharesPath && r.Method == http.MethodPost:
+		var body share
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return result{Error: err}
+		}
+
+		c, err := h.app.ShareCalendar(ctx, userID, parts[0], storage.CalendarShare{
+			UserID:     body.UserID,
+			Permission: storage.Permission(body.Permission),
+		})
+		if err != nil {
+			return result{Error: err}
+		}
+
+		return result{Success: "Доступ к календарю открыт", Calendar: convertCalendar(c)}
+	case len(parts) == 3 && parts[1] == sharesPath && r.Method == http.MethodDelete:
+		c, err := h.app.UnshareCalendar(ctx, userID, parts[0], parts[2])
+		if err != nil {
+			return result{Error: err}
+		}
+
+		return result{Success: "Доступ к календарю закрыт", Calendar: convertCalendar(c)}
+	case (len(parts) == 2 || len(parts) == 3) && parts[1] == sharesPath:
+		return result{Error: ErrNotSupportedMethod}
+	default:
+		return result{Error: ErrPageNotFound}
+	}
+}
+
+// calendar обрабатывает GET, PUT и DELETE /calendars/{id}.
+func (h *Handler) calendar(ctx context.Context, r *http.Request, id string) result {
+	userID := r.Header.Get(userIDHeader)
+	switch r.Method {
+	case http.MethodGet:
+		c, err := h.app.GetCalendar(ctx, userID, id)
+		if err != nil {
+			return result{Error: err}
+		}
+
+		return result{Calendar: convertCalendar(c)}
+	case http.MethodPut:
+		var body calendar
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return result{Error: err}
+		}
+
+		c, err := h.app.UpdateCalendar(ctx, userID, id, body.Name)
+		if err != nil {
+			return result{Error: err}
+		}
+
+		return result{Success: "Календарь обновлен", Calendar: convertCalendar(c)}
+	case http.MethodDelete:
+		if err := h.app.DeleteCalendar(ctx, userID, id); err != nil {
+			return result{Error: err}
+		}
+
+		return result{Success: "Календарь удален"}
+	default:
+		return result{Error: ErrNotSupportedMethod}
+	}
+}
+
+func convertCalendars(calendars []storage.Calendar) []*calendar {
+	r := make([]*calendar, 0, len(calendars))
+	for _, c := range calendars {
+		r = append(r, convertCalendar(c))
+	}
+
+	return r
+}
+
+func convertCalendar(c storage.Calendar) *calendar {
+	cResult := &calendar{ID: c.ID, Name: c.Name, OwnerID: c.OwnerID}
+	for _, s := range c.Shares {
+		cResult.Shares = append(cResult.Shares, share{UserID: s.UserID, Permission: string(s.Permission)})
+	}
+
+	return cResult
+}
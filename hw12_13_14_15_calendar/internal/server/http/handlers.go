@@ -6,10 +6,12 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/app"
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/ical"
 	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/logger"
 	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
 )
@@ -20,30 +22,136 @@ type Handler struct {
 }
 
 type result struct {
-	Events  []*event `json:"events,omitempty"`
-	Error   error    `json:"error,omitempty"`
-	Success string   `json:"success,omitempty"`
+	Event      *event      `json:"event,omitempty"`
+	Events     []*event    `json:"events,omitempty"`
+	Imported   []*imported `json:"imported,omitempty"`
+	FreeBusy   *freeBusy   `json:"freeBusy,omitempty"`
+	History    []*change   `json:"history,omitempty"`
+	Calendar   *calendar   `json:"calendar,omitempty"`
+	Calendars  []*calendar `json:"calendars,omitempty"`
+	Resource   *resource   `json:"resource,omitempty"`
+	Resources  []*resource `json:"resources,omitempty"`
+	Results    []*found    `json:"results,omitempty"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+	Error      error       `json:"error,omitempty"`
+	Success    string      `json:"success,omitempty"`
+}
+
+type imported struct {
+	UID    string `json:"uid"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// change запись журнала изменений события.
+type change struct {
+	ID      int64     `json:"id"`
+	Action  string    `json:"action"`
+	ActorID string    `json:"actorId"`
+	At      time.Time `json:"at"`
+	Before  *event    `json:"before,omitempty"`
+	After   *event    `json:"after,omitempty"`
+}
+
+// found событие, найденное поиском, больший rank соответствует более релевантному событию.
+type found struct {
+	Event *event  `json:"event"`
+	Rank  float64 `json:"rank"`
+}
+
+type interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type freeBusy struct {
+	Busy []interval `json:"busy"`
+	Free []interval `json:"free"`
+	Next *interval  `json:"next,omitempty"`
 }
 
 type event struct {
-	ID             string    `json:"id,omitempty"`
-	Title          string    `json:"title"`
-	StartAt        time.Time `json:"startAt"`
-	Duration       float64   `json:"duration"`
-	Description    string    `json:"description"`
-	AuthorID       string    `json:"authorId"`
-	NotificationAt time.Time `json:"notificationAt"`
+	ID             string      `json:"id,omitempty"`
+	Title          string      `json:"title"`
+	StartAt        time.Time   `json:"startAt"`
+	TimeZone       string      `json:"timeZone,omitempty"`
+	Duration       float64     `json:"duration"`
+	AllDay         bool        `json:"allDay,omitempty"`
+	Description    string      `json:"description"`
+	AuthorID       string      `json:"authorId"`
+	CalendarID     string      `json:"calendarId,omitempty"`
+	NotificationAt time.Time   `json:"notificationAt"`
+	Reminders      []float64   `json:"reminders,omitempty"`
+	RRule          string      `json:"rrule,omitempty"`
+	ExDates        []time.Time `json:"exdates,omitempty"`
+	Version        int64       `json:"version,omitempty"`
+	DeletedAt      *time.Time  `json:"deletedAt,omitempty"`
+	Attendees      []attendee  `json:"attendees,omitempty"`
+	Tags           []string    `json:"tags,omitempty"`
+	Color          string      `json:"color,omitempty"`
+	Location       *location   `json:"location,omitempty"`
+	MeetingURL     string      `json:"meetingUrl,omitempty"`
+	Resources      []string    `json:"resources,omitempty"`
+}
+
+type location struct {
+	Text        string       `json:"text,omitempty"`
+	Coordinates *coordinates `json:"coordinates,omitempty"`
+}
+
+type coordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type attendee struct {
+	UserID string `json:"userId"`
+	Role   string `json:"role"`
+	Status string `json:"status,omitempty"`
+}
+
+type invitation struct {
+	Attendees []attendee `json:"attendees"`
+}
+
+type rsvp struct {
+	Status string `json:"status"`
 }
 
 type EventResult struct {
 	Events []*event `json:"events"`
 }
 
-const urlPath = "/events"
+const (
+	urlPath      = "/events"
+	userIDHeader = "X-User-ID"
+
+	etagHeader    = "ETag"
+	ifMatchHeader = "If-Match"
+
+	exportPath = urlPath + "/export"
+	importPath = urlPath + "/import"
+
+	freeBusyPath = urlPath + "/freebusy"
+	searchPath   = urlPath + "/search"
+
+	trashPath     = urlPath + "/trash"
+	restoreSuffix = "/restore"
+	historySuffix = "/history"
+
+	attendeesSuffix = "/attendees"
+	rsvpSuffix      = "/rsvp"
+
+	importStatusCreated  = "created"
+	importStatusConflict = "conflict"
+	importStatusFailed   = "failed"
+)
 
 var (
 	ErrNotSupportedMethod = errors.New("unsupported method")
 	ErrPageNotFound       = errors.New("page not found")
+	ErrInvalidETag        = errors.New("invalid If-Match header")
 )
 
 func NewHandlers(app app.App, l logger.Logger) Handler {
@@ -52,6 +160,16 @@ func NewHandlers(app app.App, l logger.Logger) Handler {
 
 func (h *Handler) Handlers(ctx context.Context) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == calendarsPath || strings.HasPrefix(r.URL.Path, calendarsPath+"/") {
+			h.writeResult(w, h.calendars(ctx, r))
+			return
+		}
+
+		if r.URL.Path == resourcesPath || strings.HasPrefix(r.URL.Path, resourcesPath+"/") {
+			h.writeResult(w, h.resources(ctx, r))
+			return
+		}
+
 		if !strings.HasPrefix(r.URL.Path, urlPath) {
 			if err := notFound(w); err != nil {
 				h.logger.Error(err)
@@ -59,46 +177,99 @@ func (h *Handler) Handlers(ctx context.Context) http.HandlerFunc {
 			}
 		}
 
+		if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, exportPath+"/") {
+			if res := h.export(ctx, w, r); res.Error != nil {
+				h.writeResult(w, res)
+			}
+			return
+		}
+
 		var res result
 		switch r.Method {
 		case http.MethodPost:
+			if r.URL.Path == importPath {
+				res = h.importEvents(ctx, r)
+				break
+			}
+			if strings.HasPrefix(r.URL.Path, urlPath+"/") && strings.HasSuffix(r.URL.Path, restoreSuffix) {
+				res = h.restore(ctx, r)
+				break
+			}
+			if strings.HasPrefix(r.URL.Path, urlPath+"/") && strings.HasSuffix(r.URL.Path, attendeesSuffix) {
+				res = h.invite(ctx, r)
+				break
+			}
+			if strings.HasPrefix(r.URL.Path, urlPath+"/") && strings.HasSuffix(r.URL.Path, rsvpSuffix) {
+				res = h.respond(ctx, r)
+				break
+			}
 			res = h.create(ctx, r)
 		case http.MethodPut:
 			res = h.update(ctx, r)
 		case http.MethodDelete:
 			res = h.delete(ctx, r)
 		case http.MethodGet:
+			if r.URL.Path == freeBusyPath {
+				res = h.freeBusy(ctx, r)
+				break
+			}
+			if r.URL.Path == trashPath {
+				res = h.trash(ctx, r)
+				break
+			}
+			if r.URL.Path == searchPath {
+				res = h.search(ctx, r)
+				break
+			}
 			res = h.get(ctx, r)
 		default:
 			res = result{Error: ErrNotSupportedMethod}
 		}
 
-		data, err := json.Marshal(res)
-		if err != nil {
+		h.writeResult(w, res)
+	}
+}
+
+func (h *Handler) writeResult(w http.ResponseWriter, res result) {
+	data, err := json.Marshal(res)
+	if err != nil {
+		h.logger.Error(err)
+		if err := internalError(w, err); err != nil {
 			h.logger.Error(err)
-			if err := internalError(w, err); err != nil {
-				h.logger.Error(err)
-			}
-			return
 		}
+		return
+	}
 
-		w.Header().Add("Content-Type", "application/json")
-		if res.Error != nil {
-			switch {
-			case errors.Is(res.Error, ErrPageNotFound):
-				w.WriteHeader(http.StatusNotFound)
-			case errors.Is(res.Error, ErrNotSupportedMethod):
-				w.WriteHeader(http.StatusMethodNotAllowed)
-			default:
-				w.WriteHeader(http.StatusBadRequest)
-			}
-
-			h.logger.Error(res.Error)
+	w.Header().Add("Content-Type", "application/json")
+	if res.Event != nil && res.Event.Version > 0 {
+		w.Header().Set(etagHeader, etag(res.Event.Version))
+	}
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, ErrPageNotFound), errors.Is(res.Error, storage.ErrEventNotFound),
+			errors.Is(res.Error, storage.ErrCalendarNotFound), errors.Is(res.Error, storage.ErrResourceNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Is(res.Error, ErrNotSupportedMethod):
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case errors.Is(res.Error, app.ErrUserNotSpecified):
+			w.WriteHeader(http.StatusUnauthorized)
+		case errors.Is(res.Error, storage.ErrAccessDenied), errors.Is(res.Error, storage.ErrNotInvited),
+			errors.Is(res.Error, storage.ErrCalendarAccessDenied), errors.Is(res.Error, storage.ErrResourceAccessDenied):
+			w.WriteHeader(http.StatusForbidden)
+		case errors.Is(res.Error, storage.ErrCalendarNotEmpty), errors.Is(res.Error, storage.ErrResourceInUse),
+			errors.Is(res.Error, storage.ErrDateBusy), errors.Is(res.Error, storage.ErrResourceBusy):
+			w.WriteHeader(http.StatusConflict)
+		case errors.Is(res.Error, storage.ErrVersionConflict):
+			w.WriteHeader(http.StatusPreconditionFailed)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
 		}
 
-		if _, err := w.Write(data); err != nil {
-			h.logger.Error(err)
-		}
+		h.logger.Error(res.Error)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		h.logger.Error(err)
 	}
 }
 
@@ -129,25 +300,82 @@ func (h *Handler) unmarshalEvent(r *http.Request) (*event, error) {
 	return e, nil
 }
 
+func (e *event) recurrence() (*storage.Recurrence, error) {
+	r, err := storage.ParseRecurrence(e.RRule)
+	if err != nil || r == nil {
+		return nil, err
+	}
+	r.Exceptions = e.ExDates
+
+	return r, nil
+}
+
+// reminders возвращает смещения напоминаний до начала события. Reminders
+// задаются в секундах, notificationAt поддерживается для совместимости и
+// добавляет одно напоминание.
+func (e *event) reminders() []time.Duration {
+	r := make([]time.Duration, 0, len(e.Reminders)+1)
+	for _, seconds := range e.Reminders {
+		r = append(r, time.Duration(seconds*float64(time.Second)))
+	}
+	if !e.NotificationAt.IsZero() {
+		r = append(r, e.StartAt.Sub(e.NotificationAt))
+	}
+
+	return r
+}
+
+// place возвращает место проведения события, отсутствующее поле location - место не указано.
+func (e *event) place() storage.Location {
+	if e.Location == nil {
+		return storage.Location{}
+	}
+
+	l := storage.Location{Text: e.Location.Text}
+	if c := e.Location.Coordinates; c != nil {
+		l.Coordinates = &storage.Coordinates{Latitude: c.Latitude, Longitude: c.Longitude}
+	}
+
+	return l
+}
+
+// input возвращает поля события для создания и изменения.
+func (e *event) input(recurrence *storage.Recurrence) app.EventInput {
+	return app.EventInput{
+		Title:       e.Title,
+		StartAt:     e.StartAt,
+		TimeZone:    e.TimeZone,
+		Duration:    time.Duration(e.Duration) * time.Second,
+		AllDay:      e.AllDay,
+		Description: e.Description,
+		CalendarID:  e.CalendarID,
+		Reminders:   e.reminders(),
+		Tags:        e.Tags,
+		Color:       e.Color,
+		Location:    e.place(),
+		MeetingURL:  e.MeetingURL,
+		Resources:   e.Resources,
+		Recurrence:  recurrence,
+	}
+}
+
 func (h *Handler) create(ctx context.Context, r *http.Request) result {
 	e, err := h.unmarshalEvent(r)
 	if err != nil {
 		return result{Error: err}
 	}
 
-	if err := h.app.CreateEvent(
-		ctx,
-		e.Title,
-		e.StartAt,
-		time.Duration(e.Duration)*time.Second,
-		e.Description,
-		e.AuthorID,
-		e.NotificationAt,
-	); err != nil {
+	recurrence, err := e.recurrence()
+	if err != nil {
+		return result{Error: err}
+	}
+
+	created, err := h.app.CreateEvent(ctx, r.Header.Get(userIDHeader), e.input(recurrence))
+	if err != nil {
 		return result{Error: err}
 	}
 
-	return result{Success: "Событие успешно добавлено в календарь"}
+	return result{Success: "Событие успешно добавлено в календарь", Event: convertEvent(created)}
 }
 
 func (h *Handler) update(ctx context.Context, r *http.Request) result {
@@ -162,20 +390,22 @@ func (h *Handler) update(ctx context.Context, r *http.Request) result {
 
 	id := r.URL.Path[len(urlPath)+1:]
 
-	if err := h.app.UpdateEvent(
-		ctx,
-		id,
-		e.Title,
-		e.StartAt,
-		time.Duration(e.Duration)*time.Second,
-		e.Description,
-		e.AuthorID,
-		e.NotificationAt,
-	); err != nil {
+	recurrence, err := e.recurrence()
+	if err != nil {
 		return result{Error: err}
 	}
 
-	return result{Success: "Событие успешно обновлено"}
+	version, err := ifMatch(r)
+	if err != nil {
+		return result{Error: err}
+	}
+
+	updated, err := h.app.UpdateEvent(ctx, r.Header.Get(userIDHeader), id, e.input(recurrence), version)
+	if err != nil {
+		return result{Error: err}
+	}
+
+	return result{Success: "Событие успешно обновлено", Event: convertEvent(updated)}
 }
 
 func (h *Handler) delete(ctx context.Context, r *http.Request) result {
@@ -185,88 +415,426 @@ func (h *Handler) delete(ctx context.Context, r *http.Request) result {
 
 	id := r.URL.Path[len(urlPath)+1:]
 
+	version, err := ifMatch(r)
+	if err != nil {
+		return result{Error: err}
+	}
+
 	if err := h.app.DeleteEvent(
 		ctx,
+		r.Header.Get(userIDHeader),
 		id,
+		version,
 	); err != nil {
 		return result{Error: err}
 	}
 
-	return result{Success: "Событие успешно удалено"}
+	return result{Success: "Событие перемещено в корзину"}
+}
+
+// restore обрабатывает POST /events/{id}/restore.
+func (h *Handler) restore(ctx context.Context, r *http.Request) result {
+	id := strings.TrimSuffix(r.URL.Path[len(urlPath)+1:], restoreSuffix)
+
+	e, err := h.app.RestoreEvent(ctx, r.Header.Get(userIDHeader), id)
+	if err != nil {
+		return result{Error: err}
+	}
+
+	return result{Success: "Событие восстановлено", Event: convertEvent(e)}
+}
+
+// invite обрабатывает POST /events/{id}/attendees.
+func (h *Handler) invite(ctx context.Context, r *http.Request) result {
+	id := strings.TrimSuffix(r.URL.Path[len(urlPath)+1:], attendeesSuffix)
+
+	var body invitation
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return result{Error: err}
+	}
+
+	attendees := make([]storage.Attendee, 0, len(body.Attendees))
+	for _, v := range body.Attendees {
+		attendees = append(attendees, storage.Attendee{UserID: v.UserID, Role: storage.AttendeeRole(v.Role)})
+	}
+
+	e, err := h.app.InviteAttendees(ctx, r.Header.Get(userIDHeader), id, attendees)
+	if err != nil {
+		return result{Error: err}
+	}
+
+	return result{Success: "Участники приглашены", Event: convertEvent(e)}
+}
+
+// respond обрабатывает POST /events/{id}/rsvp.
+func (h *Handler) respond(ctx context.Context, r *http.Request) result {
+	id := strings.TrimSuffix(r.URL.Path[len(urlPath)+1:], rsvpSuffix)
+
+	var body rsvp
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return result{Error: err}
+	}
+
+	e, err := h.app.RespondInvitation(ctx, r.Header.Get(userIDHeader), id, storage.RSVPStatus(body.Status))
+	if err != nil {
+		return result{Error: err}
+	}
+
+	return result{Success: "Ответ на приглашение сохранен", Event: convertEvent(e)}
+}
+
+// history обрабатывает GET /events/{id}/history.
+func (h *Handler) history(ctx context.Context, r *http.Request, id string) result {
+	records, err := h.app.EventHistory(ctx, r.Header.Get(userIDHeader), id)
+	if err != nil {
+		return result{Error: err}
+	}
+
+	res := result{History: make([]*change, 0, len(records))}
+	for _, v := range records {
+		c := &change{ID: v.ID, Action: string(v.Action), ActorID: v.ActorID, At: v.At}
+		if v.Before != nil {
+			c.Before = convertEvent(*v.Before)
+		}
+		if v.After != nil {
+			c.After = convertEvent(*v.After)
+		}
+		res.History = append(res.History, c)
+	}
+
+	return res
+}
+
+// trash обрабатывает GET /events/trash.
+func (h *Handler) trash(ctx context.Context, r *http.Request) result {
+	events, err := h.app.ListDeleted(ctx, r.Header.Get(userIDHeader))
+
+	return result{Error: err, Events: convert(events)}
 }
 
 func (h *Handler) get(ctx context.Context, r *http.Request) result {
 	if r.URL.Path == urlPath {
-		return result{Error: ErrNotSupportedMethod}
+		return h.list(ctx, r)
+	}
+
+	path := r.URL.Path[len(urlPath)+1:]
+	if id, ok := strings.CutSuffix(path, historySuffix); ok && !strings.Contains(id, "/") {
+		return h.history(ctx, r, id)
+	}
+
+	if !strings.Contains(path, "/") {
+		e, err := h.app.GetEvent(ctx, r.Header.Get(userIDHeader), path)
+		if err != nil {
+			return result{Error: err}
+		}
+
+		return result{Event: convertEvent(e)}
 	}
-	query := strings.Split(r.URL.Path[len(urlPath)+1:], "/")
+
+	events, err := h.periodEvents(ctx, r, path)
+
+	return result{Error: err, Events: convert(events)}
+}
+
+// list обрабатывает GET /events?from=&to=&cursor=&limit=&q=&hasNotification=&calendar=.
+// Время передается в RFC3339.
+func (h *Handler) list(ctx context.Context, r *http.Request) result {
+	query := r.URL.Query()
+	filter := storage.ListFilter{
+		Query:      query.Get("q"),
+		Cursor:     query.Get("cursor"),
+		CalendarID: query.Get("calendar"),
+	}
+
+	var err error
+	if filter.From, err = time.Parse(time.RFC3339, query.Get("from")); err != nil {
+		return result{Error: err}
+	}
+
+	if filter.To, err = time.Parse(time.RFC3339, query.Get("to")); err != nil {
+		return result{Error: err}
+	}
+
+	if v := query.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			return result{Error: err}
+		}
+	}
+
+	if v := query.Get("hasNotification"); v != "" {
+		hasNotification, err := strconv.ParseBool(v)
+		if err != nil {
+			return result{Error: err}
+		}
+		filter.HasNotification = &hasNotification
+	}
+
+	page, err := h.app.ListEvents(ctx, r.Header.Get(userIDHeader), filter)
+	if err != nil {
+		return result{Error: err}
+	}
+
+	return result{Events: convert(page.Events), NextCursor: page.NextCursor}
+}
+
+// search обрабатывает GET /events/search?q=&from=&to=&limit=. Время передается
+// в RFC3339, from и to необязательны.
+func (h *Handler) search(ctx context.Context, r *http.Request) result {
+	query := r.URL.Query()
+	filter := storage.SearchFilter{Query: query.Get("q")}
+
+	var err error
+	if v := query.Get("from"); v != "" {
+		if filter.From, err = time.Parse(time.RFC3339, v); err != nil {
+			return result{Error: err}
+		}
+	}
+
+	if v := query.Get("to"); v != "" {
+		if filter.To, err = time.Parse(time.RFC3339, v); err != nil {
+			return result{Error: err}
+		}
+	}
+
+	if v := query.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			return result{Error: err}
+		}
+	}
+
+	results, err := h.app.SearchEvents(ctx, r.Header.Get(userIDHeader), filter)
+	if err != nil {
+		return result{Error: err}
+	}
+
+	res := make([]*found, 0, len(results))
+	for _, v := range results {
+		res = append(res, &found{Event: convertEvent(v.Event), Rank: v.Rank})
+	}
+
+	return result{Results: res}
+}
+
+// periodEvents обрабатывает пути вида day/2024-03-10. Параметр tz задает
+// часовой пояс IANA, в котором считаются границы суток, по умолчанию UTC.
+// При aligned=true неделя и месяц берутся по календарю, а не от переданной даты.
+// Параметр calendar ограничивает выборку событиями календаря, tag - событиями с тегом.
+func (h *Handler) periodEvents(ctx context.Context, r *http.Request, path string) ([]storage.Event, error) {
+	query := strings.Split(path, "/")
 	if len(query) != 2 || !contains(query[0], []string{"day", "week", "month"}) {
-		return result{Error: ErrPageNotFound}
+		return nil, ErrPageNotFound
+	}
+
+	loc, err := storage.LoadLocation(r.URL.Query().Get("tz"))
+	if err != nil {
+		return nil, err
+	}
+
+	aligned := false
+	if v := r.URL.Query().Get("aligned"); v != "" {
+		if aligned, err = strconv.ParseBool(v); err != nil {
+			return nil, err
+		}
 	}
 
 	method := query[0]
-	t, err := time.Parse(time.DateOnly, query[1])
+	t, err := time.ParseInLocation(time.DateOnly, query[1], loc)
 	if err != nil {
-		return result{Error: err}
+		return nil, err
 	}
 
+	userID := r.Header.Get(userIDHeader)
+	calendarID := r.URL.Query().Get("calendar")
+	tag := r.URL.Query().Get("tag")
+
 	switch method {
 	case "day":
-		return h.eventByDay(ctx, t)
+		return h.app.EventByDay(ctx, userID, calendarID, tag, t)
 	case "week":
-		return h.eventByWeek(ctx, t)
+		return h.app.EventByWeek(ctx, userID, calendarID, tag, t, aligned)
 	case "month":
-		return h.eventByMonth(ctx, t)
+		return h.app.EventByMonth(ctx, userID, calendarID, tag, t, aligned)
 	default:
-		return result{Error: ErrPageNotFound}
+		return nil, ErrPageNotFound
 	}
 }
 
-func (h *Handler) eventByDay(ctx context.Context, d time.Time) result {
-	events, err := h.app.EventByDay(
-		ctx,
-		d,
-	)
+func (h *Handler) export(ctx context.Context, w http.ResponseWriter, r *http.Request) result {
+	events, err := h.periodEvents(ctx, r, r.URL.Path[len(exportPath)+1:])
+	if err != nil {
+		return result{Error: err}
+	}
 
-	return result{Error: err, Events: convert(events)}
+	w.Header().Add("Content-Type", ical.ContentType)
+	if err := ical.Encode(w, events); err != nil {
+		h.logger.Error(err)
+	}
+
+	return result{}
 }
 
-func (h *Handler) eventByWeek(ctx context.Context, d time.Time) result {
-	events, err := h.app.EventByWeek(
-		ctx,
-		d,
-	)
+// importEvents обрабатывает POST /events/import, параметр calendar задает
+// календарь для всех импортируемых событий.
+func (h *Handler) importEvents(ctx context.Context, r *http.Request) result {
+	defer r.Body.Close()
 
-	return result{Error: err, Events: convert(events)}
+	events, err := ical.Decode(r.Body)
+	if err != nil {
+		return result{Error: err}
+	}
+
+	for i := range events {
+		events[i].CalendarID = r.URL.Query().Get("calendar")
+	}
+
+	res := result{Imported: make([]*imported, 0, len(events))}
+	for _, v := range h.app.ImportEvents(ctx, r.Header.Get(userIDHeader), events) {
+		i := &imported{UID: v.UID, Title: v.Title, Status: importStatusCreated}
+		switch {
+		case errors.Is(v.Err, storage.ErrDateBusy), errors.Is(v.Err, storage.ErrResourceBusy):
+			i.Status, i.Error = importStatusConflict, v.Err.Error()
+		case v.Err != nil:
+			i.Status, i.Error = importStatusFailed, v.Err.Error()
+		}
+		res.Imported = append(res.Imported, i)
+	}
+	res.Success = "Импорт завершен"
+
+	return res
 }
 
-func (h *Handler) eventByMonth(ctx context.Context, d time.Time) result {
-	events, err := h.app.EventByMonth(
+// freeBusy обрабатывает GET /events/freebusy?from=&to=&duration=&user=.
+// Время передается в RFC3339, длительность слота - в секундах.
+func (h *Handler) freeBusy(ctx context.Context, r *http.Request) result {
+	query := r.URL.Query()
+	from, err := time.Parse(time.RFC3339, query.Get("from"))
+	if err != nil {
+		return result{Error: err}
+	}
+
+	to, err := time.Parse(time.RFC3339, query.Get("to"))
+	if err != nil {
+		return result{Error: err}
+	}
+
+	var duration float64
+	if v := query.Get("duration"); v != "" {
+		if duration, err = strconv.ParseFloat(v, 64); err != nil {
+			return result{Error: err}
+		}
+	}
+
+	fb, err := h.app.FreeBusy(
 		ctx,
-		d,
+		r.Header.Get(userIDHeader),
+		query.Get("user"),
+		from,
+		to,
+		time.Duration(duration*float64(time.Second)),
 	)
+	if err != nil {
+		return result{Error: err}
+	}
 
-	return result{Error: err, Events: convert(events)}
+	res := &freeBusy{Busy: convertIntervals(fb.Busy), Free: convertIntervals(fb.Free)}
+	if fb.Next != nil {
+		res.Next = &interval{Start: fb.Next.Start, End: fb.Next.End}
+	}
+
+	return result{FreeBusy: res}
+}
+
+func convertIntervals(intervals []app.Interval) []interval {
+	r := make([]interval, 0, len(intervals))
+	for _, v := range intervals {
+		r = append(r, interval{Start: v.Start, End: v.End})
+	}
+
+	return r
+}
+
+// etag возвращает строгий ETag версии события.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatch возвращает ожидаемую версию из заголовка If-Match. Без заголовка
+// или со значением "*" версия не проверяется.
+func ifMatch(r *http.Request) (int64, error) {
+	value := strings.TrimSpace(r.Header.Get(ifMatchHeader))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	unquoted, err := strconv.Unquote(value)
+	if err != nil || !strings.HasPrefix(value, `"`) {
+		return 0, ErrInvalidETag
+	}
+
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, ErrInvalidETag
+	}
+
+	return version, nil
 }
 
 func convert(events []storage.Event) []*event {
 	r := make([]*event, 0, len(events))
 	for _, e := range events {
-		eResult := &event{
-			ID:             e.ID,
-			Title:          e.Title,
-			StartAt:        e.StartAt,
-			Duration:       e.EndAt.Sub(e.StartAt).Seconds(),
-			Description:    e.Description,
-			AuthorID:       e.AuthorID,
-			NotificationAt: e.NotificationDate,
-		}
-		r = append(r, eResult)
+		r = append(r, convertEvent(e))
 	}
 
 	return r
 }
 
+func convertEvent(e storage.Event) *event {
+	eResult := &event{
+		ID:          e.ID,
+		Title:       e.Title,
+		StartAt:     e.StartAt,
+		TimeZone:    e.TimeZone,
+		Duration:    e.EndAt.Sub(e.StartAt).Seconds(),
+		AllDay:      e.AllDay,
+		Description: e.Description,
+		AuthorID:    e.AuthorID,
+		CalendarID:  e.CalendarID,
+		Version:     e.Version,
+		Tags:        e.Tags,
+		Color:       e.Color,
+		MeetingURL:  e.MeetingURL,
+		Resources:   e.Resources,
+	}
+	if !e.Place.IsZero() {
+		eResult.Location = &location{Text: e.Place.Text}
+		if c := e.Place.Coordinates; c != nil {
+			eResult.Location.Coordinates = &coordinates{Latitude: c.Latitude, Longitude: c.Longitude}
+		}
+	}
+	for _, offset := range e.Reminders {
+		eResult.Reminders = append(eResult.Reminders, offset.Seconds())
+	}
+	if len(e.Reminders) > 0 {
+		eResult.NotificationAt = e.StartAt.Add(-e.Reminders[0])
+	}
+	if !e.DeletedAt.IsZero() {
+		eResult.DeletedAt = &e.DeletedAt
+	}
+	for _, a := range e.Attendees {
+		eResult.Attendees = append(eResult.Attendees, attendee{
+			UserID: a.UserID,
+			Role:   string(a.Role),
+			Status: string(a.Status),
+		})
+	}
+	if e.IsRecurring() {
+		eResult.RRule = e.Recurrence.String()
+		eResult.ExDates = e.Recurrence.Exceptions
+	}
+
+	return eResult
+}
+
 func contains(s string, a []string) bool {
 	for _, v := range a {
 		if v == s {
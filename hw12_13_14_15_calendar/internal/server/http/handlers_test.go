@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -11,7 +12,9 @@ import (
 	"time"
 
 	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/app"
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/ical"
 	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/logger"
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
 	memorystorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage/memory"
 	"github.com/stretchr/testify/require"
 )
@@ -45,6 +48,7 @@ func TestHandler_Handlers(t *testing.T) {
 		req, err := http.NewRequestWithContext(ctx, http.MethodGet, test.URL+"/events/day/2000-01-01", nil)
 		require.NoError(t, err)
 		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add(userIDHeader, e.AuthorID)
 		resp, err := httpClient.Do(req)
 		require.NoError(t, err)
 		defer resp.Body.Close()
@@ -61,6 +65,7 @@ func TestHandler_Handlers(t *testing.T) {
 		req, err := http.NewRequestWithContext(ctx, http.MethodGet, test.URL+"/events/2000-01-01", nil)
 		require.NoError(t, err)
 		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add(userIDHeader, e.AuthorID)
 		resp, err := httpClient.Do(req)
 		require.NoError(t, err)
 		defer resp.Body.Close()
@@ -77,19 +82,43 @@ func TestHandler_Handlers(t *testing.T) {
 		req, err := http.NewRequestWithContext(ctx, http.MethodPost, test.URL+"/events", bytes.NewReader(data))
 		require.NoError(t, err)
 		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add(userIDHeader, e.AuthorID)
 		resp, err := httpClient.Do(req)
 		require.NoError(t, err)
 		defer resp.Body.Close()
 		require.Equal(t, http.StatusOK, resp.StatusCode)
+		out, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		created := &result{}
+		err = json.Unmarshal(out, created)
+		require.NoError(t, err)
+		require.NotNil(t, created.Event)
+		require.NotEmpty(t, created.Event.ID)
+		// Получим запись по id
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, test.URL+"/events/"+created.Event.ID, nil)
+		require.NoError(t, err)
+		req.Header.Add(userIDHeader, e.AuthorID)
+		resp, err = httpClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		out, err = io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		got := &result{}
+		err = json.Unmarshal(out, got)
+		require.NoError(t, err)
+		require.Equal(t, created.Event, got.Event)
+		require.Equal(t, e.Title, got.Event.Title)
 		// Проверим что запись создалась
 		req, err = http.NewRequestWithContext(ctx, http.MethodGet, test.URL+"/events/day/"+d.Format(time.DateOnly), nil)
 		require.NoError(t, err)
 		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add(userIDHeader, e.AuthorID)
 		resp, err = httpClient.Do(req)
 		require.NoError(t, err)
 		defer resp.Body.Close()
 		require.Equal(t, http.StatusOK, resp.StatusCode)
-		out, err := io.ReadAll(resp.Body)
+		out, err = io.ReadAll(resp.Body)
 		require.NoError(t, err)
 		// Проверим что запись имеет правильные значения и сохраним сгенерированный id
 		te := &result{}
@@ -102,6 +131,7 @@ func TestHandler_Handlers(t *testing.T) {
 		require.Equal(t, e.Description, te.Events[0].Description)
 		require.Equal(t, e.AuthorID, te.Events[0].AuthorID)
 		id := te.Events[0].ID
+		require.Equal(t, created.Event.ID, id)
 		// Обновим запись
 		e.Title = "Test after update"
 		data, err = json.Marshal(e)
@@ -109,6 +139,7 @@ func TestHandler_Handlers(t *testing.T) {
 		req, err = http.NewRequestWithContext(ctx, http.MethodPut, test.URL+"/events/"+id, bytes.NewReader(data))
 		require.NoError(t, err)
 		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add(userIDHeader, e.AuthorID)
 		resp, err = httpClient.Do(req)
 		require.NoError(t, err)
 		defer resp.Body.Close()
@@ -117,6 +148,7 @@ func TestHandler_Handlers(t *testing.T) {
 		req, err = http.NewRequestWithContext(ctx, http.MethodGet, test.URL+"/events/day/"+d.Format(time.DateOnly), nil)
 		require.NoError(t, err)
 		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add(userIDHeader, e.AuthorID)
 		resp, err = httpClient.Do(req)
 		require.NoError(t, err)
 		defer resp.Body.Close()
@@ -135,6 +167,7 @@ func TestHandler_Handlers(t *testing.T) {
 		// Удалим запись
 		req, err = http.NewRequestWithContext(ctx, http.MethodDelete, test.URL+"/events/"+id, nil)
 		require.NoError(t, err)
+		req.Header.Add(userIDHeader, e.AuthorID)
 		resp, err = httpClient.Do(req)
 		require.NoError(t, err)
 		defer resp.Body.Close()
@@ -143,6 +176,7 @@ func TestHandler_Handlers(t *testing.T) {
 		req, err = http.NewRequestWithContext(ctx, http.MethodGet, test.URL+"/events/day/"+d.Format(time.DateOnly), nil)
 		require.NoError(t, err)
 		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add(userIDHeader, e.AuthorID)
 		resp, err = httpClient.Do(req)
 		require.NoError(t, err)
 		defer resp.Body.Close()
@@ -150,5 +184,1136 @@ func TestHandler_Handlers(t *testing.T) {
 		out, err = io.ReadAll(resp.Body)
 		require.NoError(t, err)
 		require.Equal(t, exceptedEmpty, string(out))
+		// Удаленная запись не находится по id
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, test.URL+"/events/"+id, nil)
+		require.NoError(t, err)
+		req.Header.Add(userIDHeader, e.AuthorID)
+		resp, err = httpClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+	t.Run("import and export", func(t *testing.T) {
+		test := httptest.NewServer(h.Handlers(ctx))
+		defer test.Close()
+		data := "BEGIN:VCALENDAR\r\n" +
+			"VERSION:2.0\r\n" +
+			"BEGIN:VEVENT\r\n" +
+			"UID:first\r\n" +
+			"DTSTART:20230701T100000Z\r\n" +
+			"DTEND:20230701T110000Z\r\n" +
+			"SUMMARY:First\r\n" +
+			"END:VEVENT\r\n" +
+			"BEGIN:VEVENT\r\n" +
+			"UID:second\r\n" +
+			"DTSTART:20230701T103000Z\r\n" +
+			"DTEND:20230701T113000Z\r\n" +
+			"SUMMARY:Second\r\n" +
+			"END:VEVENT\r\n" +
+			"END:VCALENDAR\r\n"
+
+		// Импортируем события, второе пересекается с первым
+		req, err := http.NewRequestWithContext(
+			ctx,
+			http.MethodPost,
+			test.URL+"/events/import",
+			bytes.NewReader([]byte(data)),
+		)
+		require.NoError(t, err)
+		req.Header.Add("Content-Type", "text/calendar")
+		req.Header.Add(userIDHeader, e.AuthorID)
+		resp, err := httpClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		out, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		te := &result{}
+		err = json.Unmarshal(out, te)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(te.Imported))
+		require.Equal(t, importStatusCreated, te.Imported[0].Status)
+		require.Equal(t, "first", te.Imported[0].UID)
+		require.Equal(t, importStatusConflict, te.Imported[1].Status)
+		require.Equal(t, "second", te.Imported[1].UID)
+		// Выгрузим события за день
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, test.URL+"/events/export/day/2023-07-01", nil)
+		require.NoError(t, err)
+		req.Header.Add(userIDHeader, e.AuthorID)
+		resp, err = httpClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, ical.ContentType, resp.Header.Get("Content-Type"))
+		events, err := ical.Decode(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(events))
+		require.Equal(t, "First", events[0].Title)
+		// Неизвестный период
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, test.URL+"/events/export/year/2023-07-01", nil)
+		require.NoError(t, err)
+		req.Header.Add(userIDHeader, e.AuthorID)
+		resp, err = httpClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+	t.Run("user isolation", func(t *testing.T) {
+		test := httptest.NewServer(h.Handlers(ctx))
+		defer test.Close()
+		const otherUserID = "7f1c4c8e-6a36-4d35-9c1a-0d6e0f3b9d11"
+		data, err := json.Marshal(e)
+		require.NoError(t, err)
+
+		// Без пользователя запрос не выполняется
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, test.URL+"/events", bytes.NewReader(data))
+		require.NoError(t, err)
+		resp, err := httpClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		// Создадим событие от имени первого пользователя
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, test.URL+"/events", bytes.NewReader(data))
+		require.NoError(t, err)
+		req.Header.Add(userIDHeader, e.AuthorID)
+		resp, err = httpClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		// То же время у другого пользователя свободно
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, test.URL+"/events", bytes.NewReader(data))
+		require.NoError(t, err)
+		req.Header.Add(userIDHeader, otherUserID)
+		resp, err = httpClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		// Каждый пользователь видит только свои события
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, test.URL+"/events/day/"+d.Format(time.DateOnly), nil)
+		require.NoError(t, err)
+		req.Header.Add(userIDHeader, otherUserID)
+		resp, err = httpClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		out, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		te := &result{}
+		err = json.Unmarshal(out, te)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(te.Events))
+		require.Equal(t, otherUserID, te.Events[0].AuthorID)
+		// Чужое событие удалить нельзя
+		req, err = http.NewRequestWithContext(ctx, http.MethodDelete, test.URL+"/events/"+te.Events[0].ID, nil)
+		require.NoError(t, err)
+		req.Header.Add(userIDHeader, e.AuthorID)
+		resp, err = httpClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+	t.Run("free busy", func(t *testing.T) {
+		test := httptest.NewServer(h.Handlers(ctx))
+		defer test.Close()
+		day := time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)
+		for _, v := range []struct{ start, duration int }{{10 * 60, 60}, {11*60 + 30, 30}} {
+			fe := e
+			fe.StartAt = day.Add(time.Duration(v.start) * time.Minute)
+			fe.Duration = float64(v.duration * 60)
+			data, err := json.Marshal(fe)
+			require.NoError(t, err)
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, test.URL+"/events", bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Add(userIDHeader, e.AuthorID)
+			resp, err := httpClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+
+		const otherUserID = "7f1c4c8e-6a36-4d35-9c1a-0d6e0f3b9d12"
+		do := func(method, url, userID string, body any) (*http.Response, *result) {
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Add(userIDHeader, userID)
+			resp, err := httpClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			res := &result{}
+			if resp.StatusCode == http.StatusOK {
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+			}
+
+			return resp, res
+		}
+
+		// Без общего календаря занятость автора другому пользователю недоступна
+		url := test.URL + "/events/freebusy?from=2023-08-01T09:00:00Z&to=2023-08-01T13:00:00Z&duration=3600&user="
+		resp, _ := do(http.MethodGet, url+e.AuthorID, otherUserID, nil)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp, created := do(http.MethodPost, test.URL+"/calendars", e.AuthorID, calendar{Name: "Команда"})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		calendarURL := test.URL + "/calendars/" + created.Calendar.ID
+		resp, _ = do(http.MethodPost, calendarURL+"/shares", e.AuthorID, share{UserID: otherUserID, Permission: "read"})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// Пользователь с общим календарем запрашивает занятость автора, свободны слоты от часа
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+e.AuthorID, nil)
+		require.NoError(t, err)
+		req.Header.Add(userIDHeader, otherUserID)
+		resp, err = httpClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		out, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		te := &result{}
+		err = json.Unmarshal(out, te)
+		require.NoError(t, err)
+		require.NotNil(t, te.FreeBusy)
+		at := func(hour, minute int) time.Time {
+			return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+		}
+		require.Equal(t, []interval{{at(10, 0), at(11, 0)}, {at(11, 30), at(12, 0)}}, te.FreeBusy.Busy)
+		require.Equal(t, []interval{{at(9, 0), at(10, 0)}, {at(12, 0), at(13, 0)}}, te.FreeBusy.Free)
+		require.Equal(t, &interval{at(9, 0), at(10, 0)}, te.FreeBusy.Next)
+		// Свой календарь другого пользователя свободен
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		require.NoError(t, err)
+		req.Header.Add(userIDHeader, otherUserID)
+		resp, err = httpClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		out, err = io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		te = &result{}
+		err = json.Unmarshal(out, te)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(te.FreeBusy.Busy))
+		require.Equal(t, []interval{{at(9, 0), at(13, 0)}}, te.FreeBusy.Free)
+		// После закрытия доступа занятость снова недоступна
+		resp, _ = do(http.MethodDelete, calendarURL+"/shares/"+otherUserID, e.AuthorID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = do(http.MethodGet, url+e.AuthorID, otherUserID, nil)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp, _ = do(http.MethodDelete, calendarURL, e.AuthorID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		// Конец интервала раньше начала
+		req, err = http.NewRequestWithContext(
+			ctx,
+			http.MethodGet,
+			test.URL+"/events/freebusy?from=2023-08-01T13:00:00Z&to=2023-08-01T09:00:00Z",
+			nil,
+		)
+		require.NoError(t, err)
+		req.Header.Add(userIDHeader, e.AuthorID)
+		resp, err = httpClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("list with pagination", func(t *testing.T) {
+		test := httptest.NewServer(h.Handlers(ctx))
+		defer test.Close()
+		const userID = "7f1c4c8e-6a36-4d35-9c1a-0d6e0f3b9d13"
+		day := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+		for i := 0; i < 3; i++ {
+			le := e
+			le.StartAt = day.AddDate(0, 0, i)
+			data, err := json.Marshal(le)
+			require.NoError(t, err)
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, test.URL+"/events", bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Add(userIDHeader, userID)
+			resp, err := httpClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+
+		// Получим события постранично по два
+		url := test.URL + "/events?from=2023-09-01T00:00:00Z&to=2023-09-08T00:00:00Z&limit=2"
+		starts := make([]time.Time, 0, 3)
+		cursor := ""
+		for pages := 0; pages < 2; pages++ {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"&cursor="+cursor, nil)
+			require.NoError(t, err)
+			req.Header.Add(userIDHeader, userID)
+			resp, err := httpClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			out, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			te := &result{}
+			err = json.Unmarshal(out, te)
+			require.NoError(t, err)
+			for _, v := range te.Events {
+				starts = append(starts, v.StartAt)
+			}
+			cursor = te.NextCursor
+		}
+		require.Empty(t, cursor)
+		require.Equal(t, []time.Time{day, day.AddDate(0, 0, 1), day.AddDate(0, 0, 2)}, starts)
+		// Невалидный курсор
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"&cursor=invalid", nil)
+		require.NoError(t, err)
+		req.Header.Add(userIDHeader, userID)
+		resp, err := httpClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		// Интервал длиннее app.MaxListRange
+		url = test.URL + "/events?from=1970-01-01T00:00:00Z&to=9999-12-31T00:00:00Z"
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		require.NoError(t, err)
+		req.Header.Add(userIDHeader, userID)
+		resp, err = httpClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("search", func(t *testing.T) {
+		test := httptest.NewServer(h.Handlers(ctx))
+		defer test.Close()
+		const userID = "7f1c4c8e-6a36-4d35-9c1a-0d6e0f3b9d14"
+		day := time.Date(2023, 10, 1, 10, 0, 0, 0, time.UTC)
+		for i, v := range []struct{ title, description string }{
+			{"Planning", "Budget review"},
+			{"Budget review", "Quarterly"},
+			{"Lunch", "Budget"},
+		} {
+			se := e
+			se.Title, se.Description = v.title, v.description
+			se.StartAt = day.AddDate(0, 0, i)
+			data, err := json.Marshal(se)
+			require.NoError(t, err)
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, test.URL+"/events", bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Add(userIDHeader, userID)
+			resp, err := httpClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+
+		search := func(t *testing.T, query string, status int) []*found {
+			t.Helper()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, test.URL+"/events/search?"+query, nil)
+			require.NoError(t, err)
+			req.Header.Add(userIDHeader, userID)
+			resp, err := httpClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, status, resp.StatusCode)
+			if status != http.StatusOK {
+				return nil
+			}
+			out, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			te := &result{}
+			require.NoError(t, json.Unmarshal(out, te))
+
+			return te.Results
+		}
+
+		results := search(t, "q=budget+REVIEW", http.StatusOK)
+		require.Equal(t, 2, len(results))
+		require.Equal(t, "Budget review", results[0].Event.Title)
+		require.Equal(t, "Planning", results[1].Event.Title)
+		require.Greater(t, results[0].Rank, results[1].Rank)
+
+		results = search(t, "q=budget&from=2023-10-02T00:00:00Z&limit=1", http.StatusOK)
+		require.Equal(t, 1, len(results))
+		require.Equal(t, "Budget review", results[0].Event.Title)
+
+		search(t, "q=+", http.StatusBadRequest)
+		search(t, "q=budget&from=2023-10-02T00:00:00Z&to=2023-10-01T00:00:00Z", http.StatusBadRequest)
+	})
+	t.Run("versions", func(t *testing.T) {
+		test := httptest.NewServer(h.Handlers(ctx))
+		defer test.Close()
+		ve := e
+		ve.StartAt = time.Date(2023, 10, 1, 10, 0, 0, 0, time.UTC)
+		data, err := json.Marshal(ve)
+		require.NoError(t, err)
+		do := func(method, url, ifMatch string) *http.Response {
+			req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Add(userIDHeader, e.AuthorID)
+			if ifMatch != "" {
+				req.Header.Add(ifMatchHeader, ifMatch)
+			}
+			resp, err := httpClient.Do(req)
+			require.NoError(t, err)
+			t.Cleanup(func() { resp.Body.Close() })
+
+			return resp
+		}
+
+		// Создадим запись и получим ее ETag
+		resp := do(http.MethodPost, test.URL+"/events", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		created := &result{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(created))
+		url := test.URL + "/events/" + created.Event.ID
+		resp = do(http.MethodGet, url, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		first := resp.Header.Get(etagHeader)
+		require.Equal(t, `"1"`, first)
+		// Обновление с актуальной версией меняет ETag
+		resp = do(http.MethodPut, url, first)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		second := resp.Header.Get(etagHeader)
+		require.Equal(t, `"2"`, second)
+		// Устаревшая версия не позволяет изменить и удалить запись
+		resp = do(http.MethodPut, url, first)
+		require.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+		resp = do(http.MethodDelete, url, first)
+		require.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+		// Невалидный заголовок
+		resp = do(http.MethodDelete, url, "2")
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = do(http.MethodDelete, url, second)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("trash", func(t *testing.T) {
+		test := httptest.NewServer(h.Handlers(ctx))
+		defer test.Close()
+		te := e
+		te.StartAt = time.Date(2023, 11, 1, 10, 0, 0, 0, time.UTC)
+		data, err := json.Marshal(te)
+		require.NoError(t, err)
+		do := func(method, url string) (*http.Response, *result) {
+			req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Add(userIDHeader, e.AuthorID)
+			resp, err := httpClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			res := &result{}
+			if resp.StatusCode == http.StatusOK {
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+			}
+
+			return resp, res
+		}
+
+		// Создадим запись и переместим ее в корзину
+		resp, created := do(http.MethodPost, test.URL+"/events")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		url := test.URL + "/events/" + created.Event.ID
+		resp, _ = do(http.MethodDelete, url)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = do(http.MethodGet, url)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		// Запись видна в корзине, предыдущие подтесты тоже удаляли записи
+		trashed := func() *event {
+			resp, trash := do(http.MethodGet, test.URL+"/events/trash")
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			for _, v := range trash.Events {
+				if v.ID == created.Event.ID {
+					return v
+				}
+			}
+
+			return nil
+		}
+		deleted := trashed()
+		require.NotNil(t, deleted)
+		require.NotNil(t, deleted.DeletedAt)
+		// Восстановим запись
+		resp, restored := do(http.MethodPost, url+"/restore")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, created.Event.ID, restored.Event.ID)
+		require.Nil(t, restored.Event.DeletedAt)
+		require.Equal(t, `"3"`, resp.Header.Get(etagHeader))
+		resp, _ = do(http.MethodGet, url)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		// Повторно восстановить нельзя
+		resp, _ = do(http.MethodPost, url+"/restore")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		require.Nil(t, trashed())
+	})
+
+	t.Run("history", func(t *testing.T) {
+		test := httptest.NewServer(h.Handlers(ctx))
+		defer test.Close()
+		he := e
+		he.StartAt = time.Date(2023, 12, 1, 10, 0, 0, 0, time.UTC)
+		data, err := json.Marshal(he)
+		require.NoError(t, err)
+		do := func(method, url, userID string) (*http.Response, *result) {
+			req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Add(userIDHeader, userID)
+			resp, err := httpClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			res := &result{}
+			if resp.StatusCode == http.StatusOK {
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+			}
+
+			return resp, res
+		}
+
+		// Создадим, изменим и удалим запись
+		resp, created := do(http.MethodPost, test.URL+"/events", e.AuthorID)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		url := test.URL + "/events/" + created.Event.ID
+		he.Title = "Moved event"
+		he.StartAt = he.StartAt.Add(time.Hour)
+		data, err = json.Marshal(he)
+		require.NoError(t, err)
+		resp, _ = do(http.MethodPut, url, e.AuthorID)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = do(http.MethodDelete, url, e.AuthorID)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		// Журнал доступен после удаления
+		resp, history := do(http.MethodGet, url+"/history", e.AuthorID)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 3, len(history.History))
+		require.Equal(t, "create", history.History[0].Action)
+		require.Nil(t, history.History[0].Before)
+		require.Equal(t, "update", history.History[1].Action)
+		require.Equal(t, e.AuthorID, history.History[1].ActorID)
+		require.Equal(t, e.Title, history.History[1].Before.Title)
+		require.Equal(t, he.Title, history.History[1].After.Title)
+		require.True(t, he.StartAt.Equal(history.History[1].After.StartAt))
+		require.Equal(t, "delete", history.History[2].Action)
+		require.Nil(t, history.History[2].After)
+		// Журнал чужого события недоступен
+		resp, _ = do(http.MethodGet, url+"/history", "6f1c8a52-33d1-4a8e-9f0b-2a4d5c6e7f80")
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp, _ = do(http.MethodGet, test.URL+"/events/6f1c8a52-33d1-4a8e-9f0b-2a4d5c6e7f80/history", e.AuthorID)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("attendees", func(t *testing.T) {
+		test := httptest.NewServer(h.Handlers(ctx))
+		defer test.Close()
+		guestID := "3b8d0e6a-54c2-4f1e-8a7d-9c0b1e2f3a4d"
+		ae := e
+		ae.StartAt = time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+		do := func(method, url, userID string, body any) (*http.Response, *result) {
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Add(userIDHeader, userID)
+			resp, err := httpClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			res := &result{}
+			if resp.StatusCode == http.StatusOK {
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+			}
+
+			return resp, res
+		}
+
+		resp, created := do(http.MethodPost, test.URL+"/events", e.AuthorID, ae)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		url := test.URL + "/events/" + created.Event.ID
+		// До приглашения событие гостю недоступно
+		resp, _ = do(http.MethodGet, url, guestID, nil)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		// Пригласим гостя
+		resp, invited := do(http.MethodPost, url+"/attendees", e.AuthorID, invitation{
+			Attendees: []attendee{{UserID: guestID}},
+		})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, []attendee{{UserID: guestID, Role: "required", Status: "needs-action"}}, invited.Event.Attendees)
+		// Приглашать может только автор, сам автор участником быть не может
+		resp, _ = do(http.MethodPost, url+"/attendees", guestID, invitation{
+			Attendees: []attendee{{UserID: "6f1c8a52-33d1-4a8e-9f0b-2a4d5c6e7f80"}},
+		})
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp, _ = do(http.MethodPost, url+"/attendees", e.AuthorID, invitation{
+			Attendees: []attendee{{UserID: e.AuthorID}},
+		})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		// Гость видит событие и отвечает на приглашение
+		resp, _ = do(http.MethodGet, url, guestID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = do(http.MethodGet, url+"/history", guestID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		// Участнику доступна занятость автора в интервале общего события
+		freeBusy := test.URL + "/events/freebusy?user=" + e.AuthorID
+		resp, _ = do(http.MethodGet, freeBusy+"&from=2024-01-10T00:00:00Z&to=2024-01-11T00:00:00Z", guestID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = do(http.MethodGet, freeBusy+"&from=2024-02-10T00:00:00Z&to=2024-02-11T00:00:00Z", guestID, nil)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp, _ = do(http.MethodPost, url+"/rsvp", guestID, rsvp{Status: "maybe"})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp, answered := do(http.MethodPost, url+"/rsvp", guestID, rsvp{Status: "accepted"})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "accepted", answered.Event.Attendees[0].Status)
+		// Автор не приглашен на свое событие
+		resp, _ = do(http.MethodPost, url+"/rsvp", e.AuthorID, rsvp{Status: "accepted"})
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("reminders", func(t *testing.T) {
+		test := httptest.NewServer(h.Handlers(ctx))
+		defer test.Close()
+		post := func(body event) (*http.Response, *result) {
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, test.URL+"/events", bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Add(userIDHeader, e.AuthorID)
+			resp, err := httpClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			res := &result{}
+			if resp.StatusCode == http.StatusOK {
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+			}
+
+			return resp, res
+		}
+
+		re := e
+		re.StartAt = time.Date(2024, 2, 10, 10, 0, 0, 0, time.UTC)
+		re.Reminders = []float64{900, 86400, 900}
+		// notificationAt добавляет напоминание за час до начала
+		re.NotificationAt = re.StartAt.Add(-time.Hour)
+		resp, created := post(re)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, []float64{86400, 3600, 900}, created.Event.Reminders)
+		require.True(t, created.Event.NotificationAt.Equal(re.StartAt.Add(-24*time.Hour)))
+		// Напоминание после начала события не поддерживается
+		re.Reminders = []float64{-60}
+		re.NotificationAt = time.Time{}
+		resp, _ = post(re)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("time zones", func(t *testing.T) {
+		test := httptest.NewServer(h.Handlers(ctx))
+		defer test.Close()
+		do := func(method, url string, body any) (*http.Response, *result) {
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Add(userIDHeader, e.AuthorID)
+			resp, err := httpClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			res := &result{}
+			if resp.StatusCode == http.StatusOK {
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+			}
+
+			return resp, res
+		}
+
+		moscow, err := time.LoadLocation("Europe/Moscow")
+		require.NoError(t, err)
+		te := e
+		te.StartAt = time.Date(2024, 3, 10, 0, 30, 0, 0, moscow)
+		te.TimeZone = "Europe/Moscow"
+		resp, created := do(http.MethodPost, test.URL+"/events", te)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "Europe/Moscow", created.Event.TimeZone)
+		// Границы суток считаются в поясе tz, по умолчанию в UTC
+		resp, res := do(http.MethodGet, test.URL+"/events/day/2024-03-10?tz=Europe/Moscow", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 1, len(res.Events))
+		require.Equal(t, created.Event.ID, res.Events[0].ID)
+		resp, res = do(http.MethodGet, test.URL+"/events/day/2024-03-10", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 0, len(res.Events))
+		resp, _ = do(http.MethodGet, test.URL+"/events/day/2024-03-10?tz=Mars/Olympus", nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		te.TimeZone = "Mars/Olympus"
+		resp, _ = do(http.MethodPost, test.URL+"/events", te)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		test := httptest.NewServer(h.Handlers(ctx))
+		defer test.Close()
+		do := func(method, url string, body any) (*http.Response, *result) {
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Add(userIDHeader, e.AuthorID)
+			resp, err := httpClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			res := &result{}
+			if resp.StatusCode == http.StatusOK {
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+			}
+
+			return resp, res
+		}
+
+		te := e
+		te.StartAt = time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+		te.Tags = []string{"Backend", " release ", "backend"}
+		te.Color = "#FF8800"
+		resp, created := do(http.MethodPost, test.URL+"/events", te)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, []string{"backend", "release"}, created.Event.Tags)
+		require.Equal(t, "#ff8800", created.Event.Color)
+
+		other := e
+		other.StartAt = te.StartAt.Add(2 * time.Hour)
+		other.Tags = []string{"frontend"}
+		resp, _ = do(http.MethodPost, test.URL+"/events", other)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, res := do(http.MethodGet, test.URL+"/events/week/2024-07-01?tag=RELEASE", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 1, len(res.Events))
+		require.Equal(t, created.Event.ID, res.Events[0].ID)
+		resp, res = do(http.MethodGet, test.URL+"/events/day/2024-07-01", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 2, len(res.Events))
+
+		te.Tags = nil
+		te.Color = "orange"
+		te.StartAt = te.StartAt.AddDate(0, 0, 1)
+		resp, _ = do(http.MethodPost, test.URL+"/events", te)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("location", func(t *testing.T) {
+		test := httptest.NewServer(h.Handlers(ctx))
+		defer test.Close()
+		do := func(method, url string, body any) (*http.Response, *result) {
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Add(userIDHeader, e.AuthorID)
+			resp, err := httpClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			res := &result{}
+			if resp.StatusCode == http.StatusOK {
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+			}
+
+			return resp, res
+		}
+
+		le := e
+		le.StartAt = time.Date(2024, 8, 5, 10, 0, 0, 0, time.UTC)
+		le.Location = &location{
+			Text:        " Переговорная 3 ",
+			Coordinates: &coordinates{Latitude: 55.7558, Longitude: 37.6173},
+		}
+		le.MeetingURL = "https://meet.example.com/abc"
+		resp, created := do(http.MethodPost, test.URL+"/events", le)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "Переговорная 3", created.Event.Location.Text)
+		require.Equal(t, le.Location.Coordinates, created.Event.Location.Coordinates)
+		require.Equal(t, le.MeetingURL, created.Event.MeetingURL)
+
+		resp, res := do(http.MethodGet, test.URL+"/events/day/2024-08-05", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 1, len(res.Events))
+		require.Equal(t, created.Event.Location, res.Events[0].Location)
+
+		le.StartAt = le.StartAt.AddDate(0, 0, 1)
+		le.MeetingURL = "ftp://meet.example.com/abc"
+		resp, _ = do(http.MethodPost, test.URL+"/events", le)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		le.MeetingURL = ""
+		le.Location = &location{Coordinates: &coordinates{Latitude: 91}}
+		resp, _ = do(http.MethodPost, test.URL+"/events", le)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("all day", func(t *testing.T) {
+		test := httptest.NewServer(h.Handlers(ctx))
+		defer test.Close()
+		do := func(method, url string, body any) (*http.Response, *result) {
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Add(userIDHeader, e.AuthorID)
+			resp, err := httpClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			res := &result{}
+			if resp.StatusCode == http.StatusOK {
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+			}
+
+			return resp, res
+		}
+
+		moscow, err := time.LoadLocation("Europe/Moscow")
+		require.NoError(t, err)
+
+		ae := e
+		ae.StartAt = time.Date(2024, 10, 14, 15, 0, 0, 0, time.UTC)
+		ae.TimeZone = "Europe/Moscow"
+		ae.Duration = (72 * time.Hour).Seconds()
+		ae.AllDay = true
+		resp, created := do(http.MethodPost, test.URL+"/events", ae)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.True(t, created.Event.AllDay)
+		require.True(t, time.Date(2024, 10, 14, 0, 0, 0, 0, moscow).Equal(created.Event.StartAt))
+		require.Equal(t, ae.Duration, created.Event.Duration)
+
+		// Многодневное событие возвращается за каждые сутки, которые оно занимает.
+		for date, count := range map[string]int{"2024-10-13": 0, "2024-10-16": 1, "2024-10-17": 0} {
+			resp, res := do(http.MethodGet, test.URL+"/events/day/"+date+"?tz=Europe/Moscow", nil)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, count, len(res.Events), date)
+		}
+	})
+
+	t.Run("aligned periods", func(t *testing.T) {
+		test := httptest.NewServer(h.Handlers(ctx))
+		defer test.Close()
+		do := func(method, url string, body any) (*http.Response, *result) {
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Add(userIDHeader, e.AuthorID)
+			resp, err := httpClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			res := &result{}
+			if resp.StatusCode == http.StatusOK {
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+			}
+
+			return resp, res
+		}
+
+		// Понедельник и среда одной недели.
+		for _, day := range []int{1, 3} {
+			pe := e
+			pe.StartAt = time.Date(2024, 4, day, 10, 0, 0, 0, time.UTC)
+			resp, _ := do(http.MethodPost, test.URL+"/events", pe)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+
+		tests := []struct {
+			path     string
+			excepted int
+		}{
+			{"/events/week/2024-04-03", 1},
+			{"/events/week/2024-04-03?aligned=true", 2},
+			{"/events/month/2024-04-15", 0},
+			{"/events/month/2024-04-15?aligned=true", 2},
+		}
+		for _, tc := range tests {
+			resp, res := do(http.MethodGet, test.URL+tc.path, nil)
+			require.Equal(t, http.StatusOK, resp.StatusCode, tc.path)
+			require.Equal(t, tc.excepted, len(res.Events), tc.path)
+		}
+
+		resp, _ := do(http.MethodGet, test.URL+"/events/week/2024-04-03?aligned=maybe", nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("calendars", func(t *testing.T) {
+		test := httptest.NewServer(h.Handlers(ctx))
+		defer test.Close()
+		guestID := "7c2e4b1a-0d3f-4e5a-9b6c-8d7e6f5a4b3c"
+		do := func(method, url, userID string, body any) (*http.Response, *result) {
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Add(userIDHeader, userID)
+			resp, err := httpClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			res := &result{}
+			if resp.StatusCode == http.StatusOK {
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+			}
+
+			return resp, res
+		}
+
+		resp, _ := do(http.MethodPost, test.URL+"/calendars", e.AuthorID, calendar{Name: " "})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp, created := do(http.MethodPost, test.URL+"/calendars", e.AuthorID, calendar{Name: "Работа"})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		url := test.URL + "/calendars/" + created.Calendar.ID
+		resp, renamed := do(http.MethodPut, url, e.AuthorID, calendar{Name: "Офис"})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "Офис", renamed.Calendar.Name)
+
+		// Событие в календаре не конфликтует с событием в личном календаре
+		ce := e
+		ce.StartAt = time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC)
+		resp, _ = do(http.MethodPost, test.URL+"/events", e.AuthorID, ce)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		ce.CalendarID = created.Calendar.ID
+		resp, inCalendar := do(http.MethodPost, test.URL+"/events", e.AuthorID, ce)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, created.Calendar.ID, inCalendar.Event.CalendarID)
+		resp, res := do(http.MethodGet, test.URL+"/events/day/2024-05-06?calendar="+created.Calendar.ID, e.AuthorID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 1, len(res.Events))
+
+		// Без доступа календарь и его события гостю недоступны
+		resp, _ = do(http.MethodGet, url, guestID, nil)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp, _ = do(http.MethodGet, test.URL+"/events/"+inCalendar.Event.ID, guestID, nil)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp, _ = do(http.MethodGet, test.URL+"/events/"+inCalendar.Event.ID+"/history", guestID, nil)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		// Доступ на чтение
+		resp, _ = do(http.MethodPost, url+"/shares", e.AuthorID, share{UserID: guestID, Permission: "admin"})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp, _ = do(http.MethodPost, url+"/shares", e.AuthorID, share{UserID: e.AuthorID, Permission: "read"})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp, _ = do(http.MethodPost, url+"/shares", guestID, share{UserID: e.AuthorID, Permission: "read"})
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp, shared := do(http.MethodPost, url+"/shares", e.AuthorID, share{UserID: guestID, Permission: "read"})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, []share{{UserID: guestID, Permission: "read"}}, shared.Calendar.Shares)
+		resp, res = do(http.MethodGet, test.URL+"/calendars", guestID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 1, len(res.Calendars))
+		resp, _ = do(http.MethodGet, test.URL+"/events/"+inCalendar.Event.ID, guestID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, history := do(http.MethodGet, test.URL+"/events/"+inCalendar.Event.ID+"/history", guestID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 1, len(history.History))
+		ge := ce
+		ge.AuthorID = guestID
+		resp, _ = do(http.MethodPost, test.URL+"/events", guestID, ge)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		ge.Title = "Изменено гостем"
+		resp, _ = do(http.MethodPut, test.URL+"/events/"+inCalendar.Event.ID, guestID, ge)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		// Доступ на запись позволяет изменять события, автор сохраняется
+		resp, _ = do(http.MethodPost, url+"/shares", e.AuthorID, share{UserID: guestID, Permission: "write"})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, updated := do(http.MethodPut, test.URL+"/events/"+inCalendar.Event.ID, guestID, ge)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "Изменено гостем", updated.Event.Title)
+		require.Equal(t, e.AuthorID, updated.Event.AuthorID)
+
+		// Календарь с событиями удалить нельзя
+		resp, _ = do(http.MethodDelete, url, e.AuthorID, nil)
+		require.Equal(t, http.StatusConflict, resp.StatusCode)
+		resp, _ = do(http.MethodDelete, test.URL+"/events/"+inCalendar.Event.ID, guestID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = do(http.MethodDelete, url+"/shares/"+guestID, e.AuthorID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = do(http.MethodDelete, url, guestID, nil)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp, _ = do(http.MethodDelete, url, e.AuthorID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = do(http.MethodGet, url, e.AuthorID, nil)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("resources", func(t *testing.T) {
+		test := httptest.NewServer(h.Handlers(ctx))
+		defer test.Close()
+		guestID := "7c2e4b1a-0d3f-4e5a-9b6c-8d7e6f5a4b3d"
+		do := func(method, url, userID string, body any) (*http.Response, *result) {
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Add(userIDHeader, userID)
+			resp, err := httpClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			res := &result{}
+			if resp.StatusCode == http.StatusOK {
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+			}
+
+			return resp, res
+		}
+
+		resp, _ := do(http.MethodPost, test.URL+"/resources", e.AuthorID, resource{Name: "Зал", Kind: "hall"})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp, created := do(http.MethodPost, test.URL+"/resources", e.AuthorID, resource{Name: "Переговорная", Kind: "room"})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		url := test.URL + "/resources/" + created.Resource.ID
+		resp, _ = do(http.MethodPut, url, guestID, resource{Name: "Зал", Kind: "room", Capacity: 20})
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp, updated := do(http.MethodPut, url, e.AuthorID, resource{Name: "Переговорная", Kind: "room", Capacity: 8})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 8, updated.Resource.Capacity)
+		resp, res := do(http.MethodGet, test.URL+"/resources", guestID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, []*resource{updated.Resource}, res.Resources)
+
+		// Ресурс занят независимо от того, чье время занимает событие
+		re := e
+		re.StartAt = time.Date(2024, 9, 2, 10, 0, 0, 0, time.UTC)
+		re.Resources = []string{created.Resource.ID}
+		resp, booked := do(http.MethodPost, test.URL+"/events", e.AuthorID, re)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, re.Resources, booked.Event.Resources)
+		ge := re
+		ge.AuthorID = guestID
+		ge.StartAt = re.StartAt.Add(30 * time.Minute)
+		resp, _ = do(http.MethodPost, test.URL+"/events", guestID, ge)
+		require.Equal(t, http.StatusConflict, resp.StatusCode)
+		ae := ge
+		ae.AuthorID, ae.Resources = e.AuthorID, nil
+		resp, _ = do(http.MethodPost, test.URL+"/events", e.AuthorID, ae)
+		require.Equal(t, http.StatusConflict, resp.StatusCode)
+		ge.Resources = []string{"unknown"}
+		resp, _ = do(http.MethodPost, test.URL+"/events", guestID, ge)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		// Без WithLocationRooms место проведения не бронирует одноименную переговорную
+		le := e
+		le.StartAt = time.Date(2024, 9, 3, 10, 0, 0, 0, time.UTC)
+		le.Location = &location{Text: "Переговорная"}
+		resp, placed := do(http.MethodPost, test.URL+"/events", e.AuthorID, le)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 0, len(placed.Event.Resources))
+		resp, _ = do(http.MethodDelete, test.URL+"/events/"+placed.Event.ID, e.AuthorID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, res = do(http.MethodGet, url+"/availability/2024-09-02", guestID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 1, len(res.FreeBusy.Busy))
+		require.True(t, re.StartAt.Equal(res.FreeBusy.Busy[0].Start))
+		require.Equal(t, 2, len(res.FreeBusy.Free))
+		// Сутки в Токио заканчиваются в 15:00 UTC
+		resp, res = do(http.MethodGet, url+"/availability/2024-09-02?tz=Asia/Tokyo", guestID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 1, len(res.FreeBusy.Busy))
+		require.True(t, time.Date(2024, 9, 2, 15, 0, 0, 0, time.UTC).Equal(res.FreeBusy.Free[1].End))
+
+		resp, _ = do(http.MethodDelete, url, e.AuthorID, nil)
+		require.Equal(t, http.StatusConflict, resp.StatusCode)
+		resp, _ = do(http.MethodDelete, test.URL+"/events/"+booked.Event.ID, e.AuthorID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = do(http.MethodDelete, url, e.AuthorID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = do(http.MethodGet, url, e.AuthorID, nil)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
 	})
 }
+
+// failingAudit хранилище, в котором не удается записать журнал изменений.
+type failingAudit struct {
+	storage.Storage
+}
+
+func (failingAudit) AddAuditRecord(context.Context, storage.AuditRecord) error {
+	return errors.New("журнал недоступен")
+}
+
+func TestHandler_AuditFailure(t *testing.T) {
+	l, err := logger.New("debug", "/dev/stdout")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	s := failingAudit{Storage: memorystorage.New()}
+	h := NewHandlers(app.New(s, app.WithLogger(l)), l)
+	test := httptest.NewServer(h.Handlers(ctx))
+	defer test.Close()
+
+	e := event{
+		Title:    "Test event",
+		StartAt:  time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC),
+		Duration: 3600,
+		AuthorID: "512b922c-822a-4a05-b52b-85b85ab7a00c",
+	}
+	data, err := json.Marshal(e)
+	require.NoError(t, err)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, test.URL+"/events", bytes.NewReader(data))
+	require.NoError(t, err)
+	req.Header.Add(userIDHeader, e.AuthorID)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// Событие сохранено, поэтому ошибка журнала не возвращается клиенту.
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	events, err := s.EventsDay(ctx, e.AuthorID, e.StartAt)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(events))
+}
+
+func TestHandler_LocationRooms(t *testing.T) {
+	l, err := logger.New("debug", "/dev/stdout")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	s := memorystorage.New()
+	h := NewHandlers(app.New(s, app.WithLocationRooms(true), app.WithLogger(l)), l)
+	test := httptest.NewServer(h.Handlers(ctx))
+	defer test.Close()
+
+	authorID := "512b922c-822a-4a05-b52b-85b85ab7a00c"
+	guestID := "7c2e4b1a-0d3f-4e5a-9b6c-8d7e6f5a4b3d"
+	do := func(method, url, userID string, body any) (*http.Response, *result) {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+		require.NoError(t, err)
+		req.Header.Add(userIDHeader, userID)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		res := &result{}
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+		}
+
+		return resp, res
+	}
+
+	resp, room := do(http.MethodPost, test.URL+"/resources", authorID, resource{Name: "Переговорная", Kind: "room"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Место проведения, совпадающее с названием переговорной, бронирует ее
+	e := event{
+		Title:    "Планерка",
+		StartAt:  time.Date(2024, 9, 3, 10, 0, 0, 0, time.UTC),
+		Duration: 3600,
+		Location: &location{Text: "переговорная"},
+	}
+	resp, placed := do(http.MethodPost, test.URL+"/events", authorID, e)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, []string{room.Resource.ID}, placed.Event.Resources)
+
+	ge := e
+	ge.StartAt = e.StartAt.Add(30 * time.Minute)
+	ge.Location = &location{Text: "Переговорная"}
+	resp, _ = do(http.MethodPost, test.URL+"/events", guestID, ge)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	ge.Location = &location{Text: "Переговорная 2"}
+	resp, _ = do(http.MethodPost, test.URL+"/events", guestID, ge)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// При смене места проведения бронь прежней переговорной снимается, даже если
+	// клиент вернул ее в списке ресурсов, и переговорную может занять другое событие
+	moved := e
+	moved.Location = &location{Text: "Кухня"}
+	moved.Resources = placed.Event.Resources
+	resp, updated := do(http.MethodPut, test.URL+"/events/"+placed.Event.ID, authorID, moved)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 0, len(updated.Event.Resources))
+	resp, _ = do(http.MethodPost, test.URL+"/events", "3f8d2c6e-1b4a-4c9d-8e7f-6a5b4c3d2e1f", e)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,143 @@
+package internalhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+const (
+	resourcesPath    = "/resources"
+	availabilityPath = "availability"
+)
+
+type resource struct {
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Capacity int    `json:"capacity,omitempty"`
+	OwnerID  string `json:"ownerId,omitempty"`
+}
+
+// resources обрабатывает GET и POST /resources, GET, PUT и DELETE /resources/{id}
+// и GET /resources/{id}/availability/{date}?tz=.
+func (h *Handler) resources(ctx context.Context, r *http.Request) result {
+	userID := r.Header.Get(userIDHeader)
+	if r.URL.Path == resourcesPath {
+		switch r.Method {
+		case http.MethodGet:
+			resources, err := h.app.ListResources(ctx, userID)
+
+			return result{Error: err, Resources: convertResources(resources)}
+		case http.MethodPost:
+			var body resource
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				return result{Error: err}
+			}
+
+			res, err := h.app.CreateResource(ctx, userID, body.Name, storage.ResourceKind(body.Kind), body.Capacity)
+			if err != nil {
+				return result{Error: err}
+			}
+
+			return result{Success: "Ресурс создан", Resource: convertResource(res)}
+		default:
+			return result{Error: ErrNotSupportedMethod}
+		}
+	}
+
+	path, ok := strings.CutPrefix(r.URL.Path, resourcesPath+"/")
+	if !ok {
+		return result{Error: ErrPageNotFound}
+	}
+
+	parts := strings.Split(path, "/")
+	switch {
+	case len(parts) == 1:
+		return h.resource(ctx, r, parts[0])
+	case len(parts) == 3 && parts[1] == availabilityPath && r.Method == http.MethodGet:
+		return h.availability(ctx, r, parts[0], parts[2])
+	case len(parts) == 3 && parts[1] == availabilityPath:
+		return result{Error: ErrNotSupportedMethod}
+	default:
+		return result{Error: ErrPageNotFound}
+	}
+}
+
+// resource обрабатывает GET, PUT и DELETE /resources/{id}.
+func (h *Handler) resource(ctx context.Context, r *http.Request, id string) result {
+	userID := r.Header.Get(userIDHeader)
+	switch r.Method {
+	case http.MethodGet:
+		res, err := h.app.GetResource(ctx, userID, id)
+		if err != nil {
+			return result{Error: err}
+		}
+
+		return result{Resource: convertResource(res)}
+	case http.MethodPut:
+		var body resource
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return result{Error: err}
+		}
+
+		res, err := h.app.UpdateResource(ctx, userID, id, body.Name, storage.ResourceKind(body.Kind), body.Capacity)
+		if err != nil {
+			return result{Error: err}
+		}
+
+		return result{Success: "Ресурс обновлен", Resource: convertResource(res)}
+	case http.MethodDelete:
+		if err := h.app.DeleteResource(ctx, userID, id); err != nil {
+			return result{Error: err}
+		}
+
+		return result{Success: "Ресурс удален"}
+	default:
+		return result{Error: ErrNotSupportedMethod}
+	}
+}
+
+// availability обрабатывает GET /resources/{id}/availability/{date}, сутки
+// считаются в часовом поясе из параметра tz.
+func (h *Handler) availability(ctx context.Context, r *http.Request, id string, date string) result {
+	loc, err := storage.LoadLocation(r.URL.Query().Get("tz"))
+	if err != nil {
+		return result{Error: err}
+	}
+
+	day, err := time.ParseInLocation(time.DateOnly, date, loc)
+	if err != nil {
+		return result{Error: err}
+	}
+
+	fb, err := h.app.ResourceAvailability(ctx, r.Header.Get(userIDHeader), id, day)
+	if err != nil {
+		return result{Error: err}
+	}
+
+	return result{FreeBusy: &freeBusy{Busy: convertIntervals(fb.Busy), Free: convertIntervals(fb.Free)}}
+}
+
+func convertResources(resources []storage.Resource) []*resource {
+	r := make([]*resource, 0, len(resources))
+	for _, res := range resources {
+		r = append(r, convertResource(res))
+	}
+
+	return r
+}
+
+func convertResource(r storage.Resource) *resource {
+	return &resource{
+		ID:       r.ID,
+		Name:     r.Name,
+		Kind:     string(r.Kind),
+		Capacity: r.Capacity,
+		OwnerID:  r.OwnerID,
+	}
+}
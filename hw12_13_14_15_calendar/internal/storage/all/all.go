@@ -0,0 +1,9 @@
+// Package all регистрирует все встроенные хранилища. Сторонние хранилища
+// подключаются так же - импортом пакета, который вызывает storage.Register.
+package all
+
+import (
+	_ "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage/memory" // inMemory.
+	_ "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage/pgsql"  // pgsql.
+	_ "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage/sqlite" // sqlite.
+)
@@ -0,0 +1,42 @@
+package storage
+
+import "time"
+
+const dayLength = 24 * time.Hour
+
+// AlignAllDay выравнивает событие на весь день по суткам его часового пояса:
+// начало переносится на полночь суток начала, длительность округляется до
+// целого числа суток, но не меньше одних суток. Остальные события не меняются.
+func (e Event) AlignAllDay() Event {
+	if !e.AllDay {
+		return e
+	}
+
+	days := e.Days()
+	e.StartAt = startOfDay(e.StartAt.In(e.Location()))
+	e.EndAt = e.StartAt.AddDate(0, 0, days)
+
+	return e
+}
+
+// Days возвращает длительность события, округленную до целого числа суток,
+// но не меньше одних суток. Из-за перехода на летнее время сутки события на
+// весь день могут длиться 23 или 25 часов.
+func (e Event) Days() int {
+	days := int((e.EndAt.Sub(e.StartAt) + dayLength/2) / dayLength)
+	if days < 1 {
+		return 1
+	}
+
+	return days
+}
+
+// span возвращает наибольшую длительность повторения события. Повторение
+// события на весь день может оказаться на час длиннее исходного события.
+func (e Event) span() time.Duration {
+	if e.AllDay {
+		return e.EndAt.Sub(e.StartAt) + time.Hour
+	}
+
+	return e.EndAt.Sub(e.StartAt)
+}
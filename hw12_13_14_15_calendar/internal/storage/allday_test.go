@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlignAllDay(t *testing.T) {
+	berlin, err := LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	t.Run("regular event", func(t *testing.T) {
+		e := Event{StartAt: time.Date(2024, 3, 29, 9, 30, 0, 0, time.UTC)}
+		e.EndAt = e.StartAt.Add(time.Hour)
+		require.Equal(t, e, e.AlignAllDay())
+	})
+
+	t.Run("rounds to whole days", func(t *testing.T) {
+		start := time.Date(2024, 3, 29, 9, 30, 0, 0, time.UTC)
+		for duration, days := range map[time.Duration]int{
+			0:              1,
+			time.Hour:      1,
+			36 * time.Hour: 2,
+			72 * time.Hour: 3,
+		} {
+			e := Event{StartAt: start, EndAt: start.Add(duration), TimeZone: "Europe/Berlin", AllDay: true}.AlignAllDay()
+			require.Equal(t, time.Date(2024, 3, 29, 0, 0, 0, 0, berlin), e.StartAt)
+			require.Equal(t, time.Date(2024, 3, 29+days, 0, 0, 0, 0, berlin), e.EndAt, duration)
+			require.Equal(t, days, e.Days())
+		}
+	})
+
+	t.Run("daylight saving time", func(t *testing.T) {
+		// 31.03.2024 в Берлине длится 23 часа.
+		start := time.Date(2024, 3, 30, 0, 0, 0, 0, berlin)
+		e := Event{StartAt: start, EndAt: start.Add(72 * time.Hour), TimeZone: "Europe/Berlin", AllDay: true}.AlignAllDay()
+		require.Equal(t, time.Date(2024, 4, 2, 0, 0, 0, 0, berlin), e.EndAt)
+		require.Equal(t, 71*time.Hour, e.EndAt.Sub(e.StartAt))
+		require.Equal(t, 3, e.Days())
+
+		// Повторение на весь день занимает сутки до и после перехода.
+		e = Event{
+			StartAt:    time.Date(2024, 3, 30, 0, 0, 0, 0, berlin),
+			EndAt:      time.Date(2024, 3, 31, 0, 0, 0, 0, berlin),
+			TimeZone:   "Europe/Berlin",
+			AllDay:     true,
+			Recurrence: &Recurrence{Frequency: FrequencyDaily, Interval: 1},
+		}
+		occurrences := e.Occurrences(e.StartAt, e.StartAt.AddDate(0, 0, 3))
+		require.Equal(t, 3, len(occurrences))
+		for i, o := range occurrences {
+			require.Equal(t, time.Date(2024, 3, 30+i, 0, 0, 0, 0, berlin), o.StartAt)
+			require.Equal(t, time.Date(2024, 3, 31+i, 0, 0, 0, 0, berlin), o.EndAt)
+		}
+
+		// 27.10.2024 длится 25 часов и находится выборкой, начинающейся в конце суток.
+		e.StartAt = time.Date(2024, 10, 26, 0, 0, 0, 0, berlin)
+		e.EndAt = time.Date(2024, 10, 27, 0, 0, 0, 0, berlin)
+		overlapping := e.OccurrencesOverlapping(
+			time.Date(2024, 10, 27, 23, 30, 0, 0, berlin),
+			time.Date(2024, 10, 28, 0, 0, 0, 0, berlin),
+		)
+		require.Equal(t, 1, len(overlapping))
+		require.Equal(t, time.Date(2024, 10, 27, 0, 0, 0, 0, berlin), overlapping[0].StartAt)
+		require.Equal(t, 25*time.Hour, overlapping[0].EndAt.Sub(overlapping[0].StartAt))
+	})
+}
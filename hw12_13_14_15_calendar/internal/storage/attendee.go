@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+)
+
+// AttendeeRole роль приглашенного участника события.
+type AttendeeRole string
+
+const (
+	RoleRequired AttendeeRole = "required"
+	RoleOptional AttendeeRole = "optional"
+)
+
+// RSVPStatus ответ участника на приглашение.
+type RSVPStatus string
+
+const (
+	RSVPNeedsAction RSVPStatus = "needs-action"
+	RSVPAccepted    RSVPStatus = "accepted"
+	RSVPDeclined    RSVPStatus = "declined"
+	RSVPTentative   RSVPStatus = "tentative"
+)
+
+var (
+	ErrInvalidAttendee = errors.New("невалидный участник события")
+	ErrInvalidRSVP     = errors.New("невалидный ответ на приглашение")
+	ErrNotInvited      = errors.New("пользователь не приглашен на событие")
+)
+
+// Attendee участник события. Автор события участником не является.
+type Attendee struct {
+	UserID string
+	Role   AttendeeRole
+	Status RSVPStatus
+}
+
+// Validate проверяет роль участника.
+func (r AttendeeRole) Validate() error {
+	if r != RoleRequired && r != RoleOptional {
+		return ErrInvalidAttendee
+	}
+
+	return nil
+}
+
+// Validate проверяет ответ участника, needs-action можно только получить вместе с приглашением.
+func (s RSVPStatus) Validate() error {
+	if s != RSVPAccepted && s != RSVPDeclined && s != RSVPTentative {
+		return ErrInvalidRSVP
+	}
+
+	return nil
+}
+
+// Attendee возвращает участника события с идентификатором userID.
+func (e Event) Attendee(userID string) (Attendee, bool) {
+	i := attendeeIndex(e.Attendees, userID)
+	if i == -1 {
+		return Attendee{}, false
+	}
+
+	return e.Attendees[i], true
+}
+
+// IsVisibleTo сообщает, что событие видно пользователю: он автор или приглашен,
+// в том числе если отклонил приглашение.
+func (e Event) IsVisibleTo(userID string) bool {
+	_, ok := e.Attendee(userID)
+
+	return e.AuthorID == userID || ok
+}
+
+// IsBusyFor сообщает, что событие занимает время пользователя: он автор или
+// участник, не отклонивший приглашение.
+func (e Event) IsBusyFor(userID string) bool {
+	a, ok := e.Attendee(userID)
+
+	return e.AuthorID == userID || ok && a.Status != RSVPDeclined
+}
+
+// Participants возвращает пользователей, время которых занимает событие.
+func (e Event) Participants() []string {
+	result := []string{e.AuthorID}
+	for _, a := range e.Attendees {
+		if a.Status != RSVPDeclined {
+			result = append(result, a.UserID)
+		}
+	}
+
+	return result
+}
+
+// MergeAttendees добавляет приглашенных к текущим участникам: новые получают
+// статус needs-action, у уже приглашенных меняется только роль. Возвращает
+// новый список, упорядоченный по UserID, и идентификаторы добавленных пользователей.
+func MergeAttendees(current, invited []Attendee) ([]Attendee, []string) {
+	result := make([]Attendee, len(current), len(current)+len(invited))
+	copy(result, current)
+
+	added := make([]string, 0)
+	for _, a := range invited {
+		i := attendeeIndex(result, a.UserID)
+		if i == -1 {
+			result = append(result, Attendee{UserID: a.UserID, Role: a.Role, Status: RSVPNeedsAction})
+			added = append(added, a.UserID)
+			continue
+		}
+
+		result[i].Role = a.Role
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UserID < result[j].UserID
+	})
+
+	return result, added
+}
+
+func attendeeIndex(attendees []Attendee, userID string) int {
+	for i, a := range attendees {
+		if a.UserID == userID {
+			return i
+		}
+	}
+
+	return -1
+}
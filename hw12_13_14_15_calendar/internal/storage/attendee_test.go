@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeAttendees(t *testing.T) {
+	current := []Attendee{
+		{UserID: "b", Role: RoleRequired, Status: RSVPDeclined},
+		{UserID: "c", Role: RoleRequired, Status: RSVPAccepted},
+	}
+
+	merged, added := MergeAttendees(current, []Attendee{
+		{UserID: "b", Role: RoleOptional},
+		{UserID: "a", Role: RoleRequired},
+	})
+
+	require.Equal(t, []string{"a"}, added)
+	require.Equal(t, []Attendee{
+		{UserID: "a", Role: RoleRequired, Status: RSVPNeedsAction},
+		{UserID: "b", Role: RoleOptional, Status: RSVPDeclined},
+		{UserID: "c", Role: RoleRequired, Status: RSVPAccepted},
+	}, merged)
+	// Текущий список не изменяется.
+	require.Equal(t, RoleRequired, current[0].Role)
+}
+
+func TestNotifications(t *testing.T) {
+	e := Event{
+		ID:         "1",
+		AuthorID:   "author",
+		Place:      Location{Text: "Переговорная"},
+		MeetingURL: "https://meet.example.com/1",
+		Attendees: []Attendee{
+			{UserID: "a", Status: RSVPAccepted},
+			{UserID: "b", Status: RSVPDeclined},
+			{UserID: "c", Status: RSVPNeedsAction},
+		},
+	}
+
+	users := make([]string, 0)
+	for _, n := range (Reminder{Event: e}).Notifications() {
+		require.Equal(t, e.ID, n.ID)
+		require.Equal(t, e.AuthorID, n.AuthorID)
+		require.Equal(t, e.Place, n.Location)
+		require.Equal(t, e.MeetingURL, n.MeetingURL)
+		users = append(users, n.UserID)
+	}
+	require.Equal(t, []string{"author", "a", "c"}, users)
+
+	require.True(t, e.IsVisibleTo("b"))
+	require.False(t, e.IsBusyFor("b"))
+	require.True(t, e.IsBusyFor("c"))
+	require.False(t, e.IsVisibleTo("d"))
+}
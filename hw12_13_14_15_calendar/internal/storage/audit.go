@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditAction действие над событием в журнале изменений.
+type AuditAction string
+
+const (
+	AuditCreate  AuditAction = "create"
+	AuditUpdate  AuditAction = "update"
+	AuditDelete  AuditAction = "delete"
+	AuditRestore AuditAction = "restore"
+)
+
+// AuditRecord запись журнала изменений события. Before - состояние до изменения
+// (nil при создании и восстановлении из корзины), After - после (nil при
+// удалении). ID назначается хранилищем при добавлении и возрастает в порядке записи.
+type AuditRecord struct {
+	ID      int64
+	EventID string
+	Action  AuditAction
+	ActorID string
+	At      time.Time
+	Before  *Event
+	After   *Event
+}
+
+// Snapshot возвращает последнее известное состояние события из записи.
+func (r AuditRecord) Snapshot() *Event {
+	if r.After != nil {
+		return r.After
+	}
+
+	return r.Before
+}
+
+// MarshalSnapshot кодирует состояние события для хранения в журнале, nil кодируется как nil.
+func MarshalSnapshot(event *Event) ([]byte, error) {
+	if event == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(event)
+}
+
+// UnmarshalSnapshot декодирует состояние события, записанное MarshalSnapshot.
+func UnmarshalSnapshot(data []byte) (*Event, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	event := &Event{}
+	if err := json.Unmarshal(data, event); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
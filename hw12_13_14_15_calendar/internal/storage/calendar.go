@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+var (
+	ErrCalendarNotFound     = errors.New("календарь не найден")
+	ErrCalendarAccessDenied = errors.New("нет доступа к календарю")
+	ErrCalendarNotEmpty     = errors.New("в календаре есть события")
+	ErrInvalidCalendar      = errors.New("невалидный календарь")
+	ErrInvalidShare         = errors.New("невалидный общий доступ к календарю")
+)
+
+// Permission уровень общего доступа к календарю.
+type Permission string
+
+const (
+	// PermissionRead позволяет видеть события календаря.
+	PermissionRead Permission = "read"
+	// PermissionWrite дополнительно позволяет создавать, изменять и удалять события календаря.
+	PermissionWrite Permission = "write"
+)
+
+func (p Permission) Validate() error {
+	switch p {
+	case PermissionRead, PermissionWrite:
+		return nil
+	default:
+		return fmt.Errorf("%w: уровень доступа %q", ErrInvalidShare, p)
+	}
+}
+
+// CalendarShare общий доступ пользователя UserID к календарю.
+type CalendarShare struct {
+	UserID     string
+	Permission Permission
+}
+
+// Calendar именованный календарь пользователя OwnerID. Shares изменяются
+// только ShareCalendar и UnshareCalendar, UpdateCalendar меняет название.
+//
+// События без календаря относятся к личному календарю автора, который
+// нельзя открыть другим пользователям.
+type Calendar struct {
+	ID      string
+	Name    string
+	OwnerID string
+	Shares  []CalendarShare
+}
+
+// PermissionFor возвращает уровень доступа пользователя, владелец имеет доступ на запись.
+func (c Calendar) PermissionFor(userID string) (Permission, bool) {
+	if c.OwnerID == userID {
+		return PermissionWrite, true
+	}
+
+	for _, s := range c.Shares {
+		if s.UserID == userID {
+			return s.Permission, true
+		}
+	}
+
+	return "", false
+}
+
+// CanRead сообщает, что пользователь видит события календаря.
+func (c Calendar) CanRead(userID string) bool {
+	_, ok := c.PermissionFor(userID)
+
+	return ok
+}
+
+// CanWrite сообщает, что пользователь может изменять события календаря.
+func (c Calendar) CanWrite(userID string) bool {
+	p, ok := c.PermissionFor(userID)
+
+	return ok && p == PermissionWrite
+}
+
+// SetShare возвращает доступы с добавленным или измененным доступом share,
+// упорядоченные по UserID.
+func SetShare(shares []CalendarShare, share CalendarShare) []CalendarShare {
+	result := make([]CalendarShare, 0, len(shares)+1)
+	for _, s := range shares {
+		if s.UserID != share.UserID {
+			result = append(result, s)
+		}
+	}
+	result = append(result, share)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UserID < result[j].UserID
+	})
+
+	return result
+}
+
+// RemoveShare возвращает доступы без доступа пользователя userID.
+func RemoveShare(shares []CalendarShare, userID string) []CalendarShare {
+	return slices.DeleteFunc(slices.Clone(shares), func(s CalendarShare) bool {
+		return s.UserID == userID
+	})
+}
+
+// SortCalendars упорядочивает календари по названию и ID.
+func SortCalendars(calendars []Calendar) {
+	sort.Slice(calendars, func(i, j int) bool {
+		if calendars[i].Name != calendars[j].Name {
+			return calendars[i].Name < calendars[j].Name
+		}
+
+		return calendars[i].ID < calendars[j].ID
+	})
+}
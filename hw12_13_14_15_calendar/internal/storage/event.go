@@ -3,18 +3,190 @@ package storage
 import "time"
 
 type Event struct {
-	ID               string
-	Title            string
-	StartAt          time.Time
-	EndAt            time.Time
-	Description      string
-	AuthorID         string
-	NotificationDate time.Time
+	ID          string
+	Title       string
+	StartAt     time.Time
+	EndAt       time.Time
+	Description string
+	AuthorID    string
+	Recurrence  *Recurrence
+	// CalendarID календарь события, пустое значение - личный календарь автора.
+	// Пересечения по времени проверяются только между событиями одного календаря.
+	CalendarID string
+	// TimeZone часовой пояс IANA, в котором разворачиваются повторения:
+	// при переходе на летнее время повторение остается в то же местное время.
+	// При пустом значении используется пояс StartAt.
+	TimeZone string
+	// Version увеличивается при каждом изменении события. В UpdateEvent и
+	// DeleteEvent передается ожидаемая версия, 0 отключает проверку.
+	Version int64
+	// DeletedAt время перемещения события в корзину, для активных событий нулевое.
+	DeletedAt time.Time
+	// Attendees изменяются только InviteAttendees и RespondInvitation,
+	// UpdateEvent сохраняет текущий список участников.
+	Attendees []Attendee
+	// Reminders смещения напоминаний до начала события, от раннего напоминания
+	// к позднему. При переносе события напоминания переносятся вместе с ним.
+	Reminders []time.Duration
+	// Tags категории события в каноничном виде NormalizeTags.
+	Tags []string
+	// Color цвет события в формате #rrggbb, пустое значение - цвет по умолчанию.
+	Color string
+	// Place место проведения, MeetingURL - ссылка на онлайн-встречу. Имя
+	// Location занято методом, возвращающим часовой пояс события.
+	Place      Location
+	MeetingURL string
+	// Resources идентификаторы забронированных ресурсов в каноничном виде
+	// NormalizeResources. Ресурс нельзя забронировать пересекающимися
+	// событиями, в отличие от времени участников - независимо от календаря.
+	Resources []string
+	// AllDay отмечает событие на весь день: оно начинается в полночь и длится
+	// целое число суток в часовом поясе события, см. AlignAllDay.
+	AllDay bool
 }
 
+// Notification уведомление о событии для пользователя UserID.
 type Notification struct {
-	ID       string
-	Title    string
-	Date     time.Time
-	AuthorID string
+	ID         string
+	Title      string
+	Date       time.Time
+	AuthorID   string
+	UserID     string
+	Location   Location
+	MeetingURL string
+}
+
+func (e Event) IsRecurring() bool {
+	return e.Recurrence != nil
+}
+
+// Occurrences возвращает повторения события, начинающиеся в интервале [from, to).
+// Для обычного события это само событие, если оно попадает в интервал.
+func (e Event) Occurrences(from, to time.Time) []Event {
+	result := make([]Event, 0)
+	if !e.IsRecurring() {
+		if !e.StartAt.Before(from) && e.StartAt.Before(to) {
+			result = append(result, e)
+		}
+
+		return result
+	}
+
+	e.Recurrence.starts(e.localStart(), func(start time.Time) bool {
+		if !start.Before(to) {
+			return false
+		}
+
+		if !start.Before(from) {
+			result = append(result, e.occurrence(start))
+		}
+
+		return true
+	})
+
+	return result
+}
+
+// OccurrencesOverlapping возвращает повторения события, пересекающиеся с
+// интервалом [from, to), в том числе начавшиеся раньше from.
+func (e Event) OccurrencesOverlapping(from, to time.Time) []Event {
+	result := make([]Event, 0)
+	for _, o := range e.Occurrences(from.Add(-e.span()), to) {
+		if Overlaps(o.StartAt, o.EndAt, from, to) {
+			result = append(result, o)
+		}
+	}
+
+	return result
+}
+
+// SeriesEnd возвращает время окончания последнего повторения события.
+// Для бесконечных повторений результат ограничен RecurrenceHorizon.
+func (e Event) SeriesEnd() time.Time {
+	if !e.IsRecurring() {
+		return e.EndAt
+	}
+
+	end := e.EndAt
+	horizon := e.StartAt.Add(RecurrenceHorizon)
+	e.Recurrence.starts(e.localStart(), func(start time.Time) bool {
+		if e.IsInfinite() && start.After(horizon) {
+			return false
+		}
+		end = e.occurrence(start).EndAt
+
+		return true
+	})
+
+	return end
+}
+
+// IsInfinite сообщает, что у повторяющегося события не задано ни COUNT, ни UNTIL.
+func (e Event) IsInfinite() bool {
+	return e.IsRecurring() && e.Recurrence.Count == 0 && e.Recurrence.Until.IsZero()
+}
+
+func (e Event) occurrence(start time.Time) Event {
+	o := e
+	o.StartAt = start
+	o.EndAt = start.Add(e.EndAt.Sub(e.StartAt))
+	// Повторение события на весь день занимает те же сутки и при переходе на летнее время.
+	if e.AllDay {
+		o.EndAt = start.AddDate(0, 0, e.Days())
+	}
+
+	return o
+}
+
+// Overlaps проверяет пересечение полуинтервалов [aStart, aEnd) и [bStart, bEnd).
+// Смежные интервалы не пересекаются, событие нулевой длительности занимает
+// момент своего начала.
+func Overlaps(aStart, aEnd, bStart, bEnd time.Time) bool {
+	if !aEnd.After(aStart) {
+		aEnd = aStart.Add(time.Nanosecond)
+	}
+	if !bEnd.After(bStart) {
+		bEnd = bStart.Add(time.Nanosecond)
+	}
+
+	return aStart.Before(bEnd) && bStart.Before(aEnd)
+}
+
+// Conflicts проверяет, пересекается ли хотя бы одно повторение события a
+// с каким-либо повторением события b.
+func Conflicts(a, b Event) bool {
+	if a.ID == b.ID {
+		return false
+	}
+
+	// Разворачиваем событие с меньшим числом повторений.
+	if !a.IsRecurring() && b.IsRecurring() {
+		a, b = b, a
+	}
+
+	bDuration := b.span()
+	from := b.StartAt
+	if a.StartAt.After(from) {
+		from = a.StartAt
+	}
+	// Конец окна задает событие с конечным числом повторений. Если оба события
+	// бесконечны, окно отсчитывается от начала позднего из них, а не от начала a,
+	// иначе повторения после RecurrenceHorizon не проверялись бы.
+	to := from.Add(RecurrenceHorizon)
+	for _, e := range []Event{a, b} {
+		if end := e.SeriesEnd(); !e.IsInfinite() && end.Before(to) {
+			to = end
+		}
+	}
+
+	// Окна расширены на наносекунду, чтобы учесть события нулевой длительности.
+	for _, o := range a.Occurrences(from.Add(-a.span()), to.Add(time.Nanosecond)) {
+		for _, other := range b.Occurrences(o.StartAt.Add(-bDuration), o.EndAt.Add(time.Nanosecond)) {
+			if Overlaps(o.StartAt, o.EndAt, other.StartAt, other.EndAt) {
+				return true
+			}
+		}
+	}
+
+	return false
 }
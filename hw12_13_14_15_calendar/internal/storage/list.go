@@ -0,0 +1,213 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultListLimit = 100
+	MaxListLimit     = 1000
+)
+
+var ErrInvalidCursor = errors.New("невалидный курсор")
+
+// ListFilter параметры выборки событий, начинающихся в интервале [From, To),
+// а при Overlapping - пересекающихся с ним, как в выборках за сутки, неделю
+// и месяц. UserID ограничивает выборку событиями, которые видны пользователю,
+// CalendarID - событиями календаря. Пустые UserID, CalendarID и Query, а также
+// HasNotification == nil не ограничивают выборку.
+type ListFilter struct {
+	UserID     string
+	CalendarID string
+	// Calendars календари, которыми UserID владеет или к которым имеет общий
+	// доступ. Заполняется хранилищем перед PageEvents.
+	Calendars       []string
+	From            time.Time
+	To              time.Time
+	Query           string
+	HasNotification *bool
+	Cursor          string
+	Limit           int
+	Overlapping     bool
+}
+
+// Page страница событий. NextCursor пуст, если страница последняя.
+type Page struct {
+	Events     []Event
+	NextCursor string
+}
+
+// Cursor позиция последнего события страницы. События упорядочены по времени
+// начала и ID, повторения одного события различаются временем начала.
+type Cursor struct {
+	StartAt time.Time
+	ID      string
+}
+
+func EncodeCursor(e Event) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(e.StartAt.UnixNano(), 10) + ":" + e.ID))
+}
+
+// DecodeCursor разбирает курсор, для пустой строки возвращает нулевой Cursor.
+func DecodeCursor(cursor string) (Cursor, error) {
+	if cursor == "" {
+		return Cursor{}, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	start, id, ok := strings.Cut(string(data), ":")
+	if !ok || id == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	nsec, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return Cursor{StartAt: time.Unix(0, nsec).UTC(), ID: id}, nil
+}
+
+func (c Cursor) IsZero() bool {
+	return c.ID == ""
+}
+
+// Before сообщает, что событие e находится в выборке после курсора.
+func (c Cursor) Before(e Event) bool {
+	return c.IsZero() || less(c.StartAt, c.ID, e)
+}
+
+func less(startAt time.Time, id string, e Event) bool {
+	if !startAt.Equal(e.StartAt) {
+		return startAt.Before(e.StartAt)
+	}
+
+	return id < e.ID
+}
+
+// PageLimit возвращает размер страницы с учетом значения по умолчанию и максимума.
+func (f ListFilter) PageLimit() int {
+	switch {
+	case f.Limit <= 0:
+		return DefaultListLimit
+	case f.Limit > MaxListLimit:
+		return MaxListLimit
+	default:
+		return f.Limit
+	}
+}
+
+// Match проверяет пользователя, календарь, текст и наличие уведомления. Интервал и курсор
+// проверяются отдельно.
+func (f ListFilter) Match(e Event) bool {
+	if f.UserID != "" && !e.IsVisibleTo(f.UserID) && !slices.Contains(f.Calendars, e.CalendarID) {
+		return false
+	}
+
+	if f.CalendarID != "" && e.CalendarID != f.CalendarID {
+		return false
+	}
+
+	if f.HasNotification != nil && *f.HasNotification == (len(e.Reminders) == 0) {
+		return false
+	}
+
+	if f.Query == "" {
+		return true
+	}
+
+	query := strings.ToLower(f.Query)
+
+	return strings.Contains(strings.ToLower(e.Title), query) ||
+		strings.Contains(strings.ToLower(e.Description), query)
+}
+
+// PageEvents упорядочивает отобранные хранилищем события и возвращает страницу
+// после курсора. Хранилище может передать больше событий, чем помещается на
+// страницу, лишние отбрасываются.
+func PageEvents(events []Event, f ListFilter) (Page, error) {
+	cursor, err := DecodeCursor(f.Cursor)
+	if err != nil {
+		return Page{}, err
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return less(events[i].StartAt, events[i].ID, events[j])
+	})
+
+	result := make([]Event, 0, len(events))
+	for _, e := range events {
+		if cursor.Before(e) && f.Match(e) && f.InRange(e) {
+			result = append(result, e)
+		}
+	}
+
+	limit := f.PageLimit()
+	if len(result) <= limit {
+		return Page{Events: result}, nil
+	}
+
+	return Page{Events: result[:limit], NextCursor: EncodeCursor(result[limit-1])}, nil
+}
+
+// InRange проверяет, что событие начинается в интервале фильтра или, при
+// Overlapping, пересекается с ним.
+func (f ListFilter) InRange(e Event) bool {
+	if f.Overlapping {
+		return Overlaps(e.StartAt, e.EndAt, f.From, f.To)
+	}
+
+	return !e.StartAt.Before(f.From) && e.StartAt.Before(f.To)
+}
+
+// Occurrences возвращает повторения события, которые могут попасть в выборку
+// начиная с from.
+func (f ListFilter) Occurrences(e Event, from time.Time) []Event {
+	if f.Overlapping {
+		return e.OccurrencesOverlapping(from, f.To)
+	}
+
+	return e.Occurrences(from, f.To)
+}
+
+// CollectEvents возвращает события всех страниц выборки list.
+func CollectEvents(
+	ctx context.Context,
+	list func(context.Context, ListFilter) (Page, error),
+	f ListFilter,
+) ([]Event, error) {
+	f.Limit = MaxListLimit
+	result := make([]Event, 0)
+	for {
+		page, err := list(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, page.Events...)
+		if page.NextCursor == "" {
+			return result, nil
+		}
+		f.Cursor = page.NextCursor
+	}
+}
+
+// Start возвращает начало интервала с учетом курсора.
+func (f ListFilter) Start(cursor Cursor) time.Time {
+	if !cursor.IsZero() && cursor.StartAt.After(f.From) {
+		return cursor.StartAt
+	}
+
+	return f.From
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxLocationLength ограничивает длину текста места проведения в символах.
+const MaxLocationLength = 255
+
+var (
+	ErrInvalidLocation   = errors.New("невалидное место проведения")
+	ErrInvalidMeetingURL = errors.New("невалидная ссылка на встречу")
+)
+
+// Location место проведения события: произвольный текст и необязательные координаты.
+type Location struct {
+	Text        string
+	Coordinates *Coordinates
+}
+
+// Coordinates географические координаты в градусах WGS 84.
+type Coordinates struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// IsZero проверяет, что место проведения не указано.
+func (l Location) IsZero() bool {
+	return l.Text == "" && l.Coordinates == nil
+}
+
+// Validate проверяет длину текста и диапазон координат.
+func (l Location) Validate() error {
+	if utf8.RuneCountInString(l.Text) > MaxLocationLength {
+		return ErrInvalidLocation
+	}
+
+	if c := l.Coordinates; c != nil {
+		if c.Latitude < -90 || c.Latitude > 90 || c.Longitude < -180 || c.Longitude > 180 {
+			return ErrInvalidLocation
+		}
+	}
+
+	return nil
+}
+
+// NormalizeLocation убирает пробелы по краям текста и проверяет место проведения.
+func NormalizeLocation(l Location) (Location, error) {
+	l.Text = strings.TrimSpace(l.Text)
+
+	return l, l.Validate()
+}
+
+// NormalizeMeetingURL проверяет ссылку на онлайн-встречу: допускаются только
+// абсолютные ссылки http и https. Пустая ссылка означает встречу без онлайн-подключения.
+func NormalizeMeetingURL(meetingURL string) (string, error) {
+	meetingURL = strings.TrimSpace(meetingURL)
+	if meetingURL == "" {
+		return "", nil
+	}
+
+	u, err := url.Parse(meetingURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", ErrInvalidMeetingURL
+	}
+
+	return u.String(), nil
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeLocation(t *testing.T) {
+	l, err := NormalizeLocation(Location{Text: " Офис, 3 этаж ", Coordinates: &Coordinates{55.75, 37.62}})
+	require.NoError(t, err)
+	require.Equal(t, "Офис, 3 этаж", l.Text)
+
+	for _, l := range []Location{
+		{Text: strings.Repeat("я", MaxLocationLength+1)},
+		{Coordinates: &Coordinates{Latitude: 91}},
+		{Coordinates: &Coordinates{Longitude: -181}},
+	} {
+		_, err := NormalizeLocation(l)
+		require.True(t, errors.Is(err, ErrInvalidLocation))
+	}
+}
+
+func TestNormalizeMeetingURL(t *testing.T) {
+	u, err := NormalizeMeetingURL(" https://meet.example.com/abc-def ")
+	require.NoError(t, err)
+	require.Equal(t, "https://meet.example.com/abc-def", u)
+
+	u, err = NormalizeMeetingURL("")
+	require.NoError(t, err)
+	require.Equal(t, "", u)
+
+	for _, v := range []string{"meet.example.com/abc", "ftp://example.com", "https://", "javascript:alert(1)"} {
+		_, err := NormalizeMeetingURL(v)
+		require.True(t, errors.Is(err, ErrInvalidMeetingURL), v)
+	}
+}
@@ -0,0 +1,61 @@
+package memorystorage
+
+import (
+	"context"
+
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+// auditCapacity количество последних записей журнала изменений, которые хранятся в памяти.
+const auditCapacity = 10000
+
+// auditRing кольцевой буфер журнала изменений. Журнал не сохраняется на диск,
+// при переполнении вытесняются самые старые записи.
+type auditRing struct {
+	records []internalStorage.AuditRecord
+	next    int
+	lastID  int64
+}
+
+func (r *auditRing) add(record internalStorage.AuditRecord) {
+	r.lastID++
+	record.ID = r.lastID
+
+	if len(r.records) < auditCapacity {
+		r.records = append(r.records, record)
+		return
+	}
+
+	r.records[r.next] = record
+	r.next = (r.next + 1) % auditCapacity
+}
+
+// each обходит записи от старых к новым.
+func (r *auditRing) each(fn func(record internalStorage.AuditRecord)) {
+	for i := range r.records {
+		fn(r.records[(r.next+i)%len(r.records)])
+	}
+}
+
+func (s *storage) AddAuditRecord(_ context.Context, record internalStorage.AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.audit.add(record)
+
+	return nil
+}
+
+func (s *storage) EventHistory(_ context.Context, eventID string) ([]internalStorage.AuditRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]internalStorage.AuditRecord, 0)
+	s.audit.each(func(record internalStorage.AuditRecord) {
+		if record.EventID == eventID {
+			result = append(result, record)
+		}
+	})
+
+	return result, nil
+}
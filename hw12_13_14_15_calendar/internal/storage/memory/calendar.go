@@ -0,0 +1,176 @@
+package memorystorage
+
+import (
+	"context"
+
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *storage) CreateCalendar(_ context.Context, calendar internalStorage.Calendar) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	calendar.Shares = nil
+
+	return s.saveCalendar(calendar)
+}
+
+// UpdateCalendar изменяет название календаря, доступы сохраняются.
+func (s *storage) UpdateCalendar(_ context.Context, calendar internalStorage.Calendar) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.ownCalendar(calendar.OwnerID, calendar.ID)
+	if err != nil {
+		return err
+	}
+	current.Name = calendar.Name
+
+	return s.saveCalendar(current)
+}
+
+// DeleteCalendar удаляет календарь без событий вне корзины.
+func (s *storage) DeleteCalendar(_ context.Context, userID string, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.ownCalendar(userID, id); err != nil {
+		return err
+	}
+
+	for _, event := range s.events {
+		if event.CalendarID == id {
+			return internalStorage.ErrCalendarNotEmpty
+		}
+	}
+
+	if err := s.log(record{Op: opCalendarDelete, IDs: []string{id}}); err != nil {
+		return err
+	}
+	s.deleteCalendar(id)
+
+	return nil
+}
+
+func (s *storage) GetCalendar(_ context.Context, userID string, id string) (internalStorage.Calendar, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	calendar, ok := s.calendars[id]
+	if !ok {
+		return internalStorage.Calendar{}, internalStorage.ErrCalendarNotFound
+	}
+
+	if !calendar.CanRead(userID) {
+		return internalStorage.Calendar{}, internalStorage.ErrCalendarAccessDenied
+	}
+
+	return calendar, nil
+}
+
+// ListCalendars возвращает собственные и открытые пользователю календари.
+func (s *storage) ListCalendars(_ context.Context, userID string) ([]internalStorage.Calendar, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]internalStorage.Calendar, 0)
+	for _, calendar := range s.calendars {
+		if calendar.CanRead(userID) {
+			result = append(result, calendar)
+		}
+	}
+	internalStorage.SortCalendars(result)
+
+	return result, nil
+}
+
+// ShareCalendar открывает или изменяет доступ пользователя к календарю.
+func (s *storage) ShareCalendar(
+	_ context.Context,
+	userID string,
+	id string,
+	share internalStorage.CalendarShare,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	calendar, err := s.ownCalendar(userID, id)
+	if err != nil {
+		return err
+	}
+	calendar.Shares = internalStorage.SetShare(calendar.Shares, share)
+
+	return s.saveCalendar(calendar)
+}
+
+// UnshareCalendar закрывает доступ пользователя targetID к календарю.
+func (s *storage) UnshareCalendar(_ context.Context, userID string, id string, targetID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	calendar, err := s.ownCalendar(userID, id)
+	if err != nil {
+		return err
+	}
+	calendar.Shares = internalStorage.RemoveShare(calendar.Shares, targetID)
+
+	return s.saveCalendar(calendar)
+}
+
+// ownCalendar возвращает календарь, если им владеет пользователь.
+func (s *storage) ownCalendar(userID string, id string) (internalStorage.Calendar, error) {
+	calendar, ok := s.calendars[id]
+	if !ok {
+		return internalStorage.Calendar{}, internalStorage.ErrCalendarNotFound
+	}
+
+	if calendar.OwnerID != userID {
+		return internalStorage.Calendar{}, internalStorage.ErrCalendarAccessDenied
+	}
+
+	return calendar, nil
+}
+
+func (s *storage) saveCalendar(calendar internalStorage.Calendar) error {
+	if err := s.log(record{Op: opCalendar, Calendar: &calendar}); err != nil {
+		return err
+	}
+	s.calendars[calendar.ID] = calendar
+
+	return nil
+}
+
+// deleteCalendar удаляет календарь, события из корзины переходят в личный календарь автора.
+func (s *storage) deleteCalendar(id string) {
+	delete(s.calendars, id)
+	for eventID, event := range s.deleted {
+		if event.CalendarID == id {
+			event.CalendarID = ""
+			s.deleted[eventID] = event
+		}
+	}
+}
+
+// readableCalendars возвращает календари, события которых видны пользователю.
+func (s *storage) readableCalendars(userID string) []string {
+	result := make([]string, 0)
+	for id, calendar := range s.calendars {
+		if calendar.CanRead(userID) {
+			result = append(result, id)
+		}
+	}
+
+	return result
+}
+
+// isVisible проверяет, что событие видно пользователю как автору, участнику
+// или через общий доступ к календарю.
+func (s *storage) isVisible(event internalStorage.Event, userID string) bool {
+	if event.IsVisibleTo(userID) {
+		return true
+	}
+
+	calendar, ok := s.calendars[event.CalendarID]
+
+	return ok && calendar.CanRead(userID)
+}
@@ -0,0 +1,366 @@
+package memorystorage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+const (
+	snapshotFile = "snapshot.json"
+	walFile      = "wal.log"
+
+	opPut      = "put"
+	opTrash    = "trash"
+	opDelete   = "delete"
+	opNotified = "notified"
+
+	opCalendar       = "calendar"
+	opCalendarDelete = "calendarDelete"
+
+	opResource       = "resource"
+	opResourceDelete = "resourceDelete"
+)
+
+var ErrCorruptedLog = errors.New("журнал хранилища поврежден")
+
+// record запись журнала предзаписи, в файле каждая запись занимает одну строку JSON.
+type record struct {
+	Op        string                    `json:"op"`
+	Event     *internalStorage.Event    `json:"event,omitempty"`
+	IDs       []string                  `json:"ids,omitempty"`
+	Reminders []sentReminder            `json:"reminders,omitempty"`
+	Calendar  *internalStorage.Calendar `json:"calendar,omitempty"`
+	Resource  *internalStorage.Resource `json:"resource,omitempty"`
+	At        time.Time                 `json:"at"`
+}
+
+// sentReminder отправленное напоминание события ID за Offset до начала,
+// At - время его срабатывания.
+type sentReminder struct {
+	ID     string        `json:"id"`
+	Offset time.Duration `json:"offset"`
+	At     time.Time     `json:"at"`
+}
+
+// snapshot полное состояние хранилища на момент сжатия журнала.
+type snapshot struct {
+	Events    []internalStorage.Event                `json:"events"`
+	Deleted   []internalStorage.Event                `json:"deleted"`
+	Reminders map[string]map[time.Duration]time.Time `json:"reminders"`
+	Calendars []internalStorage.Calendar             `json:"calendars"`
+	Resources []internalStorage.Resource             `json:"resources"`
+}
+
+// persistence хранит файлы снимка и журнала. Запись журнала передается ОС
+// сразу после изменения, fsync выполняется при сжатии и в Close.
+type persistence struct {
+	path            string
+	compactInterval time.Duration
+
+	wal        *os.File
+	walWriter  *bufio.Writer
+	stop       chan struct{}
+	wg         sync.WaitGroup
+	compactErr error
+}
+
+func (s *storage) open() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.path, 0o755); err != nil {
+		return err
+	}
+
+	if err := s.loadSnapshot(); err != nil {
+		return err
+	}
+
+	wal, err := os.OpenFile(filepath.Join(s.path, walFile), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if err := s.replay(wal); err != nil {
+		_ = wal.Close()
+		return err
+	}
+
+	s.wal = wal
+	s.walWriter = bufio.NewWriter(wal)
+
+	if err := s.compact(); err != nil {
+		_ = wal.Close()
+		s.wal = nil
+		return err
+	}
+
+	if s.compactInterval > 0 {
+		s.stop = make(chan struct{})
+		s.wg.Add(1)
+		go s.compactLoop()
+	}
+
+	return nil
+}
+
+func (s *storage) close() error {
+	if s.stop != nil {
+		close(s.stop)
+		s.wg.Wait()
+		s.stop = nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.wal == nil {
+		return nil
+	}
+
+	err := s.walWriter.Flush()
+	if err == nil {
+		err = s.wal.Sync()
+	}
+	if closeErr := s.wal.Close(); err == nil {
+		err = closeErr
+	}
+	s.wal = nil
+
+	if err == nil {
+		err = s.compactErr
+	}
+
+	return err
+}
+
+func (s *storage) compactLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.compactInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			// Журнал остается целым, поэтому при ошибке сжатие повторится на следующем тике.
+			s.compactErr = s.compact()
+			s.mu.Unlock()
+		}
+	}
+}
+
+// log добавляет запись в журнал. Вызывается под блокировкой до изменения состояния.
+func (s *storage) log(r record) error {
+	if s.wal == nil {
+		return nil
+	}
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.walWriter.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	return s.walWriter.Flush()
+}
+
+func (s *storage) apply(r record) error {
+	if (r.Op == opPut || r.Op == opTrash) && r.Event == nil {
+		return fmt.Errorf("%w: запись %s без события", ErrCorruptedLog, r.Op)
+	}
+
+	if r.Op == opCalendar && r.Calendar == nil {
+		return fmt.Errorf("%w: запись %s без календаря", ErrCorruptedLog, r.Op)
+	}
+
+	if r.Op == opResource && r.Resource == nil {
+		return fmt.Errorf("%w: запись %s без ресурса", ErrCorruptedLog, r.Op)
+	}
+
+	switch r.Op {
+	case opPut:
+		s.putEvent(r.Event.Localize())
+	case opTrash:
+		s.putDeleted(r.Event.Localize())
+	case opDelete:
+		for _, id := range r.IDs {
+			s.deleteEvent(id)
+		}
+	case opNotified:
+		s.markNotified(r.Reminders)
+	case opCalendar:
+		s.calendars[r.Calendar.ID] = *r.Calendar
+	case opCalendarDelete:
+		for _, id := range r.IDs {
+			s.deleteCalendar(id)
+		}
+	case opResource:
+		s.resources[r.Resource.ID] = *r.Resource
+	case opResourceDelete:
+		for _, id := range r.IDs {
+			s.deleteResource(id)
+		}
+	default:
+		return fmt.Errorf("%w: неизвестная операция %q", ErrCorruptedLog, r.Op)
+	}
+
+	return nil
+}
+
+// replay применяет записи журнала. Незавершенная последняя строка остается
+// после аварийной остановки во время записи и отбрасывается.
+func (s *storage) replay(wal *os.File) error {
+	reader := bufio.NewReader(wal)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				return wal.Truncate(offset)
+			}
+
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		r := record{}
+		if err := json.Unmarshal(line, &r); err != nil {
+			return fmt.Errorf("%w: %s", ErrCorruptedLog, err.Error())
+		}
+
+		if err := s.apply(r); err != nil {
+			return err
+		}
+		offset += int64(len(line))
+	}
+}
+
+func (s *storage) loadSnapshot() error {
+	data, err := os.ReadFile(filepath.Join(s.path, snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	snap := snapshot{}
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("%w: %s", ErrCorruptedLog, err.Error())
+	}
+
+	// В JSON у времен остается только смещение, часовой пояс восстанавливается по TimeZone.
+	for _, event := range snap.Events {
+		s.putEvent(event.Localize())
+	}
+	for _, event := range snap.Deleted {
+		s.putDeleted(event.Localize())
+	}
+	for id, notified := range snap.Reminders {
+		s.notifiedUntil[id] = notified
+	}
+	for _, calendar := range snap.Calendars {
+		s.calendars[calendar.ID] = calendar
+	}
+	for _, resource := range snap.Resources {
+		s.resources[resource.ID] = resource
+	}
+
+	return nil
+}
+
+// compact записывает снимок и очищает журнал. Снимок заменяется атомарно
+// через rename, а повторное применение журнала к новому снимку безопасно,
+// поэтому остановка между этими шагами не приводит к потере данных.
+func (s *storage) compact() error {
+	snap := snapshot{
+		Events:    make([]internalStorage.Event, 0, len(s.events)),
+		Deleted:   make([]internalStorage.Event, 0, len(s.deleted)),
+		Reminders: s.notifiedUntil,
+		Calendars: make([]internalStorage.Calendar, 0, len(s.calendars)),
+		Resources: make([]internalStorage.Resource, 0, len(s.resources)),
+	}
+	for _, event := range s.events {
+		snap.Events = append(snap.Events, event)
+	}
+	for _, event := range s.deleted {
+		snap.Deleted = append(snap.Deleted, event)
+	}
+	for _, calendar := range s.calendars {
+		snap.Calendars = append(snap.Calendars, calendar)
+	}
+	for _, resource := range s.resources {
+		snap.Resources = append(snap.Resources, resource)
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileSync(filepath.Join(s.path, snapshotFile), data); err != nil {
+		return err
+	}
+
+	if err := s.walWriter.Flush(); err != nil {
+		return err
+	}
+
+	if err := s.wal.Truncate(0); err != nil {
+		return err
+	}
+
+	return s.wal.Sync()
+}
+
+func writeFileSync(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	return dir.Sync()
+}
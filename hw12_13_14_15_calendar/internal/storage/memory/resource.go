@@ -0,0 +1,159 @@
+package memorystorage
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *storage) CreateResource(_ context.Context, resource internalStorage.Resource) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.saveResource(resource)
+}
+
+// UpdateResource изменяет название, тип и вместимость ресурса.
+func (s *storage) UpdateResource(_ context.Context, resource internalStorage.Resource) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.ownResource(resource.OwnerID, resource.ID); err != nil {
+		return err
+	}
+
+	return s.saveResource(resource)
+}
+
+// DeleteResource удаляет ресурс, не забронированный событиями вне корзины.
+func (s *storage) DeleteResource(_ context.Context, userID string, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.ownResource(userID, id); err != nil {
+		return err
+	}
+
+	for _, event := range s.events {
+		if event.HasResource(id) {
+			return internalStorage.ErrResourceInUse
+		}
+	}
+
+	if err := s.log(record{Op: opResourceDelete, IDs: []string{id}}); err != nil {
+		return err
+	}
+	s.deleteResource(id)
+
+	return nil
+}
+
+func (s *storage) GetResource(_ context.Context, id string) (internalStorage.Resource, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resource, ok := s.resources[id]
+	if !ok {
+		return internalStorage.Resource{}, internalStorage.ErrResourceNotFound
+	}
+
+	return resource, nil
+}
+
+func (s *storage) ListResources(_ context.Context) ([]internalStorage.Resource, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]internalStorage.Resource, 0, len(s.resources))
+	for _, resource := range s.resources {
+		result = append(result, resource)
+	}
+	internalStorage.SortResources(result)
+
+	return result, nil
+}
+
+// ResourceEvents перебирает все события: индекс по дате начала не учитывает
+// события, начавшиеся раньше from.
+func (s *storage) ResourceEvents(
+	_ context.Context,
+	id string,
+	from time.Time,
+	to time.Time,
+) ([]internalStorage.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.resources[id]; !ok {
+		return nil, internalStorage.ErrResourceNotFound
+	}
+
+	result := make([]internalStorage.Event, 0)
+	for _, event := range s.events {
+		if event.HasResource(id) {
+			result = append(result, event.OccurrencesOverlapping(from, to)...)
+		}
+	}
+
+	return result, nil
+}
+
+// ownResource возвращает ресурс, если его создал пользователь.
+func (s *storage) ownResource(userID string, id string) (internalStorage.Resource, error) {
+	resource, ok := s.resources[id]
+	if !ok {
+		return internalStorage.Resource{}, internalStorage.ErrResourceNotFound
+	}
+
+	if resource.OwnerID != userID {
+		return internalStorage.Resource{}, internalStorage.ErrResourceAccessDenied
+	}
+
+	return resource, nil
+}
+
+func (s *storage) saveResource(resource internalStorage.Resource) error {
+	if err := s.log(record{Op: opResource, Resource: &resource}); err != nil {
+		return err
+	}
+	s.resources[resource.ID] = resource
+
+	return nil
+}
+
+// deleteResource удаляет ресурс и снимает его бронь с событий из корзины.
+func (s *storage) deleteResource(id string) {
+	delete(s.resources, id)
+	for eventID, event := range s.deleted {
+		if event.HasResource(id) {
+			event.Resources = slices.DeleteFunc(slices.Clone(event.Resources), func(r string) bool {
+				return r == id
+			})
+			s.deleted[eventID] = event
+		}
+	}
+}
+
+// checkResources проверяет, что ресурсы события существуют и не забронированы
+// пересекающимися событиями.
+func (s *storage) checkResources(event internalStorage.Event) error {
+	for _, id := range event.Resources {
+		if _, ok := s.resources[id]; !ok {
+			return internalStorage.ErrResourceNotFound
+		}
+	}
+
+	if len(event.Resources) == 0 {
+		return nil
+	}
+
+	for _, t := range s.events {
+		if event.SharesResource(t) && internalStorage.Conflicts(event, t) {
+			return internalStorage.ErrResourceBusy
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package memorystorage
+
+import (
+	"context"
+
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+// SearchEvents выбирает по инвертированному индексу события, содержащие все
+// слова запроса, и упорядочивает их по SearchRank.
+func (s *storage) SearchEvents(
+	_ context.Context,
+	filter internalStorage.SearchFilter,
+) ([]internalStorage.SearchResult, error) {
+	terms := internalStorage.SearchTerms(filter.Query)
+	if len(terms) == 0 {
+		return nil, internalStorage.ErrInvalidQuery
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if filter.UserID != "" {
+		filter.Calendars = s.readableCalendars(filter.UserID)
+	}
+
+	result := make([]internalStorage.SearchResult, 0)
+	for id := range s.eventIdsByTerm[terms[0]] {
+		if !s.hasTerms(id, terms[1:]) {
+			continue
+		}
+
+		event := s.events[id]
+		if !filter.Match(event) {
+			continue
+		}
+
+		if rank := event.SearchRank(terms); rank > 0 {
+			result = append(result, internalStorage.SearchResult{Event: event, Rank: rank})
+		}
+	}
+
+	return internalStorage.SortSearchResults(result, filter.PageLimit()), nil
+}
+
+// hasTerms проверяет по индексу, что событие содержит все слова terms.
+func (s *storage) hasTerms(id string, terms []string) bool {
+	for _, term := range terms {
+		if _, ok := s.eventIdsByTerm[term][id]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// addToSearchIndex добавляет событие в индекс слов названия и описания.
+func (s *storage) addToSearchIndex(event internalStorage.Event) {
+	for _, term := range internalStorage.SearchTerms(event.Title + " " + event.Description) {
+		if _, ok := s.eventIdsByTerm[term]; !ok {
+			s.eventIdsByTerm[term] = make(map[string]struct{})
+		}
+
+		s.eventIdsByTerm[term][event.ID] = struct{}{}
+	}
+}
+
+func (s *storage) removeFromSearchIndex(event internalStorage.Event) {
+	for _, term := range internalStorage.SearchTerms(event.Title + " " + event.Description) {
+		delete(s.eventIdsByTerm[term], event.ID)
+		if len(s.eventIdsByTerm[term]) == 0 {
+			delete(s.eventIdsByTerm, term)
+		}
+	}
+}
@@ -2,7 +2,8 @@ package memorystorage
 
 import (
 	"context"
-	"fmt"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
@@ -15,18 +16,58 @@ const (
 )
 
 type storage struct {
-	events         map[string]internalStorage.Event
-	eventIdsByDate map[string]map[string]struct{}
-	sendingEvents  map[string]struct{}
-	mu             sync.RWMutex
+	events            map[string]internalStorage.Event
+	deleted           map[string]internalStorage.Event
+	eventIdsByDate    map[string]map[string]struct{}
+	recurringEventIds map[string]struct{}
+	eventIdsByTerm    map[string]map[string]struct{}
+	calendars         map[string]internalStorage.Calendar
+	resources         map[string]internalStorage.Resource
+	notifiedUntil     map[string]map[time.Duration]time.Time
+	audit             auditRing
+	mu                sync.RWMutex
+
+	persistence
+}
+
+func init() {
+	internalStorage.Register(Type, internalStorage.Backend{New: newFromOptions})
+}
+
+func newFromOptions(opts internalStorage.Options) internalStorage.Storage {
+	if opts.Path != "" {
+		return NewPersistent(opts.Path, opts.CompactInterval)
+	}
+
+	return New()
 }
 
 func New() internalStorage.Storage {
+	return newStorage()
+}
+
+// NewPersistent создает хранилище, которое сохраняет снимок и журнал
+// изменений в каталоге path. Журнал сжимается в снимок раз в compactInterval,
+// при нулевом значении - только при подключении.
+func NewPersistent(path string, compactInterval time.Duration) internalStorage.Storage {
+	s := newStorage()
+	s.path = path
+	s.compactInterval = compactInterval
+
+	return s
+}
+
+func newStorage() *storage {
 	return &storage{
-		mu:             sync.RWMutex{},
-		events:         make(map[string]internalStorage.Event),
-		eventIdsByDate: make(map[string]map[string]struct{}),
-		sendingEvents:  make(map[string]struct{}),
+		mu:                sync.RWMutex{},
+		events:            make(map[string]internalStorage.Event),
+		deleted:           make(map[string]internalStorage.Event),
+		eventIdsByDate:    make(map[string]map[string]struct{}),
+		recurringEventIds: make(map[string]struct{}),
+		eventIdsByTerm:    make(map[string]map[string]struct{}),
+		calendars:         make(map[string]internalStorage.Calendar),
+		resources:         make(map[string]internalStorage.Resource),
+		notifiedUntil:     make(map[string]map[time.Duration]time.Time),
 	}
 }
 
@@ -34,177 +75,535 @@ func (s *storage) CreateEvent(_ context.Context, event internalStorage.Event) er
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	year, month, day := event.StartAt.Date()
-	dateStr := fmt.Sprintf("%d-%d-%d", year, month, day)
+	event.Attendees = nil
+	if s.isDateBusy(event) {
+		return internalStorage.ErrDateBusy
+	}
+
+	if err := s.checkResources(event); err != nil {
+		return err
+	}
+
+	event.Version = internalStorage.InitialVersion
+
+	return s.put(event)
+}
+
+func (s *storage) UpdateEvent(_ context.Context, event internalStorage.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	oldEvent, ok := s.events[event.ID]
+	if !ok {
+		return internalStorage.ErrEventNotFound
+	}
 
-	if _, ok := s.eventIdsByDate[dateStr]; !ok {
-		s.eventIdsByDate[dateStr] = make(map[string]struct{})
+	if oldEvent.AuthorID != event.AuthorID {
+		return internalStorage.ErrAccessDenied
 	}
 
+	if event.Version != 0 && event.Version != oldEvent.Version {
+		return internalStorage.ErrVersionConflict
+	}
+
+	event.Attendees = oldEvent.Attendees
 	if s.isDateBusy(event) {
 		return internalStorage.ErrDateBusy
 	}
 
-	s.events[event.ID] = event
-	s.eventIdsByDate[dateStr][event.ID] = struct{}{}
+	if err := s.checkResources(event); err != nil {
+		return err
+	}
+
+	event.Version = oldEvent.Version + 1
+
+	if err := s.log(record{Op: opPut, Event: &event}); err != nil {
+		return err
+	}
+	s.putEvent(event)
 
 	return nil
 }
 
-func (s *storage) UpdateEvent(_ context.Context, event internalStorage.Event) error {
+func (s *storage) DeleteEvent(_ context.Context, userID string, id string, version int64) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	oldEvent, ok := s.events[event.ID]
+	eventForDelete, ok := s.events[id]
 	if !ok {
 		return internalStorage.ErrEventNotFound
 	}
 
-	if s.isDateBusy(event) {
+	if eventForDelete.AuthorID != userID {
+		return internalStorage.ErrAccessDenied
+	}
+
+	if version != 0 && version != eventForDelete.Version {
+		return internalStorage.ErrVersionConflict
+	}
+
+	return s.trash(eventForDelete, time.Now())
+}
+
+// InviteAttendees добавляет участников события, время новых участников должно быть свободно.
+func (s *storage) InviteAttendees(
+	_ context.Context,
+	userID string,
+	id string,
+	attendees []internalStorage.Attendee,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event, ok := s.events[id]
+	if !ok {
+		return internalStorage.ErrEventNotFound
+	}
+
+	if event.AuthorID != userID {
+		return internalStorage.ErrAccessDenied
+	}
+
+	merged, added := internalStorage.MergeAttendees(event.Attendees, attendees)
+	if s.isBusy(event, added) {
 		return internalStorage.ErrDateBusy
 	}
 
-	if oldEvent.StartAt != event.StartAt {
-		year, month, day := oldEvent.StartAt.Date()
-		dateStr := fmt.Sprintf("%d-%d-%d", year, month, day)
+	event.Attendees = merged
+	event.Version++
 
-		delete(s.eventIdsByDate[dateStr], oldEvent.ID)
+	return s.put(event)
+}
 
-		year, month, day = event.StartAt.Date()
-		dateStr = fmt.Sprintf("%d-%d-%d", year, month, day)
+// RespondInvitation сохраняет ответ участника. Отменить отказ можно, только
+// если время участника свободно.
+func (s *storage) RespondInvitation(
+	_ context.Context,
+	userID string,
+	id string,
+	status internalStorage.RSVPStatus,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		if _, ok := s.eventIdsByDate[dateStr]; !ok {
-			s.eventIdsByDate[dateStr] = make(map[string]struct{})
-		}
+	event, ok := s.events[id]
+	if !ok {
+		return internalStorage.ErrEventNotFound
+	}
 
-		s.eventIdsByDate[dateStr][event.ID] = struct{}{}
+	attendee, ok := event.Attendee(userID)
+	if !ok {
+		return internalStorage.ErrNotInvited
 	}
 
-	s.events[event.ID] = event
+	if attendee.Status == internalStorage.RSVPDeclined && status != internalStorage.RSVPDeclined &&
+		s.isBusy(event, []string{userID}) {
+		return internalStorage.ErrDateBusy
+	}
 
-	return nil
+	attendees := make([]internalStorage.Attendee, 0, len(event.Attendees))
+	for _, a := range event.Attendees {
+		if a.UserID == userID {
+			a.Status = status
+		}
+		attendees = append(attendees, a)
+	}
+	event.Attendees = attendees
+	event.Version++
+
+	return s.put(event)
 }
 
-func (s *storage) DeleteEvent(_ context.Context, id string) error {
+// RestoreEvent возвращает событие из корзины, если его время не занято.
+func (s *storage) RestoreEvent(_ context.Context, userID string, id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	eventForDelete, ok := s.events[id]
+	event, ok := s.deleted[id]
 	if !ok {
 		return internalStorage.ErrEventNotFound
 	}
 
-	year, month, day := eventForDelete.StartAt.Date()
-	dateStr := fmt.Sprintf("%d-%d-%d", year, month, day)
-	delete(s.eventIdsByDate[dateStr], id)
-	delete(s.events, id)
+	if event.AuthorID != userID {
+		return internalStorage.ErrAccessDenied
+	}
 
-	return nil
+	if s.isDateBusy(event) {
+		return internalStorage.ErrDateBusy
+	}
+
+	if err := s.checkResources(event); err != nil {
+		return err
+	}
+
+	event.DeletedAt = time.Time{}
+	event.Version++
+
+	return s.put(event)
 }
 
-func (s *storage) EventsDay(ctx context.Context, date time.Time) ([]internalStorage.Event, error) {
+// ListDeleted возвращает события из корзины, последние удаленные - первыми.
+func (s *storage) ListDeleted(_ context.Context, userID string) ([]internalStorage.Event, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return s.eventsByDates(ctx, date, date.AddDate(0, 0, 1))
+	result := make([]internalStorage.Event, 0)
+	for _, event := range s.deleted {
+		if event.AuthorID == userID {
+			result = append(result, event)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].DeletedAt.After(result[j].DeletedAt)
+	})
+
+	return result, nil
 }
 
-func (s *storage) EventsWeek(ctx context.Context, date time.Time) ([]internalStorage.Event, error) {
+// PurgeDeleted окончательно удаляет события, перемещенные в корзину раньше before.
+func (s *storage) PurgeDeleted(_ context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0)
+	for id, event := range s.deleted {
+		if event.DeletedAt.Before(before) {
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := s.log(record{Op: opDelete, IDs: ids}); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		s.deleteEvent(id)
+	}
+
+	return nil
+}
+
+func (s *storage) GetEvent(_ context.Context, userID string, id string) (internalStorage.Event, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return s.eventsByDates(ctx, date, date.AddDate(0, 0, 7))
+	event, ok := s.events[id]
+	if !ok {
+		return internalStorage.Event{}, internalStorage.ErrEventNotFound
+	}
+
+	if !s.isVisible(event, userID) {
+		return internalStorage.Event{}, internalStorage.ErrAccessDenied
+	}
+
+	return event, nil
+}
+
+func (s *storage) EventsDay(ctx context.Context, userID string, date time.Time) ([]internalStorage.Event, error) {
+	from, to := internalStorage.DayRange(date)
+
+	return s.eventsByDates(ctx, userID, from, to)
+}
+
+func (s *storage) EventsWeek(ctx context.Context, userID string, date time.Time) ([]internalStorage.Event, error) {
+	from, to := internalStorage.WeekRange(date)
+
+	return s.eventsByDates(ctx, userID, from, to)
+}
+
+func (s *storage) EventsMonth(ctx context.Context, userID string, date time.Time) ([]internalStorage.Event, error) {
+	from, to := internalStorage.MonthRange(date)
+
+	return s.eventsByDates(ctx, userID, from, to)
 }
 
-func (s *storage) EventsMonth(ctx context.Context, date time.Time) ([]internalStorage.Event, error) {
+// ListEvents выбирает события по индексу дат, фильтры и курсор применяются
+// к отобранным событиям.
+func (s *storage) ListEvents(_ context.Context, filter internalStorage.ListFilter) (internalStorage.Page, error) {
+	cursor, err := internalStorage.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return internalStorage.Page{}, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return s.eventsByDates(ctx, date, date.AddDate(0, 1, 0))
+	if filter.UserID != "" {
+		filter.Calendars = s.readableCalendars(filter.UserID)
+	}
+	events := s.collect(filter.Start(cursor), filter.To, filter.Match)
+
+	return internalStorage.PageEvents(events, filter)
 }
 
+// eventsByDates возвращает события и повторения, пересекающиеся с интервалом
+// [from, to), собирая страницы ListEvents.
 func (s *storage) eventsByDates(
-	_ context.Context,
-	startDate time.Time,
-	endDate time.Time,
+	ctx context.Context,
+	userID string,
+	from time.Time,
+	to time.Time,
 ) ([]internalStorage.Event, error) {
-	days := endDate.Sub(startDate).Hours() / 24
+	filter := internalStorage.ListFilter{UserID: userID, From: from, To: to, Overlapping: true}
+
+	return internalStorage.CollectEvents(ctx, s.ListEvents, filter)
+}
 
+// collect возвращает подходящие события и повторения, пересекающиеся с
+// интервалом [from, to). Многодневное событие есть в индексе под каждой
+// датой, поэтому события, уже попавшие в выборку, пропускаются.
+func (s *storage) collect(
+	from time.Time,
+	to time.Time,
+	match func(internalStorage.Event) bool,
+) []internalStorage.Event {
 	result := make([]internalStorage.Event, 0)
-	for i := 0; i < int(days); i++ {
-		t := startDate.AddDate(0, 0, i)
+	seen := make(map[string]struct{})
+	for day := from.UTC().Truncate(24 * time.Hour); day.Before(to); day = day.AddDate(0, 0, 1) {
+		for id := range s.eventIdsByDate[day.Format(dateLayout)] {
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
 
-		year, month, day := t.Date()
-		dateStr := fmt.Sprintf("%d-%d-%d", year, month, day)
+			event := s.events[id]
+			if match(event) && internalStorage.Overlaps(event.StartAt, event.EndAt, from, to) {
+				result = append(result, event)
+			}
+		}
+	}
 
-		for k := range s.eventIdsByDate[dateStr] {
-			result = append(result, s.events[k])
+	for id := range s.recurringEventIds {
+		for _, o := range s.events[id].OccurrencesOverlapping(from, to) {
+			if match(o) {
+				result = append(result, o)
+			}
 		}
 	}
 
-	return result, nil
+	return result
 }
 
-func (s *storage) EventsForNotification(_ context.Context) ([]internalStorage.Event, error) {
-	res := make([]internalStorage.Event, 0)
+func (s *storage) RemindersForNotification(_ context.Context) ([]internalStorage.Reminder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	res := make([]internalStorage.Reminder, 0)
 	for _, event := range s.events {
-		if _, ok := s.sendingEvents[event.ID]; !ok && event.NotificationDate.Before(time.Now()) {
-			res = append(res, event)
-		}
+		res = append(res, event.DueReminders(s.notifiedUntil[event.ID], now)...)
 	}
 
 	return res, nil
 }
 
-func (s *storage) ClearNotificationDates(_ context.Context, ids []string) error {
-	for _, id := range ids {
-		s.sendingEvents[id] = struct{}{}
+func (s *storage) MarkRemindersSent(_ context.Context, reminders []internalStorage.Reminder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sent := make([]sentReminder, 0, len(reminders))
+	for _, r := range reminders {
+		sent = append(sent, sentReminder{ID: r.Event.ID, Offset: r.Offset, At: r.Date()})
 	}
 
+	if err := s.log(record{Op: opNotified, Reminders: sent}); err != nil {
+		return err
+	}
+	s.markNotified(sent)
+
 	return nil
 }
 
+// ClearOldEvents перемещает в корзину события, закончившиеся более года назад.
 func (s *storage) ClearOldEvents(_ context.Context) error {
-	dateClear := time.Now().AddDate(-1, 0, 0)
-	for dateStr, events := range s.eventIdsByDate {
-		t, err := time.Parse(dateLayout, dateStr)
-		if err != nil {
-			return err
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	dateClear := now.AddDate(-1, 0, 0)
+	for _, event := range s.events {
+		if event.IsInfinite() || !event.SeriesEnd().Before(dateClear) {
+			continue
 		}
-		if t.Before(dateClear) {
-			for id := range events {
-				delete(s.events, id)
-				delete(s.sendingEvents, id)
-			}
-			delete(s.eventIdsByDate, dateStr)
+
+		if err := s.trash(event, now); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// Connect восстанавливает состояние из снимка и журнала, если хранилище
+// создано через NewPersistent.
 func (s *storage) Connect(_ context.Context, _ string) error {
-	return nil
+	if s.path == "" {
+		return nil
+	}
+
+	return s.open()
 }
 
+// Close сбрасывает журнал на диск.
 func (s *storage) Close(_ context.Context) error {
+	if s.path == "" {
+		return nil
+	}
+
+	return s.close()
+}
+
+// put записывает в журнал и сохраняет событие.
+func (s *storage) put(event internalStorage.Event) error {
+	if err := s.log(record{Op: opPut, Event: &event}); err != nil {
+		return err
+	}
+	s.putEvent(event)
+
 	return nil
 }
 
-func (s *storage) isDateBusy(event internalStorage.Event) bool {
-	year, month, day := event.StartAt.Date()
-	dateStr := fmt.Sprintf("%d-%d-%d", year, month, day)
+// trash записывает в журнал и перемещает событие в корзину. Состояние
+// уведомлений сохраняется, чтобы восстановленное событие не отправилось повторно.
+func (s *storage) trash(event internalStorage.Event, at time.Time) error {
+	event.DeletedAt = at
+	event.Version++
+	if err := s.log(record{Op: opTrash, Event: &event}); err != nil {
+		return err
+	}
+	s.putDeleted(event)
 
-	eventStart := event.StartAt.Unix()
-	eventEnd := event.EndAt.Unix()
+	return nil
+}
 
-	for id := range s.eventIdsByDate[dateStr] {
-		t := s.events[id]
-		if t.EndAt.Unix() <= eventStart || t.StartAt.Unix() >= eventEnd || t.ID == event.ID {
-			continue
+// putEvent, putDeleted, deleteEvent и markNotified изменяют состояние и
+// используются как при обработке запросов, так и при воспроизведении журнала,
+// поэтому должны быть идемпотентны.
+func (s *storage) putEvent(event internalStorage.Event) {
+	// События из файлов, записанных до появления версий.
+	if event.Version == 0 {
+		event.Version = internalStorage.InitialVersion
+	}
+
+	if old, ok := s.events[event.ID]; ok {
+		s.removeFromIndex(old)
+	}
+
+	delete(s.deleted, event.ID)
+	s.events[event.ID] = event
+	// Напоминания с удаленными смещениями больше не отслеживаются.
+	for offset := range s.notifiedUntil[event.ID] {
+		if !slices.Contains(event.Reminders, offset) {
+			delete(s.notifiedUntil[event.ID], offset)
+		}
+	}
+	s.addToIndex(event)
+}
+
+func (s *storage) putDeleted(event internalStorage.Event) {
+	if old, ok := s.events[event.ID]; ok {
+		s.removeFromIndex(old)
+	}
+
+	delete(s.events, event.ID)
+	s.deleted[event.ID] = event
+}
+
+func (s *storage) deleteEvent(id string) {
+	if event, ok := s.events[id]; ok {
+		s.removeFromIndex(event)
+	}
+
+	delete(s.events, id)
+	delete(s.deleted, id)
+	delete(s.notifiedUntil, id)
+}
+
+// markNotified запоминает время срабатывания отправленных напоминаний. Время
+// не уменьшается, чтобы повторное применение журнала не вернуло напоминание.
+func (s *storage) markNotified(sent []sentReminder) {
+	for _, r := range sent {
+		if _, ok := s.notifiedUntil[r.ID]; !ok {
+			s.notifiedUntil[r.ID] = make(map[time.Duration]time.Time)
 		}
 
-		return true
+		if r.At.After(s.notifiedUntil[r.ID][r.Offset]) {
+			s.notifiedUntil[r.ID][r.Offset] = r.At
+		}
+	}
+}
+
+func (s *storage) addToIndex(event internalStorage.Event) {
+	s.addToSearchIndex(event)
+	if event.IsRecurring() {
+		s.recurringEventIds[event.ID] = struct{}{}
+		return
+	}
+
+	for _, dateStr := range indexDates(event) {
+		if _, ok := s.eventIdsByDate[dateStr]; !ok {
+			s.eventIdsByDate[dateStr] = make(map[string]struct{})
+		}
+
+		s.eventIdsByDate[dateStr][event.ID] = struct{}{}
+	}
+}
+
+func (s *storage) removeFromIndex(event internalStorage.Event) {
+	s.removeFromSearchIndex(event)
+	if event.IsRecurring() {
+		delete(s.recurringEventIds, event.ID)
+		return
+	}
+
+	for _, dateStr := range indexDates(event) {
+		delete(s.eventIdsByDate[dateStr], event.ID)
+		if len(s.eventIdsByDate[dateStr]) == 0 {
+			delete(s.eventIdsByDate, dateStr)
+		}
+	}
+}
+
+// indexDates возвращает даты UTC, с которыми пересекается событие: от даты
+// начала до даты окончания, не включая окончание ровно в полночь.
+func indexDates(event internalStorage.Event) []string {
+	last := event.EndAt.UTC().Add(-time.Nanosecond)
+	if last.Before(event.StartAt) {
+		last = event.StartAt.UTC()
+	}
+
+	dates := make([]string, 0, 1)
+	for day := event.StartAt.UTC().Truncate(24 * time.Hour); !day.After(last); day = day.AddDate(0, 0, 1) {
+		dates = append(dates, day.Format(dateLayout))
+	}
+
+	return dates
+}
+
+// isDateBusy ищет пересечения среди событий, занимающих время участников события.
+func (s *storage) isDateBusy(event internalStorage.Event) bool {
+	return s.isBusy(event, event.Participants())
+}
+
+// isBusy проверяет, что событие пересекается с другими событиями пользователей users.
+// Время автора проверяется в календаре события, остальных участников - во всех
+// календарях. Повторения не попадают в индекс дат, поэтому перебираются все события.
+func (s *storage) isBusy(event internalStorage.Event, users []string) bool {
+	for _, t := range s.events {
+		for _, userID := range users {
+			if userID == event.AuthorID && t.CalendarID != event.CalendarID {
+				continue
+			}
+
+			if t.IsBusyFor(userID) && internalStorage.Conflicts(event, t) {
+				return true
+			}
+		}
 	}
 
 	return false
@@ -3,10 +3,14 @@ package memorystorage
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
 	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage/storagetest"
 	"github.com/stretchr/testify/require"
 )
 
@@ -64,12 +68,12 @@ func TestStorage(t *testing.T) {
 		)
 	})
 	t.Run("get events by day", func(t *testing.T) {
-		events, err := s.EventsDay(ctx, startDateTime1)
+		events, err := s.EventsDay(ctx, "1", startDateTime1)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(events))
 	})
 	t.Run("get events by week", func(t *testing.T) {
-		events, err := s.EventsWeek(ctx, startDateTime1)
+		events, err := s.EventsWeek(ctx, "1", startDateTime1)
 		require.NoError(t, err)
 		require.Equal(t, 2, len(events))
 	})
@@ -84,9 +88,10 @@ func TestStorage(t *testing.T) {
 		}
 		err := s.UpdateEvent(ctx, event)
 		require.NoError(t, err)
-		events, err := s.EventsDay(ctx, startDateTime1)
+		events, err := s.EventsDay(ctx, "1", startDateTime1)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(events))
+		event.Version = internalStorage.InitialVersion + 1
 		require.Equal(t, events[0], event)
 	})
 	t.Run("update event date time without error", func(t *testing.T) {
@@ -101,9 +106,10 @@ func TestStorage(t *testing.T) {
 		err := s.UpdateEvent(ctx, event)
 		require.NoError(t, err)
 
-		events, err := s.EventsDay(ctx, startDateTime4)
+		events, err := s.EventsDay(ctx, "1", startDateTime4)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(events))
+		event.Version = internalStorage.InitialVersion + 2
 		require.Equal(t, events[0], event)
 	})
 	t.Run("update not found event", func(t *testing.T) {
@@ -144,15 +150,15 @@ func TestStorage(t *testing.T) {
 	})
 	t.Run("delete event", func(t *testing.T) {
 		idForDelete := "2"
-		err := s.DeleteEvent(ctx, idForDelete)
+		err := s.DeleteEvent(ctx, "1", idForDelete, 0)
 		require.NoError(t, err)
-		events, err := s.EventsWeek(ctx, startDateTime1)
+		events, err := s.EventsWeek(ctx, "1", startDateTime1)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(events))
 		require.NotEqual(t, events[0].ID, idForDelete)
 	})
 	t.Run("delete not found event", func(t *testing.T) {
-		err := s.DeleteEvent(ctx, "2")
+		err := s.DeleteEvent(ctx, "1", "2", 0)
 		require.Truef(
 			t,
 			errors.Is(err, internalStorage.ErrEventNotFound),
@@ -163,6 +169,69 @@ func TestStorage(t *testing.T) {
 	})
 }
 
+func TestUserIsolation(t *testing.T) {
+	ctx := context.Background()
+	startDateTime, err := time.Parse(time.RFC3339, "2023-06-01T21:00:00+03:00")
+	require.NoError(t, err)
+	event := internalStorage.Event{
+		ID:       "1",
+		Title:    "test",
+		StartAt:  startDateTime,
+		EndAt:    startDateTime.Add(time.Hour),
+		AuthorID: "1",
+	}
+
+	s := New()
+	require.NoError(t, s.CreateEvent(ctx, event))
+
+	t.Run("same time for another user", func(t *testing.T) {
+		other := event
+		other.ID = "2"
+		other.AuthorID = "2"
+		require.NoError(t, s.CreateEvent(ctx, other))
+	})
+	t.Run("listings are scoped by user", func(t *testing.T) {
+		for _, userID := range []string{"1", "2"} {
+			events, err := s.EventsDay(ctx, userID, startDateTime)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(events))
+			require.Equal(t, userID, events[0].AuthorID)
+		}
+
+		events, err := s.EventsMonth(ctx, "3", startDateTime)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(events))
+	})
+	t.Run("update event of another user", func(t *testing.T) {
+		other := event
+		other.AuthorID = "2"
+		other.Title = "stolen"
+		err := s.UpdateEvent(ctx, other)
+		require.Truef(
+			t,
+			errors.Is(err, internalStorage.ErrAccessDenied),
+			"actual error %q, excepted %q",
+			err,
+			internalStorage.ErrAccessDenied,
+		)
+	})
+	t.Run("delete event of another user", func(t *testing.T) {
+		err := s.DeleteEvent(ctx, "2", event.ID, 0)
+		require.Truef(
+			t,
+			errors.Is(err, internalStorage.ErrAccessDenied),
+			"actual error %q, excepted %q",
+			err,
+			internalStorage.ErrAccessDenied,
+		)
+
+		events, err := s.EventsDay(ctx, "1", startDateTime)
+		require.NoError(t, err)
+		event.Version = internalStorage.InitialVersion
+		require.Equal(t, []internalStorage.Event{event}, events)
+	})
+}
+
 func TestNotification(t *testing.T) {
 	ctx := context.Background()
 	startDateTime1, err := time.Parse(time.RFC3339, "2023-06-01T21:00:00+03:00")
@@ -171,22 +240,22 @@ func TestNotification(t *testing.T) {
 	require.NoError(t, err)
 	events := []internalStorage.Event{
 		{
-			ID:               "1",
-			Title:            "test",
-			Description:      "test description",
-			StartAt:          startDateTime1,
-			EndAt:            startDateTime1.Add(1 * time.Hour),
-			AuthorID:         "1",
-			NotificationDate: startDateTime1,
+			ID:          "1",
+			Title:       "test",
+			Description: "test description",
+			StartAt:     startDateTime1,
+			EndAt:       startDateTime1.Add(1 * time.Hour),
+			AuthorID:    "1",
+			Reminders:   []time.Duration{0},
 		},
 		{
-			ID:               "2",
-			Title:            "test",
-			Description:      "test description",
-			StartAt:          startDateTime2,
-			EndAt:            startDateTime2.Add(1 * time.Hour),
-			AuthorID:         "1",
-			NotificationDate: startDateTime2,
+			ID:          "2",
+			Title:       "test",
+			Description: "test description",
+			StartAt:     startDateTime2,
+			EndAt:       startDateTime2.Add(1 * time.Hour),
+			AuthorID:    "1",
+			Reminders:   []time.Duration{0},
 		},
 	}
 
@@ -198,20 +267,268 @@ func TestNotification(t *testing.T) {
 		}
 	})
 	t.Run("check events for notification", func(t *testing.T) {
-		events, err := s.EventsForNotification(ctx)
+		reminders, err := s.RemindersForNotification(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(reminders))
+	})
+	t.Run("mark reminders sent", func(t *testing.T) {
+		reminders, err := s.RemindersForNotification(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(reminders))
+		require.Equal(t, "1", reminders[0].Event.ID)
+		err = s.MarkRemindersSent(ctx, reminders)
+		require.NoError(t, err)
+		reminders, err = s.RemindersForNotification(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(reminders))
+	})
+}
+
+func TestRecurringEvents(t *testing.T) {
+	ctx := context.Background()
+	startDateTime, err := time.Parse(time.RFC3339, "2023-06-01T10:00:00+03:00")
+	require.NoError(t, err)
+	recurrence, err := internalStorage.ParseRecurrence("FREQ=WEEKLY;BYDAY=MO,TH;COUNT=4")
+	require.NoError(t, err)
+
+	s := New()
+	t.Run("create recurring event", func(t *testing.T) {
+		err := s.CreateEvent(ctx, internalStorage.Event{
+			ID:          "1",
+			Title:       "standup",
+			Description: "test description",
+			StartAt:     startDateTime,
+			EndAt:       startDateTime.Add(15 * time.Minute),
+			AuthorID:    "1",
+			Recurrence:  recurrence,
+		})
+		require.NoError(t, err)
+	})
+	t.Run("occurrences in listings", func(t *testing.T) {
+		events, err := s.EventsWeek(ctx, "1", startDateTime)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(events))
+
+		events, err = s.EventsMonth(ctx, "1", startDateTime)
+		require.NoError(t, err)
+		require.Equal(t, 4, len(events))
+
+		events, err = s.EventsDay(ctx, "1", startDateTime.AddDate(0, 0, 11))
 		require.NoError(t, err)
 		require.Equal(t, 1, len(events))
+		require.Equal(t, "1", events[0].ID)
+		require.Equal(t, startDateTime.AddDate(0, 0, 11), events[0].StartAt)
 	})
-	t.Run("clear old notification", func(t *testing.T) {
-		var ids []string
-		events, err := s.EventsForNotification(ctx)
+	t.Run("occurrence conflicts", func(t *testing.T) {
+		start := startDateTime.AddDate(0, 0, 4).Add(10 * time.Minute)
+		err := s.CreateEvent(ctx, internalStorage.Event{
+			ID:       "2",
+			Title:    "test",
+			StartAt:  start,
+			EndAt:    start.Add(time.Hour),
+			AuthorID: "1",
+		})
+		require.Truef(
+			t,
+			errors.Is(err, internalStorage.ErrDateBusy),
+			"actual error %q, excepted %q",
+			err,
+			internalStorage.ErrDateBusy,
+		)
+	})
+	t.Run("after last occurrence", func(t *testing.T) {
+		start := startDateTime.AddDate(0, 0, 14)
+		err := s.CreateEvent(ctx, internalStorage.Event{
+			ID:       "3",
+			Title:    "test",
+			StartAt:  start,
+			EndAt:    start.Add(time.Hour),
+			AuthorID: "1",
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestRecurringNotification(t *testing.T) {
+	ctx := context.Background()
+	startDateTime := time.Now().AddDate(0, 0, -3).Truncate(time.Minute)
+
+	s := New()
+	err := s.CreateEvent(ctx, internalStorage.Event{
+		ID:         "1",
+		Title:      "daily",
+		StartAt:    startDateTime,
+		EndAt:      startDateTime.Add(time.Hour),
+		AuthorID:   "1",
+		Reminders:  []time.Duration{time.Hour},
+		Recurrence: &internalStorage.Recurrence{Frequency: internalStorage.FrequencyDaily, Interval: 1},
+	})
+	require.NoError(t, err)
+
+	reminders, err := s.RemindersForNotification(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(reminders))
+	require.True(t, reminders[0].Event.StartAt.After(time.Now().Add(-24*time.Hour)))
+	require.Equal(t, reminders[0].Event.StartAt.Add(-time.Hour), reminders[0].Date())
+
+	err = s.MarkRemindersSent(ctx, reminders)
+	require.NoError(t, err)
+
+	reminders, err = s.RemindersForNotification(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(reminders))
+}
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, New)
+}
+
+func TestPersistence(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	startDateTime, err := time.Parse(time.RFC3339, "2023-06-01T10:00:00+03:00")
+	require.NoError(t, err)
+
+	newEvent := func(id string, start time.Time) internalStorage.Event {
+		return internalStorage.Event{
+			ID:        id,
+			Title:     "test",
+			StartAt:   start,
+			EndAt:     start.Add(time.Hour),
+			AuthorID:  "1",
+			Reminders: []time.Duration{time.Hour},
+		}
+	}
+
+	open := func() internalStorage.Storage {
+		s := NewPersistent(dir, 0)
+		require.NoError(t, s.Connect(ctx, ""))
+
+		return s
+	}
+
+	s := open()
+	require.NoError(t, s.CreateEvent(ctx, newEvent("1", startDateTime)))
+	require.NoError(t, s.CreateEvent(ctx, newEvent("2", startDateTime.AddDate(0, 0, 1))))
+	require.NoError(t, s.CreateEvent(ctx, newEvent("3", startDateTime.AddDate(0, 0, 2))))
+
+	updated := newEvent("2", startDateTime.AddDate(0, 0, 3))
+	updated.Title = "test with update"
+	require.NoError(t, s.UpdateEvent(ctx, updated))
+	require.NoError(t, s.DeleteEvent(ctx, "1", "3", 0))
+	require.NoError(t, s.MarkRemindersSent(ctx, []internalStorage.Reminder{
+		{Event: newEvent("1", startDateTime), Offset: time.Hour},
+	}))
+	calendar := internalStorage.Calendar{ID: "c1", Name: "test", OwnerID: "1"}
+	require.NoError(t, s.CreateCalendar(ctx, calendar))
+	share := internalStorage.CalendarShare{UserID: "2", Permission: internalStorage.PermissionRead}
+	require.NoError(t, s.ShareCalendar(ctx, "1", calendar.ID, share))
+	resource := internalStorage.Resource{ID: "r1", Name: "room", Kind: internalStorage.ResourceRoom, OwnerID: "1"}
+	require.NoError(t, s.CreateResource(ctx, resource))
+	require.NoError(t, s.Close(ctx))
+
+	check := func(t *testing.T, s internalStorage.Storage) {
+		t.Helper()
+
+		events, err := s.EventsMonth(ctx, "1", startDateTime)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(events))
+
+		events, err = s.EventsDay(ctx, "1", updated.StartAt)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(events))
-		ids = append(ids, events[0].ID)
-		err = s.ClearNotificationDates(ctx, ids)
+		require.Equal(t, updated.Title, events[0].Title)
+		require.True(t, updated.StartAt.Equal(events[0].StartAt))
+
+		reminders, err := s.RemindersForNotification(ctx)
 		require.NoError(t, err)
-		events, err = s.EventsForNotification(ctx)
+		require.Equal(t, 1, len(reminders))
+		require.Equal(t, "2", reminders[0].Event.ID)
+
+		deleted, err := s.ListDeleted(ctx, "1")
 		require.NoError(t, err)
-		require.Equal(t, 0, len(events))
+		require.Equal(t, 1, len(deleted))
+		require.Equal(t, "3", deleted[0].ID)
+		require.False(t, deleted[0].DeletedAt.IsZero())
+
+		actual, err := s.GetCalendar(ctx, "2", calendar.ID)
+		require.NoError(t, err)
+		require.Equal(t, []internalStorage.CalendarShare{share}, actual.Shares)
+
+		actualResource, err := s.GetResource(ctx, resource.ID)
+		require.NoError(t, err)
+		require.Equal(t, resource, actualResource)
+	}
+
+	t.Run("replay log", func(t *testing.T) {
+		// Снимок пишется при подключении, поэтому берем состояние до сжатия.
+		ps := New().(*storage)
+		ps.path = dir
+		wal, err := os.Open(filepath.Join(dir, walFile))
+		require.NoError(t, err)
+		defer wal.Close()
+		require.NoError(t, ps.replay(wal))
+		check(t, ps)
+	})
+	t.Run("reopen", func(t *testing.T) {
+		s := open()
+		check(t, s)
+		require.NoError(t, s.Close(ctx))
+	})
+	t.Run("truncated record", func(t *testing.T) {
+		s := open()
+		require.NoError(t, s.CreateEvent(ctx, newEvent("4", startDateTime.AddDate(0, 0, 5))))
+		require.NoError(t, s.Close(ctx))
+
+		f, err := os.OpenFile(filepath.Join(dir, walFile), os.O_WRONLY|os.O_APPEND, 0o644)
+		require.NoError(t, err)
+		_, err = f.WriteString(`{"op":"delete","ids":["4"`)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		s = open()
+		events, err := s.EventsDay(ctx, "1", startDateTime.AddDate(0, 0, 5))
+		require.NoError(t, err)
+		require.Equal(t, 1, len(events))
+		require.NoError(t, s.Close(ctx))
+	})
+	t.Run("corrupted log", func(t *testing.T) {
+		err := os.WriteFile(filepath.Join(dir, walFile), []byte("invalid\n"), 0o644)
+		require.NoError(t, err)
+
+		err = NewPersistent(dir, 0).Connect(ctx, "")
+		require.Truef(t, errors.Is(err, ErrCorruptedLog), "actual error %q", err)
+	})
+}
+
+func TestPersistentConformance(t *testing.T) {
+	dir := t.TempDir()
+	n := 0
+	storagetest.Run(t, func() internalStorage.Storage {
+		n++
+		s := NewPersistent(filepath.Join(dir, strconv.Itoa(n)), time.Millisecond)
+		if err := s.Connect(context.Background(), ""); err != nil {
+			panic(err)
+		}
+		t.Cleanup(func() {
+			_ = s.Close(context.Background())
+		})
+
+		return s
+	})
+}
+
+func TestAuditRing(t *testing.T) {
+	r := auditRing{}
+	for i := 0; i < auditCapacity+2; i++ {
+		r.add(internalStorage.AuditRecord{EventID: strconv.Itoa(i % 2)})
+	}
+
+	ids := make([]int64, 0, auditCapacity)
+	r.each(func(record internalStorage.AuditRecord) {
+		ids = append(ids, record.ID)
 	})
+	require.Equal(t, auditCapacity, len(ids))
+	require.Equal(t, int64(3), ids[0])
+	require.Equal(t, int64(auditCapacity+2), ids[len(ids)-1])
 }
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrInvalidWeekday = errors.New("невалидный день недели")
+
+// DayRange возвращает полуинтервал [from, to) суток, содержащих date,
+// в часовом поясе date.
+func DayRange(date time.Time) (time.Time, time.Time) {
+	from := startOfDay(date)
+
+	return from, from.AddDate(0, 0, 1)
+}
+
+// WeekRange возвращает полуинтервал из 7 суток, начиная с суток date.
+func WeekRange(date time.Time) (time.Time, time.Time) {
+	from := startOfDay(date)
+
+	return from, from.AddDate(0, 0, 7)
+}
+
+// MonthRange возвращает полуинтервал в один месяц, начиная с суток date.
+func MonthRange(date time.Time) (time.Time, time.Time) {
+	from := startOfDay(date)
+
+	return from, from.AddDate(0, 1, 0)
+}
+
+// StartOfWeek возвращает начало календарной недели, содержащей date, если
+// неделя начинается с first. Для ISO-недели first равен time.Monday.
+func StartOfWeek(date time.Time, first time.Weekday) time.Time {
+	offset := (int(date.Weekday()) - int(first) + 7) % 7
+
+	return startOfDay(date).AddDate(0, 0, -offset)
+}
+
+// StartOfMonth возвращает начало календарного месяца, содержащего date.
+func StartOfMonth(date time.Time) time.Time {
+	year, month, _ := date.Date()
+
+	return time.Date(year, month, 1, 0, 0, 0, 0, date.Location())
+}
+
+func startOfDay(date time.Time) time.Time {
+	year, month, day := date.Date()
+
+	return time.Date(year, month, day, 0, 0, 0, 0, date.Location())
+}
+
+// ParseWeekday разбирает день недели из двухбуквенного кода RFC 5545 (MO)
+// или английского названия (monday) без учета регистра.
+func ParseWeekday(value string) (time.Weekday, error) {
+	if day, ok := weekdays[strings.ToUpper(value)]; ok {
+		return day, nil
+	}
+
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), value) {
+			return day, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %q", ErrInvalidWeekday, value)
+}
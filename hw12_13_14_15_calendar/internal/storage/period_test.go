@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarPeriods(t *testing.T) {
+	moscow, err := LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	// Четверг.
+	date := time.Date(2024, 2, 29, 15, 30, 0, 0, moscow)
+
+	tests := []struct {
+		first    time.Weekday
+		excepted time.Time
+	}{
+		{time.Monday, time.Date(2024, 2, 26, 0, 0, 0, 0, moscow)},
+		{time.Sunday, time.Date(2024, 2, 25, 0, 0, 0, 0, moscow)},
+		{time.Thursday, time.Date(2024, 2, 29, 0, 0, 0, 0, moscow)},
+		{time.Friday, time.Date(2024, 2, 23, 0, 0, 0, 0, moscow)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.first.String(), func(t *testing.T) {
+			require.Equal(t, tc.excepted, StartOfWeek(date, tc.first))
+		})
+	}
+
+	from, to := MonthRange(StartOfMonth(date))
+	require.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, moscow), from)
+	require.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, moscow), to)
+}
+
+func TestParseWeekday(t *testing.T) {
+	for value, excepted := range map[string]time.Weekday{"MO": time.Monday, "su": time.Sunday, "Saturday": time.Saturday} {
+		day, err := ParseWeekday(value)
+		require.NoError(t, err)
+		require.Equal(t, excepted, day)
+	}
+
+	_, err := ParseWeekday("mon")
+	require.True(t, errors.Is(err, ErrInvalidWeekday))
+}
@@ -0,0 +1,80 @@
+package sqlstorage
+
+import (
+	"context"
+
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *storage) AddAuditRecord(ctx context.Context, record internalStorage.AuditRecord) error {
+	before, err := internalStorage.MarshalSnapshot(record.Before)
+	if err != nil {
+		return err
+	}
+
+	after, err := internalStorage.MarshalSnapshot(record.After)
+	if err != nil {
+		return err
+	}
+
+	sql := `INSERT INTO event_history (event_id, action, actor_id, created_at, before, after) 
+	VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err = s.pool.Exec(
+		ctx,
+		sql,
+		record.EventID,
+		string(record.Action),
+		record.ActorID,
+		record.At.UTC(),
+		before,
+		after,
+	)
+
+	return err
+}
+
+func (s *storage) EventHistory(ctx context.Context, eventID string) ([]internalStorage.AuditRecord, error) {
+	sql := `SELECT id, event_id, action, actor_id, created_at, before, after 
+	FROM event_history 
+	WHERE event_id = $1 
+	ORDER BY id`
+
+	rows, err := s.pool.Query(ctx, sql, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]internalStorage.AuditRecord, 0)
+	for rows.Next() {
+		var (
+			record        internalStorage.AuditRecord
+			action        string
+			before, after []byte
+		)
+		if err := rows.Scan(
+			&record.ID,
+			&record.EventID,
+			&action,
+			&record.ActorID,
+			&record.At,
+			&before,
+			&after,
+		); err != nil {
+			return nil, err
+		}
+
+		record.Action = internalStorage.AuditAction(action)
+		if record.Before, err = internalStorage.UnmarshalSnapshot(before); err != nil {
+			return nil, err
+		}
+		if record.After, err = internalStorage.UnmarshalSnapshot(after); err != nil {
+			return nil, err
+		}
+
+		result = append(result, record)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,30 @@
+package sqlstorage
+
+import (
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+	_ "github.com/lib/pq" // Драйвер postgres для goose.
+	"github.com/pressly/goose"
+)
+
+const migrationPath = "./migrations/"
+
+func init() {
+	internalStorage.Register(Type, internalStorage.Backend{
+		New: func(opts internalStorage.Options) internalStorage.Storage {
+			return New(opts.PoolSize)
+		},
+		Migrate:       Migrate,
+		MigrationPath: migrationPath,
+	})
+}
+
+// Migrate применяет миграции goose из каталога dir.
+func Migrate(dsn string, dir string) error {
+	db, err := goose.OpenDBWithDriver("postgres", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return goose.Up(db, dir)
+}
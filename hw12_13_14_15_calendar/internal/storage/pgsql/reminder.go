@@ -0,0 +1,182 @@
+package sqlstorage
+
+import (
+	"context"
+	"time"
+
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+// remindAt время срабатывания напоминания из event_reminders в SQL, смещение хранится в наносекундах.
+const remindAt = `start_at - r.remind_before / 1000 * interval '1 microsecond'`
+
+// RemindersForNotification возвращает напоминания, время срабатывания которых
+// наступило. Повторения разворачиваются в Go, для обычных событий время
+// срабатывания проверяется в запросе.
+func (s *storage) RemindersForNotification(ctx context.Context) ([]internalStorage.Reminder, error) {
+	now := time.Now()
+	sql := `SELECT ` + eventColumns + `, r.remind_before, r.notified_until
+	FROM events
+	JOIN event_reminders r ON r.event_id = events.id
+	WHERE deleted_at IS NULL AND (
+	    (rrule IS NULL AND ` + remindAt + ` <= $1
+	        AND (r.notified_until IS NULL OR r.notified_until < ` + remindAt + `))
+	    OR (rrule IS NOT NULL
+	        AND (recurrence_end IS NULL OR r.notified_until IS NULL OR recurrence_end > r.notified_until))
+	)`
+
+	rows, err := s.pool.Query(ctx, sql, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]internalStorage.Reminder, 0)
+	for rows.Next() {
+		var (
+			offset        int64
+			notifiedUntil *time.Time
+		)
+		event, err := scanEvent(rows, &offset, &notifiedUntil)
+		if err != nil {
+			return nil, err
+		}
+
+		after := time.Time{}
+		if notifiedUntil != nil {
+			after = *notifiedUntil
+		}
+
+		if r, ok := event.DueReminder(time.Duration(offset), after, now); ok {
+			result = append(result, r)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, loadReminderEvents(ctx, s.pool, result)
+}
+
+// MarkRemindersSent запоминает время срабатывания отправленных напоминаний.
+func (s *storage) MarkRemindersSent(ctx context.Context, reminders []internalStorage.Reminder) error {
+	if len(reminders) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(reminders))
+	offsets := make([]int64, 0, len(reminders))
+	dates := make([]time.Time, 0, len(reminders))
+	for _, r := range reminders {
+		ids = append(ids, r.Event.ID)
+		offsets = append(offsets, int64(r.Offset))
+		dates = append(dates, r.Date().UTC())
+	}
+
+	sql := `UPDATE event_reminders r SET notified_until = v.at
+	FROM unnest($1::uuid[], $2::bigint[], $3::timestamptz[]) AS v(event_id, remind_before, at)
+	WHERE r.event_id = v.event_id AND r.remind_before = v.remind_before
+	  AND (r.notified_until IS NULL OR r.notified_until < v.at)`
+
+	_, err := s.pool.Exec(ctx, sql, ids, offsets, dates)
+
+	return err
+}
+
+// saveReminders приводит напоминания события к event.Reminders. Отметки об
+// отправке сохраняются для смещений, которые остались у события.
+func saveReminders(ctx context.Context, q querier, event internalStorage.Event) error {
+	offsets := make([]int64, 0, len(event.Reminders))
+	for _, offset := range event.Reminders {
+		offsets = append(offsets, int64(offset))
+	}
+
+	sql := `DELETE FROM event_reminders WHERE event_id = $1 AND NOT (remind_before = ANY($2))`
+	if _, err := q.Exec(ctx, sql, event.ID, offsets); err != nil {
+		return err
+	}
+
+	sql = `INSERT INTO event_reminders (event_id, remind_before)
+	SELECT $1, unnest($2::bigint[])
+	ON CONFLICT (event_id, remind_before) DO NOTHING`
+	_, err := q.Exec(ctx, sql, event.ID, offsets)
+
+	return err
+}
+
+// loadRelated заполняет участников, напоминания и теги событий.
+func loadRelated(ctx context.Context, q querier, events []internalStorage.Event) error {
+	if err := loadAttendees(ctx, q, events); err != nil {
+		return err
+	}
+
+	if err := loadReminders(ctx, q, events); err != nil {
+		return err
+	}
+
+	if err := loadTags(ctx, q, events); err != nil {
+		return err
+	}
+
+	return loadResources(ctx, q, events)
+}
+
+// loadReminderEvents заполняет связанные данные событий из напоминаний.
+func loadReminderEvents(ctx context.Context, q querier, reminders []internalStorage.Reminder) error {
+	events := make([]internalStorage.Event, 0, len(reminders))
+	for _, r := range reminders {
+		events = append(events, r.Event)
+	}
+
+	if err := loadRelated(ctx, q, events); err != nil {
+		return err
+	}
+
+	for i := range reminders {
+		reminders[i].Event = events[i]
+	}
+
+	return nil
+}
+
+// loadReminders заполняет смещения напоминаний событий одним запросом.
+func loadReminders(ctx context.Context, q querier, events []internalStorage.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+
+	sql := `SELECT event_id, remind_before
+	FROM event_reminders
+	WHERE event_id = ANY($1)
+	ORDER BY event_id, remind_before DESC`
+
+	rows, err := q.Query(ctx, sql, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	reminders := make(map[string][]time.Duration)
+	for rows.Next() {
+		var (
+			eventID string
+			offset  int64
+		)
+		if err := rows.Scan(&eventID, &offset); err != nil {
+			return err
+		}
+
+		reminders[eventID] = append(reminders[eventID], time.Duration(offset))
+	}
+
+	for i := range events {
+		events[i].Reminders = reminders[events[i].ID]
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,76 @@
+package sqlstorage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+// SearchEvents ищет события по индексу search_vector и упорядочивает их по
+// ts_rank, совпадения в названии весят больше совпадений в описании.
+func (s *storage) SearchEvents(
+	ctx context.Context,
+	filter internalStorage.SearchFilter,
+) ([]internalStorage.SearchResult, error) {
+	terms := internalStorage.SearchTerms(filter.Query)
+	if len(terms) == 0 {
+		return nil, internalStorage.ErrInvalidQuery
+	}
+
+	args := []any{strings.Join(terms, " ")}
+	where := `search_vector @@ query AND deleted_at IS NULL`
+	if filter.UserID != "" {
+		args = append(args, filter.UserID)
+		where += ` AND ` + visibleTo(len(args))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To.UTC())
+		where += fmt.Sprintf(` AND start_at < $%d`, len(args))
+	}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From.UTC())
+		where += fmt.Sprintf(` AND (
+		    (rrule IS NULL AND end_at >= $%d) 
+		    OR (rrule IS NOT NULL AND (recurrence_end IS NULL OR recurrence_end >= $%d))
+		)`, len(args), len(args))
+	}
+
+	sql := `SELECT ` + eventColumns + `, ts_rank(search_vector, query)::float8 AS rank 
+	FROM events, plainto_tsquery('simple', $1) query 
+	WHERE ` + where + fmt.Sprintf(` ORDER BY rank DESC, start_at DESC, id LIMIT %d`, filter.PageLimit())
+
+	rows, err := s.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]internalStorage.SearchResult, 0)
+	events := make([]internalStorage.Event, 0)
+	for rows.Next() {
+		var rank float64
+		event, err := scanEvent(rows, &rank)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+		result = append(result, internalStorage.SearchResult{Rank: rank})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := loadRelated(ctx, s.pool, events); err != nil {
+		return nil, err
+	}
+
+	for i := range result {
+		result[i].Event = events[i]
+	}
+
+	return result, nil
+}
@@ -3,38 +3,86 @@ package sqlstorage
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-const Type string = "pgsql"
+const (
+	Type string = "pgsql"
+
+	eventColumns = `id, title, start_at, end_at, description, author_id, rrule, exdates, version, deleted_at, time_zone,
+	calendar_id, color, location, latitude, longitude, meeting_url, all_day`
+)
+
+// likeEscaper экранирует спецсимволы шаблона LIKE, экранирующий символ по умолчанию - обратная косая черта.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+const (
+	// Максимальное число повторов транзакции при конфликте сериализации.
+	maxTxRetries = 5
+
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+)
+
+// querier общий интерфейс пула соединений и транзакции.
+type querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
 
 type storage struct {
-	conn *pgx.Conn
+	pool     *pgxpool.Pool
+	maxConns int32
 }
 
-func New() internalStorage.Storage {
-	return &storage{}
+// New создает хранилище, maxConns ограничивает размер пула соединений (0 - значение по умолчанию pgxpool).
+func New(maxConns int32) internalStorage.Storage {
+	return &storage{maxConns: maxConns}
 }
 
 func (s *storage) Connect(ctx context.Context, dsn string) error {
-	conn, err := pgx.Connect(ctx, dsn)
+	config, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return err
 	}
-	s.conn = conn
+
+	if s.maxConns > 0 {
+		config.MaxConns = s.maxConns
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return err
+	}
+	s.pool = pool
 
 	return nil
 }
 
-func (s *storage) Close(ctx context.Context) error {
-	return s.conn.Close(ctx)
+func (s *storage) Close(_ context.Context) error {
+	s.pool.Close()
+
+	return nil
 }
 
+// CreateEvent проверяет занятость времени и добавляет событие в одной транзакции SERIALIZABLE,
+// поэтому параллельные запросы не могут занять одно и то же время.
 func (s *storage) CreateEvent(ctx context.Context, event internalStorage.Event) error {
-	isBusy, err := s.isDateBusy(ctx, event)
+	return s.inSerializableTx(ctx, func(tx pgx.Tx) error {
+		return s.createEvent(ctx, tx, event)
+	})
+}
+
+func (s *storage) createEvent(ctx context.Context, q querier, event internalStorage.Event) error {
+	isBusy, err := s.isDateBusy(ctx, q, event)
 	if err != nil {
 		return err
 	}
@@ -43,35 +91,73 @@ func (s *storage) CreateEvent(ctx context.Context, event internalStorage.Event)
 		return internalStorage.ErrDateBusy
 	}
 
+	if err := s.checkResources(ctx, q, event); err != nil {
+		return err
+	}
+
 	sql := `INSERT INTO events 
-    (id, title, start_at, end_at, description, author_id, notification_date) 
-	VALUES ($1, $2, $3, $4, $5, $6, $7)`
+    (id, title, start_at, end_at, description, author_id, rrule, exdates, recurrence_end, version, time_zone, 
+     calendar_id, color, location, latitude, longitude, meeting_url, all_day) 
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`
 
-	_, err = s.conn.Exec(
+	rrule, exdates, recurrenceEnd := recurrenceArgs(event)
+	latitude, longitude := coordinatesArgs(event)
+	_, err = q.Exec(
 		ctx,
 		sql,
 		event.ID,
 		event.Title,
-		event.StartAt,
-		event.EndAt,
+		event.StartAt.UTC(),
+		event.EndAt.UTC(),
 		event.Description,
 		event.AuthorID,
-		event.NotificationDate,
+		rrule,
+		exdates,
+		recurrenceEnd,
+		internalStorage.InitialVersion,
+		event.TimeZone,
+		calendarArg(event),
+		event.Color,
+		event.Place.Text,
+		latitude,
+		longitude,
+		event.MeetingURL,
+		event.AllDay,
 	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	if err := saveReminders(ctx, q, event); err != nil {
+		return err
+	}
+
+	if err := saveTags(ctx, q, event); err != nil {
+		return err
+	}
+
+	return saveResources(ctx, q, event)
 }
 
 func (s *storage) UpdateEvent(ctx context.Context, event internalStorage.Event) error {
-	isExist, err := s.isExistByID(ctx, event.ID)
-	if err != nil {
+	return s.inSerializableTx(ctx, func(tx pgx.Tx) error {
+		return s.updateEvent(ctx, tx, event)
+	})
+}
+
+func (s *storage) updateEvent(ctx context.Context, q querier, event internalStorage.Event) error {
+	if err := s.checkAuthor(ctx, q, event.AuthorID, event.ID, event.Version); err != nil {
 		return err
 	}
-	if !isExist {
-		return internalStorage.ErrEventNotFound
+
+	// Участники не изменяются, но занятость проверяется и для них.
+	events := []internalStorage.Event{event}
+	if err := loadAttendees(ctx, q, events); err != nil {
+		return err
 	}
+	event = events[0]
 
-	isBusy, err := s.isDateBusy(ctx, event)
+	isBusy, err := s.isDateBusy(ctx, q, event)
 	if err != nil {
 		return err
 	}
@@ -80,166 +166,573 @@ func (s *storage) UpdateEvent(ctx context.Context, event internalStorage.Event)
 		return internalStorage.ErrDateBusy
 	}
 
+	if err := s.checkResources(ctx, q, event); err != nil {
+		return err
+	}
+
 	sql := `UPDATE events 
-	SET title=$2, start_at=$3, end_at=$4, description=$5, author_id=$6, notification_date=$7 
+	SET title=$2, start_at=$3, end_at=$4, description=$5, author_id=$6, 
+	    rrule=$7, exdates=$8, recurrence_end=$9, time_zone=$10, calendar_id=$11, color=$12, 
+	    location=$13, latitude=$14, longitude=$15, meeting_url=$16, all_day=$17, version=version+1 
 	WHERE id = $1`
 
-	_, err = s.conn.Exec(
+	rrule, exdates, recurrenceEnd := recurrenceArgs(event)
+	latitude, longitude := coordinatesArgs(event)
+	_, err = q.Exec(
 		ctx,
 		sql,
 		event.ID,
 		event.Title,
-		event.StartAt,
-		event.EndAt,
+		event.StartAt.UTC(),
+		event.EndAt.UTC(),
 		event.Description,
 		event.AuthorID,
-		event.NotificationDate,
+		rrule,
+		exdates,
+		recurrenceEnd,
+		event.TimeZone,
+		calendarArg(event),
+		event.Color,
+		event.Place.Text,
+		latitude,
+		longitude,
+		event.MeetingURL,
+		event.AllDay,
 	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	if err := saveReminders(ctx, q, event); err != nil {
+		return err
+	}
+
+	if err := saveTags(ctx, q, event); err != nil {
+		return err
+	}
+
+	return saveResources(ctx, q, event)
 }
 
-func (s *storage) DeleteEvent(ctx context.Context, id string) error {
-	isExist, err := s.isExistByID(ctx, id)
-	if err != nil {
+// DeleteEvent перемещает событие в корзину.
+func (s *storage) DeleteEvent(ctx context.Context, userID string, id string, version int64) error {
+	sql := `UPDATE events SET deleted_at = $4, version = version + 1 
+	WHERE id = $1 AND author_id = $2 AND deleted_at IS NULL AND ($3::bigint = 0 OR version = $3)`
+
+	tag, err := s.pool.Exec(ctx, sql, id, userID, version, time.Now().UTC())
+	if err != nil || tag.RowsAffected() > 0 {
 		return err
 	}
-	if !isExist {
-		return internalStorage.ErrEventNotFound
+
+	if err := s.checkAuthor(ctx, s.pool, userID, id, version); err != nil {
+		return err
 	}
 
-	sql := `DELETE FROM events WHERE id=$1`
+	// Событие удалено параллельным запросом.
+	return internalStorage.ErrEventNotFound
+}
 
-	_, err = s.conn.Exec(ctx, sql, id)
+// RestoreEvent возвращает событие из корзины, если его время не занято.
+func (s *storage) RestoreEvent(ctx context.Context, userID string, id string) error {
+	return s.inSerializableTx(ctx, func(tx pgx.Tx) error {
+		sql := `SELECT ` + eventColumns + ` FROM events WHERE id = $1 AND deleted_at IS NOT NULL`
 
-	return err
-}
+		event, err := scanEvent(tx.QueryRow(ctx, sql, id))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return internalStorage.ErrEventNotFound
+		}
+		if err != nil {
+			return err
+		}
 
-func (s *storage) EventsDay(ctx context.Context, date time.Time) ([]internalStorage.Event, error) {
-	year, month, day := date.Date()
-	startDate := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
-	endDate := time.Date(year, month, day, 23, 59, 59, 999, time.UTC)
+		if event.AuthorID != userID {
+			return internalStorage.ErrAccessDenied
+		}
 
-	return s.eventsByDates(ctx, startDate, endDate)
-}
+		events := []internalStorage.Event{event}
+		if err := loadAttendees(ctx, tx, events); err != nil {
+			return err
+		}
+
+		if err := loadResources(ctx, tx, events); err != nil {
+			return err
+		}
 
-func (s *storage) EventsWeek(ctx context.Context, date time.Time) ([]internalStorage.Event, error) {
-	year, month, day := date.Date()
-	startDate := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
-	endDate := time.Date(year, month, day+7, 23, 59, 59, 999, time.UTC)
+		isBusy, err := s.isDateBusy(ctx, tx, events[0])
+		if err != nil {
+			return err
+		}
 
-	return s.eventsByDates(ctx, startDate, endDate)
-}
+		if isBusy {
+			return internalStorage.ErrDateBusy
+		}
 
-func (s *storage) EventsMonth(ctx context.Context, date time.Time) ([]internalStorage.Event, error) {
-	year, month, day := date.Date()
-	startDate := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
-	endDate := time.Date(year, month+1, day, 23, 59, 59, 999, time.UTC)
+		if err := s.checkResources(ctx, tx, events[0]); err != nil {
+			return err
+		}
 
-	return s.eventsByDates(ctx, startDate, endDate)
-}
+		_, err = tx.Exec(ctx, `UPDATE events SET deleted_at = NULL, version = version + 1 WHERE id = $1`, id)
 
-func (s *storage) EventsForNotification(ctx context.Context) ([]internalStorage.Event, error) {
-	result := make([]internalStorage.Event, 0)
+		return err
+	})
+}
 
-	sql := `SELECT id, title, start_at, end_at, description, author_id, notification_date 
+// ListDeleted возвращает события из корзины, последние удаленные - первыми.
+func (s *storage) ListDeleted(ctx context.Context, userID string) ([]internalStorage.Event, error) {
+	sql := `SELECT ` + eventColumns + ` 
 	FROM events 
-	WHERE notification_date IS NOT NULL AND notification_date < NOW()`
+	WHERE author_id = $1 AND deleted_at IS NOT NULL 
+	ORDER BY deleted_at DESC`
 
-	rows, err := s.conn.Query(ctx, sql)
+	rows, err := s.pool.Query(ctx, sql, userID)
 	if err != nil {
-		return result, err
+		return nil, err
 	}
 	defer rows.Close()
 
+	result := make([]internalStorage.Event, 0)
 	for rows.Next() {
-		event := internalStorage.Event{}
-		if err := rows.Scan(
-			&event.ID,
-			&event.Title,
-			&event.StartAt,
-			&event.EndAt,
-			&event.Description,
-			&event.AuthorID,
-			&event.NotificationDate,
-		); err != nil {
+		event, err := scanEvent(rows)
+		if err != nil {
 			return nil, err
 		}
 
 		result = append(result, event)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return result, nil
+	return result, loadRelated(ctx, s.pool, result)
 }
 
-func (s *storage) ClearNotificationDates(ctx context.Context, ids []string) error {
-	sql := `UPDATE events SET notification_date = NULL WHERE id = ANY($1)`
+// PurgeDeleted окончательно удаляет события, перемещенные в корзину раньше before.
+func (s *storage) PurgeDeleted(ctx context.Context, before time.Time) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM events WHERE deleted_at < $1`, before.UTC())
 
-	_, err := s.conn.Exec(ctx, sql, ids)
 	return err
 }
 
-func (s *storage) ClearOldEvents(ctx context.Context) error {
-	sql := `DELETE FROM events WHERE start_at < NOW()- interval '1 year'`
+func (s *storage) GetEvent(ctx context.Context, userID string, id string) (internalStorage.Event, error) {
+	event, err := s.getEvent(ctx, s.pool, id)
+	if err != nil {
+		return internalStorage.Event{}, err
+	}
+
+	isVisible, err := s.isVisible(ctx, s.pool, event, userID)
+	if err != nil {
+		return internalStorage.Event{}, err
+	}
+
+	if !isVisible {
+		return internalStorage.Event{}, internalStorage.ErrAccessDenied
+	}
 
-	_, err := s.conn.Exec(ctx, sql)
+	return event, nil
+}
+
+func (s *storage) EventsDay(ctx context.Context, userID string, date time.Time) ([]internalStorage.Event, error) {
+	from, to := internalStorage.DayRange(date)
+
+	return s.eventsByDates(ctx, userID, from, to)
+}
+
+func (s *storage) EventsWeek(ctx context.Context, userID string, date time.Time) ([]internalStorage.Event, error) {
+	from, to := internalStorage.WeekRange(date)
+
+	return s.eventsByDates(ctx, userID, from, to)
+}
+
+func (s *storage) EventsMonth(ctx context.Context, userID string, date time.Time) ([]internalStorage.Event, error) {
+	from, to := internalStorage.MonthRange(date)
+
+	return s.eventsByDates(ctx, userID, from, to)
+}
+
+// ClearOldEvents перемещает в корзину события, закончившиеся более года назад.
+func (s *storage) ClearOldEvents(ctx context.Context) error {
+	sql := `UPDATE events SET deleted_at = $2, version = version + 1 
+	WHERE deleted_at IS NULL AND (
+	    (rrule IS NULL AND end_at < $1) 
+	    OR (rrule IS NOT NULL AND recurrence_end < $1)
+	)`
+
+	now := time.Now().UTC()
+	_, err := s.pool.Exec(ctx, sql, now.AddDate(-1, 0, 0), now)
 	return err
 }
 
+// eventsByDates возвращает события и повторения, пересекающиеся с интервалом
+// [from, to), собирая страницы ListEvents.
 func (s *storage) eventsByDates(
 	ctx context.Context,
-	startDate time.Time,
-	endDate time.Time,
+	userID string,
+	from time.Time,
+	to time.Time,
 ) ([]internalStorage.Event, error) {
-	result := make([]internalStorage.Event, 0)
+	filter := internalStorage.ListFilter{UserID: userID, From: from, To: to, Overlapping: true}
+
+	return internalStorage.CollectEvents(ctx, s.ListEvents, filter)
+}
+
+// ListEvents выбирает обычные события страницей с сортировкой и курсором на
+// стороне базы, а повторяющиеся разворачивает в Go. Итоговая страница
+// собирается storage.PageEvents.
+func (s *storage) ListEvents(
+	ctx context.Context,
+	filter internalStorage.ListFilter,
+) (internalStorage.Page, error) {
+	cursor, err := internalStorage.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return internalStorage.Page{}, err
+	}
+
+	if filter.UserID != "" {
+		filter.Calendars, err = calendarIDs(ctx, s.pool, filter.UserID)
+		if err != nil {
+			return internalStorage.Page{}, err
+		}
+	}
+
+	from := filter.Start(cursor)
+	where, args := listConditions(filter, from)
 
-	sql := `SELECT id, title, start_at, end_at, description, author_id, notification_date 
+	sql := `SELECT ` + eventColumns + ` 
 	FROM events 
-	WHERE start_at between $1 and $2`
-	rows, err := s.conn.Query(ctx, sql, startDate, endDate)
+	WHERE ` + where + ` AND rrule IS NOT NULL AND (recurrence_end IS NULL OR recurrence_end >= $1)`
+
+	recurring, err := s.queryEvents(ctx, sql, args, filter, from)
+	if err != nil {
+		return internalStorage.Page{}, err
+	}
+
+	sql = `SELECT ` + eventColumns + ` 
+	FROM events 
+	WHERE ` + where + ` AND rrule IS NULL AND ` + startCondition(filter)
+	if !cursor.IsZero() {
+		args = append(args, cursor.StartAt.UTC(), cursor.ID)
+		sql += fmt.Sprintf(` AND (start_at, id) > ($%d, $%d)`, len(args)-1, len(args))
+	}
+	sql += fmt.Sprintf(` ORDER BY start_at, id LIMIT %d`, filter.PageLimit()+1)
+
+	events, err := s.queryEvents(ctx, sql, args, filter, from)
+	if err != nil {
+		return internalStorage.Page{}, err
+	}
+
+	return internalStorage.PageEvents(append(events, recurring...), filter)
+}
+
+// startCondition возвращает условие на начало обычных событий: начало в интервале
+// или, при Overlapping, пересечение с ним. $1 - начало интервала.
+func startCondition(filter internalStorage.ListFilter) string {
+	if filter.Overlapping {
+		return `(end_at > $1 OR start_at >= $1)`
+	}
+
+	return `start_at >= $1`
+}
+
+// listConditions возвращает условия фильтра, $1 и $2 - границы интервала.
+func listConditions(filter internalStorage.ListFilter, from time.Time) (string, []any) {
+	args := []any{from.UTC(), filter.To.UTC()}
+	where := `deleted_at IS NULL AND start_at < $2`
+	if filter.UserID != "" {
+		args = append(args, filter.UserID)
+		where += ` AND ` + visibleTo(len(args))
+	}
+	if filter.CalendarID != "" {
+		args = append(args, filter.CalendarID)
+		where += fmt.Sprintf(` AND calendar_id = $%d`, len(args))
+	}
+	if filter.Query != "" {
+		args = append(args, "%"+likeEscaper.Replace(filter.Query)+"%")
+		where += fmt.Sprintf(` AND (title ILIKE $%d OR description ILIKE $%d)`, len(args), len(args))
+	}
+	if filter.HasNotification != nil {
+		if *filter.HasNotification {
+			where += ` AND id IN (SELECT event_id FROM event_reminders)`
+		} else {
+			where += ` AND id NOT IN (SELECT event_id FROM event_reminders)`
+		}
+	}
+
+	return where, args
+}
+
+// queryEvents возвращает повторения выбранных событий, которые могут попасть
+// в выборку filter начиная с from.
+func (s *storage) queryEvents(
+	ctx context.Context,
+	sql string,
+	args []any,
+	filter internalStorage.ListFilter,
+	from time.Time,
+) ([]internalStorage.Event, error) {
+	result := make([]internalStorage.Event, 0)
+
+	events, err := s.scanEvents(ctx, s.pool, sql, args...)
 	if err != nil {
 		return result, err
 	}
+
+	for _, event := range events {
+		result = append(result, filter.Occurrences(event, from)...)
+	}
+
+	return result, nil
+}
+
+// scanEvents выбирает события вместе с участниками и напоминаниями.
+func (s *storage) scanEvents(
+	ctx context.Context,
+	q querier,
+	sql string,
+	args ...any,
+) ([]internalStorage.Event, error) {
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
+	result := make([]internalStorage.Event, 0)
 	for rows.Next() {
-		event := internalStorage.Event{}
-		if err := rows.Scan(
-			&event.ID,
-			&event.Title,
-			&event.StartAt,
-			&event.EndAt,
-			&event.Description,
-			&event.AuthorID,
-			&event.NotificationDate,
-		); err != nil {
+		event, err := scanEvent(rows)
+		if err != nil {
 			return nil, err
 		}
 
 		result = append(result, event)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// В транзакции следующий запрос возможен только после закрытия строк.
+	rows.Close()
 
-	return result, nil
+	return result, loadRelated(ctx, q, result)
 }
 
-func (s *storage) isDateBusy(ctx context.Context, event internalStorage.Event) (bool, error) {
-	sql := `SELECT id from events WHERE (start_at BETWEEN $1 AND $2 OR end_at BETWEEN $1 AND $2) AND id != $3`
-	row := s.conn.QueryRow(ctx, sql, event.StartAt, event.EndAt, event.ID)
+// isDateBusy проверяет занятость времени всех участников события.
+func (s *storage) isDateBusy(ctx context.Context, q querier, event internalStorage.Event) (bool, error) {
+	return s.isBusy(ctx, q, event, event.Participants())
+}
 
-	var id string
-	err := row.Scan(&id)
-	if errors.Is(err, pgx.ErrNoRows) {
+// isBusy выбирает кандидатов среди событий, занимающих время пользователей users,
+// с нестрогими границами, точная проверка пересечения выполняется
+// storage.Conflicts так же, как в memorystorage. Время автора проверяется
+// в календаре события, остальных участников - во всех календарях.
+func (s *storage) isBusy(
+	ctx context.Context,
+	q querier,
+	event internalStorage.Event,
+	users []string,
+) (bool, error) {
+	if len(users) == 0 {
 		return false, nil
 	}
 
-	return true, err
+	var author *string
+	others := make([]string, 0, len(users))
+	for _, userID := range users {
+		if userID == event.AuthorID {
+			author = &event.AuthorID
+			continue
+		}
+		others = append(others, userID)
+	}
+
+	sql := `SELECT ` + eventColumns + ` 
+	FROM events 
+	WHERE id != $3 AND deleted_at IS NULL AND ($2::timestamptz IS NULL OR start_at <= $2) AND (
+	    (rrule IS NULL AND end_at >= $1) 
+	    OR (rrule IS NOT NULL AND (recurrence_end IS NULL OR recurrence_end >= $1))
+	) AND (
+	    (calendar_id IS NOT DISTINCT FROM $6 AND (
+	        author_id = $7
+	        OR id IN (SELECT event_id FROM event_attendees WHERE user_id = $7 AND status <> $5)
+	    ))
+	    OR author_id = ANY($4) 
+	    OR id IN (SELECT event_id FROM event_attendees WHERE user_id = ANY($4) AND status <> $5)
+	)`
+	rows, err := q.Query(
+		ctx,
+		sql,
+		event.StartAt.UTC(),
+		seriesEndArg(event),
+		event.ID,
+		others,
+		string(internalStorage.RSVPDeclined),
+		calendarArg(event),
+		author,
+	)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		other, err := scanEvent(rows)
+		if err != nil {
+			return false, err
+		}
+
+		if internalStorage.Conflicts(event, other) {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
+
+// checkAuthor проверяет, что событие существует вне корзины, принадлежит пользователю
+// и имеет ожидаемую версию (0 - любую).
+func (s *storage) checkAuthor(ctx context.Context, q querier, userID string, id string, version int64) error {
+	sql := `SELECT author_id, version FROM events WHERE id = $1 AND deleted_at IS NULL`
+	row := q.QueryRow(ctx, sql, id)
+
+	var (
+		authorID       string
+		currentVersion int64
+	)
+	err := row.Scan(&authorID, &currentVersion)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return internalStorage.ErrEventNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if authorID != userID {
+		return internalStorage.ErrAccessDenied
+	}
+
+	if version != 0 && version != currentVersion {
+		return internalStorage.ErrVersionConflict
+	}
+
+	return nil
+}
+
+// inSerializableTx выполняет fn в транзакции SERIALIZABLE и повторяет ее при конфликте сериализации.
+func (s *storage) inSerializableTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	var err error
+	for attempt := 0; attempt < maxTxRetries; attempt++ {
+		err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{IsoLevel: pgx.Serializable}, fn)
+
+		var pgErr *pgconn.PgError
+		if !errors.As(err, &pgErr) ||
+			(pgErr.Code != codeSerializationFailure && pgErr.Code != codeDeadlockDetected) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// scanEvent читает строку с колонками eventColumns, extra - дополнительные колонки после них.
+func scanEvent(row pgx.Row, extra ...any) (internalStorage.Event, error) {
+	event := internalStorage.Event{}
+
+	var (
+		rrule      *string
+		exdates    []time.Time
+		deletedAt  *time.Time
+		calendarID *string
+		latitude   *float64
+		longitude  *float64
+	)
+
+	dest := []any{
+		&event.ID,
+		&event.Title,
+		&event.StartAt,
+		&event.EndAt,
+		&event.Description,
+		&event.AuthorID,
+		&rrule,
+		&exdates,
+		&event.Version,
+		&deletedAt,
+		&event.TimeZone,
+		&calendarID,
+		&event.Color,
+		&event.Place.Text,
+		&latitude,
+		&longitude,
+		&event.MeetingURL,
+		&event.AllDay,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return event, err
+	}
+
+	if deletedAt != nil {
+		event.DeletedAt = *deletedAt
+	}
+
+	if calendarID != nil {
+		event.CalendarID = *calendarID
+	}
+
+	if latitude != nil && longitude != nil {
+		event.Place.Coordinates = &internalStorage.Coordinates{Latitude: *latitude, Longitude: *longitude}
+	}
+
+	if rrule != nil {
+		recurrence, err := internalStorage.ParseRecurrence(*rrule)
+		if err != nil {
+			return event, err
+		}
+		if recurrence != nil {
+			recurrence.Exceptions = exdates
+		}
+		event.Recurrence = recurrence
+	}
+
+	return event.Localize(), nil
+}
+
+// coordinatesArgs возвращает значения колонок latitude и longitude, без координат - NULL.
+func coordinatesArgs(event internalStorage.Event) (*float64, *float64) {
+	c := event.Place.Coordinates
+	if c == nil {
+		return nil, nil
+	}
+
+	return &c.Latitude, &c.Longitude
+}
+
+// seriesEndArg возвращает верхнюю границу начала событий, с которыми может
+// пересечься event. У бесконечной серии границы нет: SeriesEnd ограничен
+// RecurrenceHorizon, а повторения продолжаются и после него.
+func seriesEndArg(event internalStorage.Event) *time.Time {
+	if event.IsInfinite() {
+		return nil
+	}
+
+	end := event.SeriesEnd().UTC()
+
+	return &end
 }
 
-func (s *storage) isExistByID(ctx context.Context, id string) (bool, error) {
-	sql := `SELECT EXISTS(SELECT 1 FROM events WHERE id = $1)`
-	row := s.conn.QueryRow(ctx, sql, id)
-	isExist := false
+func recurrenceArgs(event internalStorage.Event) (*string, []time.Time, *time.Time) {
+	if !event.IsRecurring() {
+		return nil, nil, nil
+	}
+
+	rrule := event.Recurrence.String()
+	exdates := make([]time.Time, 0, len(event.Recurrence.Exceptions))
+	for _, t := range event.Recurrence.Exceptions {
+		exdates = append(exdates, t.UTC())
+	}
+
+	if event.IsInfinite() {
+		return &rrule, exdates, nil
+	}
+
+	end := event.SeriesEnd().UTC()
 
-	err := row.Scan(&isExist)
-	return isExist, err
+	return &rrule, exdates, &end
 }
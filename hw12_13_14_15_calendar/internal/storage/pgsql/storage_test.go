@@ -0,0 +1,47 @@
+package sqlstorage
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage/storagetest"
+)
+
+// testDsnEnv задает DSN базы с примененными миграциями. Все таблицы
+// очищаются перед каждым тестом, поэтому не используйте рабочую базу.
+const testDsnEnv = "CALENDAR_TEST_DSN"
+
+func newTestStorage(t *testing.T) func() internalStorage.Storage {
+	t.Helper()
+
+	dsn := os.Getenv(testDsnEnv)
+	if dsn == "" {
+		t.Skipf("%s не задан", testDsnEnv)
+	}
+
+	return func() internalStorage.Storage {
+		ctx := context.Background()
+		s := New(2).(*storage)
+		if err := s.Connect(ctx, dsn); err != nil {
+			panic(err)
+		}
+		t.Cleanup(func() {
+			_ = s.Close(ctx)
+		})
+
+		// Связанные таблицы участников, напоминаний, тегов, доступа и брони
+		// ссылаются на корневые и очищаются каскадно.
+		sql := `TRUNCATE events, calendars, resources, event_history RESTART IDENTITY CASCADE`
+		if _, err := s.pool.Exec(ctx, sql); err != nil {
+			panic(err)
+		}
+
+		return s
+	}
+}
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, newTestStorage(t))
+}
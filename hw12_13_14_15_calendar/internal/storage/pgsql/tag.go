@@ -0,0 +1,63 @@
+package sqlstorage
+
+import (
+	"context"
+
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+// saveTags приводит теги события к event.Tags.
+func saveTags(ctx context.Context, q querier, event internalStorage.Event) error {
+	tags := event.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	sql := `DELETE FROM event_tags WHERE event_id = $1 AND NOT (tag = ANY($2))`
+	if _, err := q.Exec(ctx, sql, event.ID, tags); err != nil {
+		return err
+	}
+
+	sql = `INSERT INTO event_tags (event_id, tag)
+	SELECT $1, unnest($2::text[])
+	ON CONFLICT (event_id, tag) DO NOTHING`
+	_, err := q.Exec(ctx, sql, event.ID, tags)
+
+	return err
+}
+
+// loadTags заполняет теги событий одним запросом.
+func loadTags(ctx context.Context, q querier, events []internalStorage.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+
+	sql := `SELECT event_id, tag FROM event_tags WHERE event_id = ANY($1) ORDER BY event_id, tag`
+
+	rows, err := q.Query(ctx, sql, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var eventID, tag string
+		if err := rows.Scan(&eventID, &tag); err != nil {
+			return err
+		}
+
+		tags[eventID] = append(tags[eventID], tag)
+	}
+
+	for i := range events {
+		events[i].Tags = tags[events[i].ID]
+	}
+
+	return rows.Err()
+}
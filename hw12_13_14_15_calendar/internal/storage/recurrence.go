@@ -0,0 +1,312 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	FrequencyDaily   Frequency = "DAILY"
+	FrequencyWeekly  Frequency = "WEEKLY"
+	FrequencyMonthly Frequency = "MONTHLY"
+)
+
+// RecurrenceHorizon ограничивает развертывание бесконечных повторений
+// при поиске пересечений событий.
+const RecurrenceHorizon = 2 * 365 * 24 * time.Hour
+
+const (
+	untilLayout = "20060102T150405Z"
+	maxPeriods  = 100000
+)
+
+var ErrInvalidRecurrence = errors.New("невалидное правило повторения")
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// WeekdayNum день недели из BYDAY, для ежемесячных правил может содержать
+// порядковый номер (1MO - первый понедельник, -1FR - последняя пятница).
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// Recurrence правило повторения события в духе RRULE из RFC 5545.
+type Recurrence struct {
+	Frequency  Frequency
+	Interval   int
+	ByDay      []WeekdayNum
+	Count      int
+	Until      time.Time
+	Exceptions []time.Time
+}
+
+// ParseRecurrence разбирает правило в формате RRULE, например
+// "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=10".
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if rule == "" {
+		return nil, nil
+	}
+
+	r := &Recurrence{Interval: 1}
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRecurrence, part)
+		}
+
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Frequency = Frequency(strings.ToUpper(value))
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+		case "UNTIL":
+			r.Until, err = parseUntil(value)
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		default:
+			err = fmt.Errorf("неподдерживаемый параметр %s", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRecurrence, err.Error())
+		}
+	}
+
+	return r, r.Validate()
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if t, err := time.Parse(untilLayout, value); err == nil {
+		return t, nil
+	}
+
+	return time.Parse("20060102", value)
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	result := make([]WeekdayNum, 0)
+	for _, v := range strings.Split(value, ",") {
+		v = strings.ToUpper(strings.TrimSpace(v))
+		if len(v) < 2 {
+			return nil, fmt.Errorf("невалидный день недели %q", v)
+		}
+
+		day, ok := weekdays[v[len(v)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("невалидный день недели %q", v)
+		}
+
+		n := 0
+		if prefix := v[:len(v)-2]; prefix != "" {
+			var err error
+			if n, err = strconv.Atoi(prefix); err != nil || n == 0 || n > 5 || n < -5 {
+				return nil, fmt.Errorf("невалидный день недели %q", v)
+			}
+		}
+
+		result = append(result, WeekdayNum{N: n, Day: day})
+	}
+
+	return result, nil
+}
+
+func (r *Recurrence) Validate() error {
+	switch r.Frequency {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+	default:
+		return fmt.Errorf("%w: неподдерживаемая частота %q", ErrInvalidRecurrence, r.Frequency)
+	}
+
+	if r.Interval < 1 {
+		return fmt.Errorf("%w: интервал должен быть больше 0", ErrInvalidRecurrence)
+	}
+
+	if r.Count < 0 {
+		return fmt.Errorf("%w: COUNT не может быть отрицательным", ErrInvalidRecurrence)
+	}
+
+	if r.Count > 0 && !r.Until.IsZero() {
+		return fmt.Errorf("%w: COUNT и UNTIL не могут быть указаны одновременно", ErrInvalidRecurrence)
+	}
+
+	for _, d := range r.ByDay {
+		if d.N != 0 && r.Frequency != FrequencyMonthly {
+			return fmt.Errorf("%w: порядковый день недели допустим только для MONTHLY", ErrInvalidRecurrence)
+		}
+	}
+
+	if len(r.ByDay) > 0 && r.Frequency == FrequencyDaily {
+		return fmt.Errorf("%w: BYDAY не поддерживается для DAILY", ErrInvalidRecurrence)
+	}
+
+	return nil
+}
+
+// String возвращает правило в формате RRULE без исключений.
+func (r *Recurrence) String() string {
+	if r == nil {
+		return ""
+	}
+
+	parts := []string{"FREQ=" + string(r.Frequency)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, d := range r.ByDay {
+			day := strings.ToUpper(d.Day.String()[:2])
+			if d.N != 0 {
+				day = strconv.Itoa(d.N) + day
+			}
+			days = append(days, day)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayout))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+func (r *Recurrence) isException(t time.Time) bool {
+	for _, e := range r.Exceptions {
+		if e.Equal(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// starts перебирает даты начала повторений по порядку, пока fn возвращает true.
+// Исключения учитываются в COUNT, но в fn не передаются.
+func (r *Recurrence) starts(dtStart time.Time, fn func(time.Time) bool) {
+	n := 0
+	for period := 0; period < maxPeriods; period++ {
+		candidates := r.periodStarts(dtStart, period)
+		if candidates == nil {
+			return
+		}
+
+		for _, c := range candidates {
+			if c.Before(dtStart) {
+				continue
+			}
+
+			if !r.Until.IsZero() && c.After(r.Until) {
+				return
+			}
+
+			n++
+			if r.Count > 0 && n > r.Count {
+				return
+			}
+
+			if r.isException(c) {
+				continue
+			}
+
+			if !fn(c) {
+				return
+			}
+		}
+	}
+}
+
+// periodStarts возвращает даты начала повторений для периода с номером period.
+func (r *Recurrence) periodStarts(dtStart time.Time, period int) []time.Time {
+	year, month, day := dtStart.Date()
+	hour, minute, sec := dtStart.Clock()
+	loc := dtStart.Location()
+	step := period * r.Interval
+
+	switch r.Frequency {
+	case FrequencyDaily:
+		return []time.Time{dtStart.AddDate(0, 0, step)}
+	case FrequencyWeekly:
+		if len(r.ByDay) == 0 {
+			return []time.Time{dtStart.AddDate(0, 0, 7*step)}
+		}
+
+		// Неделя начинается с понедельника (WKST=MO).
+		offset := (int(dtStart.Weekday()) + 6) % 7
+		weekStart := time.Date(year, month, day-offset+7*step, hour, minute, sec, dtStart.Nanosecond(), loc)
+		result := make([]time.Time, 0, len(r.ByDay))
+		for _, d := range r.ByDay {
+			result = append(result, weekStart.AddDate(0, 0, (int(d.Day)+6)%7))
+		}
+		sortTimes(result)
+
+		return result
+	case FrequencyMonthly:
+		monthStart := time.Date(year, month+time.Month(step), 1, hour, minute, sec, dtStart.Nanosecond(), loc)
+		if len(r.ByDay) == 0 {
+			t := monthStart.AddDate(0, 0, day-1)
+			if t.Month() != monthStart.Month() {
+				return []time.Time{}
+			}
+
+			return []time.Time{t}
+		}
+
+		return monthWeekdays(monthStart, r.ByDay)
+	default:
+		return nil
+	}
+}
+
+func monthWeekdays(monthStart time.Time, byDay []WeekdayNum) []time.Time {
+	result := make([]time.Time, 0)
+	for _, d := range byDay {
+		matches := make([]time.Time, 0, 5)
+		for t := monthStart; t.Month() == monthStart.Month(); t = t.AddDate(0, 0, 1) {
+			if t.Weekday() == d.Day {
+				matches = append(matches, t)
+			}
+		}
+
+		switch {
+		case d.N == 0:
+			result = append(result, matches...)
+		case d.N > 0 && d.N <= len(matches):
+			result = append(result, matches[d.N-1])
+		case d.N < 0 && -d.N <= len(matches):
+			result = append(result, matches[len(matches)+d.N])
+		}
+	}
+	sortTimes(result)
+
+	return result
+}
+
+func sortTimes(t []time.Time) {
+	sort.Slice(t, func(i, j int) bool {
+		return t[i].Before(t[j])
+	})
+}
@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	t.Run("valid rules", func(t *testing.T) {
+		results := []struct {
+			rule     string
+			excepted string
+		}{
+			{"FREQ=DAILY", "FREQ=DAILY"},
+			{"RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE"},
+			{"FREQ=MONTHLY;BYDAY=-1FR;COUNT=3", "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3"},
+			{"freq=daily;until=20230610T000000Z", "FREQ=DAILY;UNTIL=20230610T000000Z"},
+		}
+
+		for _, v := range results {
+			r, err := ParseRecurrence(v.rule)
+			require.NoError(t, err)
+			require.Equal(t, v.excepted, r.String())
+		}
+	})
+
+	t.Run("empty rule", func(t *testing.T) {
+		r, err := ParseRecurrence("")
+		require.NoError(t, err)
+		require.Nil(t, r)
+	})
+
+	t.Run("invalid rules", func(t *testing.T) {
+		rules := []string{
+			"FREQ=YEARLY",
+			"FREQ=DAILY;INTERVAL=0",
+			"FREQ=DAILY;BYDAY=MO",
+			"FREQ=WEEKLY;BYDAY=1MO",
+			"FREQ=WEEKLY;BYDAY=XX",
+			"FREQ=DAILY;COUNT=2;UNTIL=20230610",
+			"FREQ=DAILY;BYSETPOS=1",
+			"FREQ",
+		}
+
+		for _, rule := range rules {
+			_, err := ParseRecurrence(rule)
+			require.Truef(t, errors.Is(err, ErrInvalidRecurrence), "rule %q, actual error %q", rule, err)
+		}
+	})
+}
+
+func TestOccurrences(t *testing.T) {
+	// Четверг.
+	start, err := time.Parse(time.RFC3339, "2023-06-01T10:00:00Z")
+	require.NoError(t, err)
+
+	newEvent := func(rule string) Event {
+		r, err := ParseRecurrence(rule)
+		require.NoError(t, err)
+
+		return Event{
+			ID:         "1",
+			StartAt:    start,
+			EndAt:      start.Add(time.Hour),
+			Recurrence: r,
+		}
+	}
+
+	starts := func(events []Event) []string {
+		result := make([]string, 0, len(events))
+		for _, e := range events {
+			result = append(result, e.StartAt.Format(time.DateOnly))
+		}
+
+		return result
+	}
+
+	t.Run("daily with count", func(t *testing.T) {
+		e := newEvent("FREQ=DAILY;COUNT=3")
+		require.Equal(
+			t,
+			[]string{"2023-06-01", "2023-06-02", "2023-06-03"},
+			starts(e.Occurrences(start, start.AddDate(0, 1, 0))),
+		)
+		require.Equal(t, start.AddDate(0, 0, 2).Add(time.Hour), e.SeriesEnd())
+	})
+
+	t.Run("weekly by day with interval", func(t *testing.T) {
+		e := newEvent("FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH")
+		require.Equal(
+			t,
+			[]string{"2023-06-01", "2023-06-12", "2023-06-15", "2023-06-26", "2023-06-29"},
+			starts(e.Occurrences(start, start.AddDate(0, 1, 0))),
+		)
+	})
+
+	t.Run("monthly skips short months", func(t *testing.T) {
+		e := newEvent("FREQ=MONTHLY")
+		e.StartAt = time.Date(2023, 1, 31, 10, 0, 0, 0, time.UTC)
+		e.EndAt = e.StartAt.Add(time.Hour)
+		require.Equal(
+			t,
+			[]string{"2023-01-31", "2023-03-31", "2023-05-31"},
+			starts(e.Occurrences(e.StartAt, time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC))),
+		)
+	})
+
+	t.Run("monthly last friday", func(t *testing.T) {
+		e := newEvent("FREQ=MONTHLY;BYDAY=-1FR;COUNT=2")
+		require.Equal(
+			t,
+			[]string{"2023-06-30", "2023-07-28"},
+			starts(e.Occurrences(start, start.AddDate(1, 0, 0))),
+		)
+	})
+
+	t.Run("until and exceptions", func(t *testing.T) {
+		e := newEvent("FREQ=DAILY;UNTIL=20230605T100000Z")
+		e.Recurrence.Exceptions = []time.Time{start.AddDate(0, 0, 1)}
+		require.Equal(
+			t,
+			[]string{"2023-06-01", "2023-06-03", "2023-06-04", "2023-06-05"},
+			starts(e.Occurrences(start, start.AddDate(0, 1, 0))),
+		)
+	})
+
+	t.Run("window", func(t *testing.T) {
+		e := newEvent("FREQ=DAILY")
+		occurrences := e.Occurrences(start.AddDate(0, 0, 10), start.AddDate(0, 0, 12))
+		require.Equal(t, []string{"2023-06-11", "2023-06-12"}, starts(occurrences))
+		require.Equal(t, time.Hour, occurrences[0].EndAt.Sub(occurrences[0].StartAt))
+		require.Equal(t, e.ID, occurrences[0].ID)
+	})
+}
+
+func TestConflicts(t *testing.T) {
+	start, err := time.Parse(time.RFC3339, "2023-06-01T10:00:00Z")
+	require.NoError(t, err)
+	weekly, err := ParseRecurrence("FREQ=WEEKLY")
+	require.NoError(t, err)
+
+	standup := Event{ID: "1", StartAt: start, EndAt: start.Add(time.Hour), Recurrence: weekly}
+	newEvent := func(start time.Time, duration time.Duration) Event {
+		return Event{ID: "2", StartAt: start, EndAt: start.Add(duration)}
+	}
+
+	results := []struct {
+		name     string
+		event    Event
+		excepted bool
+	}{
+		{
+			name:     "overlaps later occurrence",
+			event:    newEvent(start.AddDate(0, 0, 14).Add(30*time.Minute), 90*time.Minute),
+			excepted: true,
+		},
+		{
+			name:     "between occurrences",
+			event:    newEvent(start.AddDate(0, 0, 3), time.Hour),
+			excepted: false,
+		},
+		{
+			name:     "adjacent",
+			event:    newEvent(start.AddDate(0, 0, 7).Add(time.Hour), time.Hour),
+			excepted: false,
+		},
+		{
+			name: "recurring against recurring",
+			event: Event{
+				ID:         "2",
+				StartAt:    start.AddDate(0, 0, 1),
+				EndAt:      start.AddDate(0, 0, 1).Add(time.Hour),
+				Recurrence: &Recurrence{Frequency: FrequencyDaily, Interval: 1},
+			},
+			excepted: true,
+		},
+		{
+			name:     "same event",
+			event:    Event{ID: "1", StartAt: start, EndAt: start.Add(time.Hour)},
+			excepted: false,
+		},
+	}
+
+	for _, v := range results {
+		t.Run(v.name, func(t *testing.T) {
+			require.Equal(t, v.excepted, Conflicts(standup, v.event))
+			require.Equal(t, v.excepted, Conflicts(v.event, standup))
+		})
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	start, err := time.Parse(time.RFC3339, "2023-06-01T23:00:00Z")
+	require.NoError(t, err)
+	end := start.Add(2 * time.Hour)
+
+	results := []struct {
+		name     string
+		from     time.Time
+		to       time.Time
+		excepted bool
+	}{
+		{"contains", start.Add(-time.Hour), end.Add(time.Hour), true},
+		{"inside after midnight", start.Add(90 * time.Minute), start.Add(100 * time.Minute), true},
+		{"adjacent before", start.Add(-time.Hour), start, false},
+		{"adjacent after", end, end.Add(time.Hour), false},
+		{"instant at start", start, start, true},
+		{"instant at end", end, end, false},
+	}
+
+	for _, v := range results {
+		t.Run(v.name, func(t *testing.T) {
+			require.Equal(t, v.excepted, Overlaps(start, end, v.from, v.to))
+			require.Equal(t, v.excepted, Overlaps(v.from, v.to, start, end))
+		})
+	}
+}
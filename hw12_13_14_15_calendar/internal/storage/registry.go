@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrUnknownStorageType = errors.New("неизвестный тип хранилища")
+
+// Options параметры хранилища из секции [storage] конфигурации.
+type Options struct {
+	Dsn             string
+	PoolSize        int32
+	Path            string
+	CompactInterval time.Duration
+}
+
+// Factory создает хранилище, Connect вызывается отдельно.
+type Factory func(opts Options) Storage
+
+// Migrator применяет миграции из каталога dir к базе по dsn.
+type Migrator func(dsn string, dir string) error
+
+// Backend реализация хранилища. Migrate и MigrationPath не заполняются,
+// если хранилищу не нужны миграции.
+type Backend struct {
+	New           Factory
+	Migrate       Migrator
+	MigrationPath string
+}
+
+var (
+	backendsMu sync.RWMutex
+	backends   = make(map[string]Backend)
+)
+
+// Register регистрирует хранилище под именем, которое указывается в storageType.
+// Обычно вызывается из init пакета хранилища, поэтому для подключения
+// стороннего хранилища достаточно импортировать его пакет.
+func Register(name string, backend Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	if backend.New == nil {
+		panic("storage: Register с пустой фабрикой для " + name)
+	}
+
+	if _, ok := backends[name]; ok {
+		panic("storage: повторная регистрация хранилища " + name)
+	}
+
+	backends[name] = backend
+}
+
+// Lookup возвращает зарегистрированное хранилище.
+func Lookup(name string) (Backend, error) {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	backend, ok := backends[name]
+	if !ok {
+		return Backend{}, fmt.Errorf("%w: %q", ErrUnknownStorageType, name)
+	}
+
+	return backend, nil
+}
+
+// Backends возвращает отсортированный список зарегистрированных хранилищ.
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	result := make([]string, 0, len(backends))
+	for name := range backends {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+
+	return result
+}
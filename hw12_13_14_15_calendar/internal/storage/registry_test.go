@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	factory := func(_ Options) Storage {
+		return nil
+	}
+
+	t.Run("register and lookup", func(t *testing.T) {
+		Register("test", Backend{New: factory, MigrationPath: "./test/"})
+
+		backend, err := Lookup("test")
+		require.NoError(t, err)
+		require.Equal(t, "./test/", backend.MigrationPath)
+		require.Nil(t, backend.Migrate)
+		require.Contains(t, Backends(), "test")
+	})
+	t.Run("unknown backend", func(t *testing.T) {
+		_, err := Lookup("unknown")
+		require.Truef(t, errors.Is(err, ErrUnknownStorageType), "actual error %q", err)
+	})
+	t.Run("duplicate registration", func(t *testing.T) {
+		require.Panics(t, func() {
+			Register("test", Backend{New: factory})
+		})
+	})
+	t.Run("empty factory", func(t *testing.T) {
+		require.Panics(t, func() {
+			Register("empty", Backend{})
+		})
+	})
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// MaxReminders ограничивает число напоминаний у одного события.
+const MaxReminders = 10
+
+var ErrInvalidReminder = errors.New("невалидное напоминание")
+
+// Reminder сработавшее напоминание о повторении события Event за Offset до его начала.
+type Reminder struct {
+	Event  Event
+	Offset time.Duration
+}
+
+// Date возвращает время срабатывания напоминания.
+func (r Reminder) Date() time.Time {
+	return r.Event.StartAt.Add(-r.Offset)
+}
+
+// Notifications возвращает по одному уведомлению каждому участнику,
+// не отклонившему приглашение, включая автора.
+func (r Reminder) Notifications() []Notification {
+	participants := r.Event.Participants()
+	result := make([]Notification, 0, len(participants))
+	for _, userID := range participants {
+		result = append(result, Notification{
+			ID:         r.Event.ID,
+			Title:      r.Event.Title,
+			Date:       r.Date(),
+			AuthorID:   r.Event.AuthorID,
+			UserID:     userID,
+			Location:   r.Event.Place,
+			MeetingURL: r.Event.MeetingURL,
+		})
+	}
+
+	return result
+}
+
+// NormalizeReminders проверяет смещения напоминаний и возвращает их без
+// повторов, от самого раннего напоминания к самому позднему.
+func NormalizeReminders(offsets []time.Duration) ([]time.Duration, error) {
+	if len(offsets) == 0 {
+		return nil, nil
+	}
+
+	result := make([]time.Duration, 0, len(offsets))
+	seen := make(map[time.Duration]struct{}, len(offsets))
+	for _, offset := range offsets {
+		if offset < 0 {
+			return nil, ErrInvalidReminder
+		}
+
+		if _, ok := seen[offset]; ok {
+			continue
+		}
+		seen[offset] = struct{}{}
+		result = append(result, offset)
+	}
+
+	if len(result) > MaxReminders {
+		return nil, ErrInvalidReminder
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] > result[j]
+	})
+
+	return result, nil
+}
+
+// DueReminders возвращает напоминания, время срабатывания которых наступило к now.
+// notifiedUntil хранит время срабатывания последнего отправленного напоминания
+// с каждым смещением.
+func (e Event) DueReminders(notifiedUntil map[time.Duration]time.Time, now time.Time) []Reminder {
+	result := make([]Reminder, 0)
+	for _, offset := range e.Reminders {
+		if r, ok := e.DueReminder(offset, notifiedUntil[offset], now); ok {
+			result = append(result, r)
+		}
+	}
+
+	return result
+}
+
+// DueReminder возвращает напоминание за offset о последнем повторении, время
+// срабатывания которого попадает в интервал (after, now]. Более ранние
+// пропущенные повторения не отправляются.
+func (e Event) DueReminder(offset time.Duration, after, now time.Time) (Reminder, bool) {
+	occurrences := e.Occurrences(after.Add(offset).Add(time.Nanosecond), now.Add(offset).Add(time.Nanosecond))
+	if len(occurrences) == 0 {
+		return Reminder{}, false
+	}
+
+	return Reminder{Event: occurrences[len(occurrences)-1], Offset: offset}, true
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeReminders(t *testing.T) {
+	offsets, err := NormalizeReminders([]time.Duration{15 * time.Minute, 24 * time.Hour, 15 * time.Minute, 0})
+	require.NoError(t, err)
+	require.Equal(t, []time.Duration{24 * time.Hour, 15 * time.Minute, 0}, offsets)
+
+	offsets, err = NormalizeReminders(nil)
+	require.NoError(t, err)
+	require.Nil(t, offsets)
+
+	_, err = NormalizeReminders([]time.Duration{-time.Minute})
+	require.True(t, errors.Is(err, ErrInvalidReminder))
+
+	tooMany := make([]time.Duration, 0, MaxReminders+1)
+	for i := 0; i <= MaxReminders; i++ {
+		tooMany = append(tooMany, time.Duration(i)*time.Minute)
+	}
+	_, err = NormalizeReminders(tooMany)
+	require.True(t, errors.Is(err, ErrInvalidReminder))
+}
+
+func TestDueReminders(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	e := Event{
+		ID:        "1",
+		StartAt:   now.Add(30 * time.Minute),
+		EndAt:     now.Add(time.Hour),
+		Reminders: []time.Duration{24 * time.Hour, time.Hour, 15 * time.Minute},
+	}
+
+	t.Run("single event", func(t *testing.T) {
+		reminders := e.DueReminders(nil, now)
+		require.Equal(t, 2, len(reminders))
+		require.Equal(t, 24*time.Hour, reminders[0].Offset)
+		require.Equal(t, e.StartAt.Add(-24*time.Hour), reminders[0].Date())
+		require.Equal(t, time.Hour, reminders[1].Offset)
+
+		// Отправленное напоминание не повторяется, остальные срабатывают независимо.
+		notified := map[time.Duration]time.Time{time.Hour: reminders[1].Date()}
+		reminders = e.DueReminders(notified, now)
+		require.Equal(t, 1, len(reminders))
+		require.Equal(t, 24*time.Hour, reminders[0].Offset)
+	})
+
+	t.Run("rescheduled", func(t *testing.T) {
+		notified := map[time.Duration]time.Time{time.Hour: now.Add(-30 * time.Minute)}
+		moved := e
+		moved.StartAt = now.Add(3 * time.Hour)
+		moved.EndAt = moved.StartAt.Add(time.Hour)
+		require.Equal(t, 1, len(moved.DueReminders(notified, now)))
+		reminders := moved.DueReminders(notified, now.Add(2*time.Hour))
+		require.Equal(t, 2, len(reminders))
+		require.Equal(t, moved.StartAt.Add(-time.Hour), reminders[1].Date())
+	})
+
+	t.Run("recurring", func(t *testing.T) {
+		daily := e
+		daily.StartAt = now.AddDate(0, 0, -3)
+		daily.EndAt = daily.StartAt.Add(time.Hour)
+		daily.Reminders = []time.Duration{time.Hour}
+		daily.Recurrence = &Recurrence{Frequency: FrequencyDaily, Interval: 1}
+
+		reminders := daily.DueReminders(nil, now)
+		require.Equal(t, 1, len(reminders))
+		require.True(t, reminders[0].Event.StartAt.Equal(now))
+
+		notified := map[time.Duration]time.Time{time.Hour: reminders[0].Date()}
+		require.Equal(t, 0, len(daily.DueReminders(notified, now)))
+		require.Equal(t, 1, len(daily.DueReminders(notified, now.AddDate(0, 0, 1))))
+	})
+}
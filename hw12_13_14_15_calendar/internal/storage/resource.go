@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// MaxResources ограничивает число ресурсов, которые бронирует одно событие.
+const MaxResources = 10
+
+var (
+	ErrResourceNotFound     = errors.New("ресурс не найден")
+	ErrResourceAccessDenied = errors.New("ресурс принадлежит другому пользователю")
+	ErrResourceBusy         = errors.New("ресурс уже забронирован другим событием")
+	ErrResourceInUse        = errors.New("ресурс забронирован событиями")
+	ErrInvalidResource      = errors.New("невалидный ресурс")
+)
+
+// ResourceKind тип бронируемого ресурса.
+type ResourceKind string
+
+const (
+	// ResourceRoom переговорная комната.
+	ResourceRoom ResourceKind = "room"
+	// ResourceEquipment оборудование: проектор, камера и т.п.
+	ResourceEquipment ResourceKind = "equipment"
+)
+
+func (k ResourceKind) Validate() error {
+	switch k {
+	case ResourceRoom, ResourceEquipment:
+		return nil
+	default:
+		return fmt.Errorf("%w: тип %q", ErrInvalidResource, k)
+	}
+}
+
+// Resource переговорная или оборудование, которое бронируют события.
+// Capacity - вместимость, 0 означает, что она не указана. Ресурсы видны и
+// доступны для бронирования всем пользователям, изменять и удалять ресурс
+// может только создавший его OwnerID.
+type Resource struct {
+	ID       string
+	Name     string
+	Kind     ResourceKind
+	Capacity int
+	OwnerID  string
+}
+
+// Validate проверяет название, тип и вместимость ресурса.
+func (r Resource) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("%w: пустое название", ErrInvalidResource)
+	}
+
+	if r.Capacity < 0 {
+		return fmt.Errorf("%w: вместимость %d", ErrInvalidResource, r.Capacity)
+	}
+
+	return r.Kind.Validate()
+}
+
+// HasResource сообщает, что событие бронирует ресурс id.
+func (e Event) HasResource(id string) bool {
+	return slices.Contains(e.Resources, id)
+}
+
+// SharesResource сообщает, что события бронируют хотя бы один общий ресурс.
+func (e Event) SharesResource(other Event) bool {
+	for _, id := range e.Resources {
+		if other.HasResource(id) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NormalizeResources убирает повторы и упорядочивает идентификаторы ресурсов.
+func NormalizeResources(ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	result := slices.Clone(ids)
+	sort.Strings(result)
+	result = slices.Compact(result)
+	if len(result) > MaxResources {
+		return nil, fmt.Errorf("%w: больше %d ресурсов", ErrInvalidResource, MaxResources)
+	}
+
+	return result, nil
+}
+
+// SortResources упорядочивает ресурсы по названию и ID.
+func SortResources(resources []Resource) {
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].Name != resources[j].Name {
+			return resources[i].Name < resources[j].Name
+		}
+
+		return resources[i].ID < resources[j].ID
+	})
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceValidate(t *testing.T) {
+	require.NoError(t, Resource{Name: "Переговорная 3", Kind: ResourceRoom, Capacity: 8}.Validate())
+	require.NoError(t, Resource{Name: "Проектор", Kind: ResourceEquipment}.Validate())
+
+	for _, r := range []Resource{
+		{Name: " ", Kind: ResourceRoom},
+		{Name: "Переговорная", Kind: "hall"},
+		{Name: "Переговорная", Kind: ResourceRoom, Capacity: -1},
+	} {
+		require.True(t, errors.Is(r.Validate(), ErrInvalidResource), r)
+	}
+}
+
+func TestNormalizeResources(t *testing.T) {
+	ids, err := NormalizeResources([]string{"b", "a", "b"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, ids)
+
+	ids, err = NormalizeResources(nil)
+	require.NoError(t, err)
+	require.Nil(t, ids)
+
+	tooMany := make([]string, 0, MaxResources+1)
+	for i := 0; i <= MaxResources; i++ {
+		tooMany = append(tooMany, string(rune('a'+i)))
+	}
+	_, err = NormalizeResources(tooMany)
+	require.True(t, errors.Is(err, ErrInvalidResource))
+}
+
+func TestSharesResource(t *testing.T) {
+	a := Event{Resources: []string{"room", "projector"}}
+	require.True(t, a.SharesResource(Event{Resources: []string{"projector"}}))
+	require.False(t, a.SharesResource(Event{Resources: []string{"camera"}}))
+	require.False(t, a.SharesResource(Event{}))
+}
+
+func TestOccurrencesOverlapping(t *testing.T) {
+	start := time.Date(2023, 6, 1, 22, 0, 0, 0, time.UTC)
+	e := Event{StartAt: start, EndAt: start.Add(4 * time.Hour)}
+
+	nextDay := time.Date(2023, 6, 2, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, 0, len(e.Occurrences(nextDay, nextDay.AddDate(0, 0, 1))))
+	require.Equal(t, 1, len(e.OccurrencesOverlapping(nextDay, nextDay.AddDate(0, 0, 1))))
+	require.Equal(t, 0, len(e.OccurrencesOverlapping(start.Add(4*time.Hour), nextDay.AddDate(0, 0, 1))))
+
+	e.Recurrence = &Recurrence{Frequency: FrequencyDaily, Interval: 1}
+	require.Equal(t, 2, len(e.OccurrencesOverlapping(nextDay, nextDay.AddDate(0, 0, 1))))
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var ErrInvalidQuery = errors.New("невалидный поисковый запрос")
+
+// Веса совпадений в названии и описании, как веса A и B в ts_rank PostgreSQL.
+const (
+	titleWeight       = 1.0
+	descriptionWeight = 0.4
+)
+
+// SearchFilter параметры полнотекстового поиска по названию и описанию событий.
+// Событие должно содержать все слова Query. Нулевые From и To не ограничивают
+// выборку, иначе серия событий должна пересекаться с интервалом [From, To).
+// UserID ограничивает выборку событиями, которые видны пользователю.
+type SearchFilter struct {
+	UserID string
+	// Calendars календари, которыми UserID владеет или к которым имеет общий
+	// доступ. Заполняется хранилищем перед Match.
+	Calendars []string
+	Query     string
+	From      time.Time
+	To        time.Time
+	Limit     int
+}
+
+// SearchResult найденное событие, больший Rank соответствует более релевантному событию.
+// Значения Rank сравнимы только в пределах одного ответа хранилища.
+type SearchResult struct {
+	Event Event
+	Rank  float64
+}
+
+// SearchTerms разбивает текст на слова в нижнем регистре без повторов.
+// Словом считается последовательность букв и цифр.
+func SearchTerms(text string) []string {
+	all := words(text)
+	result := make([]string, 0, len(all))
+	for _, w := range all {
+		if !slices.Contains(result, w) {
+			result = append(result, w)
+		}
+	}
+
+	return result
+}
+
+// SearchRank возвращает релевантность события для слов terms или 0, если
+// событие содержит не все слова. Совпадение в названии весит больше, чем в описании.
+func (e Event) SearchRank(terms []string) float64 {
+	title, description := countTerms(e.Title), countTerms(e.Description)
+
+	rank := 0.0
+	for _, term := range terms {
+		if title[term] == 0 && description[term] == 0 {
+			return 0
+		}
+
+		rank += titleWeight*float64(title[term]) + descriptionWeight*float64(description[term])
+	}
+
+	return rank
+}
+
+func countTerms(text string) map[string]int {
+	result := make(map[string]int)
+	for _, w := range words(text) {
+		result[w]++
+	}
+
+	return result
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Match проверяет пользователя и интервал, текст проверяется SearchRank.
+func (f SearchFilter) Match(e Event) bool {
+	if f.UserID != "" && !e.IsVisibleTo(f.UserID) && !slices.Contains(f.Calendars, e.CalendarID) {
+		return false
+	}
+
+	if !f.To.IsZero() && !e.StartAt.Before(f.To) {
+		return false
+	}
+
+	return f.From.IsZero() || e.IsInfinite() || !e.SeriesEnd().Before(f.From)
+}
+
+// PageLimit возвращает число результатов с учетом значения по умолчанию и максимума.
+func (f SearchFilter) PageLimit() int {
+	return ListFilter{Limit: f.Limit}.PageLimit()
+}
+
+// SortSearchResults упорядочивает результаты по убыванию релевантности, при
+// равной релевантности новые события идут первыми, и оставляет первые limit.
+func SortSearchResults(results []SearchResult, limit int) []SearchResult {
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if !a.Event.StartAt.Equal(b.Event.StartAt) {
+			return a.Event.StartAt.After(b.Event.StartAt)
+		}
+
+		return a.Event.ID < b.Event.ID
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchTerms(t *testing.T) {
+	require.Equal(t, []string{"обзор", "бюджета", "q1", "2024"}, SearchTerms("Обзор бюджета: Q1-2024, обзор"))
+	require.Equal(t, []string{}, SearchTerms(" ,.- "))
+}
+
+func TestSearchRank(t *testing.T) {
+	e := Event{Title: "Budget review", Description: "Quarterly budget"}
+
+	require.Equal(t, 0.0, e.SearchRank([]string{"budget", "plan"}))
+	require.Greater(t, e.SearchRank([]string{"budget"}), e.SearchRank([]string{"quarterly"}))
+	require.Greater(t, e.SearchRank([]string{"quarterly"}), 0.0)
+}
+
+func TestSearchFilterMatch(t *testing.T) {
+	start := time.Date(2024, 4, 10, 10, 0, 0, 0, time.UTC)
+	e := Event{StartAt: start, EndAt: start.Add(time.Hour), AuthorID: "1"}
+	series := e
+	series.Recurrence = &Recurrence{Frequency: FrequencyWeekly, Interval: 1, Count: 3}
+
+	tests := []struct {
+		name     string
+		filter   SearchFilter
+		event    Event
+		excepted bool
+	}{
+		{"no range", SearchFilter{}, e, true},
+		{"another user", SearchFilter{UserID: "2"}, e, false},
+		{"before range", SearchFilter{From: start.AddDate(0, 0, 1)}, e, false},
+		{"after range", SearchFilter{To: start}, e, false},
+		{"in range", SearchFilter{From: start.Add(-time.Hour), To: start.Add(time.Hour)}, e, true},
+		{"series in range", SearchFilter{From: start.AddDate(0, 0, 13)}, series, true},
+		{"series before range", SearchFilter{From: start.AddDate(0, 0, 15)}, series, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.excepted, tc.filter.Match(tc.event))
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+// InviteAttendees добавляет участников события, время новых участников должно быть свободно.
+func (s *storage) InviteAttendees(
+	ctx context.Context,
+	userID string,
+	id string,
+	attendees []internalStorage.Attendee,
+) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		event, err := s.getEvent(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if event.AuthorID != userID {
+			return internalStorage.ErrAccessDenied
+		}
+
+		_, added := internalStorage.MergeAttendees(event.Attendees, attendees)
+		isBusy, err := s.isBusy(ctx, tx, event, added)
+		if err != nil {
+			return err
+		}
+
+		if isBusy {
+			return internalStorage.ErrDateBusy
+		}
+
+		query := `INSERT INTO event_attendees (event_id, user_id, role, status)
+		VALUES (?1, ?2, ?3, ?4)
+		ON CONFLICT (event_id, user_id) DO UPDATE SET role = excluded.role`
+		for _, a := range attendees {
+			_, err := tx.ExecContext(ctx, query, id, a.UserID, string(a.Role), string(internalStorage.RSVPNeedsAction))
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE events SET version = version + 1 WHERE id = ?`, id)
+
+		return err
+	})
+}
+
+// RespondInvitation сохраняет ответ участника. Отменить отказ можно, только
+// если время участника свободно.
+func (s *storage) RespondInvitation(
+	ctx context.Context,
+	userID string,
+	id string,
+	status internalStorage.RSVPStatus,
+) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		event, err := s.getEvent(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		attendee, ok := event.Attendee(userID)
+		if !ok {
+			return internalStorage.ErrNotInvited
+		}
+
+		if attendee.Status == internalStorage.RSVPDeclined && status != internalStorage.RSVPDeclined {
+			isBusy, err := s.isBusy(ctx, tx, event, []string{userID})
+			if err != nil {
+				return err
+			}
+
+			if isBusy {
+				return internalStorage.ErrDateBusy
+			}
+		}
+
+		query := `UPDATE event_attendees SET status = ?3 WHERE event_id = ?1 AND user_id = ?2`
+		if _, err := tx.ExecContext(ctx, query, id, userID, string(status)); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE events SET version = version + 1 WHERE id = ?`, id)
+
+		return err
+	})
+}
+
+// getEvent возвращает событие вне корзины вместе с участниками и напоминаниями.
+func (s *storage) getEvent(ctx context.Context, q querier, id string) (internalStorage.Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM events WHERE id = ? AND deleted_at IS NULL`
+
+	event, err := scanEvent(q.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return internalStorage.Event{}, internalStorage.ErrEventNotFound
+	}
+	if err != nil {
+		return internalStorage.Event{}, err
+	}
+
+	events := []internalStorage.Event{event}
+	if err := loadRelated(ctx, q, events); err != nil {
+		return internalStorage.Event{}, err
+	}
+
+	return events[0], nil
+}
+
+// loadAttendees заполняет участников событий одним запросом.
+func loadAttendees(ctx context.Context, q querier, events []internalStorage.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+
+	list, err := jsonList(ids)
+	if err != nil {
+		return err
+	}
+
+	query := `SELECT event_id, user_id, role, status
+	FROM event_attendees
+	WHERE event_id IN (SELECT value FROM json_each(?))
+	ORDER BY event_id, user_id`
+
+	rows, err := q.QueryContext(ctx, query, list)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	attendees := make(map[string][]internalStorage.Attendee)
+	for rows.Next() {
+		var (
+			eventID      string
+			a            internalStorage.Attendee
+			role, status string
+		)
+		if err := rows.Scan(&eventID, &a.UserID, &role, &status); err != nil {
+			return err
+		}
+
+		a.Role, a.Status = internalStorage.AttendeeRole(role), internalStorage.RSVPStatus(status)
+		attendees[eventID] = append(attendees[eventID], a)
+	}
+
+	for i := range events {
+		events[i].Attendees = attendees[events[i].ID]
+	}
+
+	return rows.Err()
+}
+
+// jsonList кодирует список для передачи одним параметром в json_each.
+func jsonList[T any](values []T) (string, error) {
+	data, err := json.Marshal(values)
+
+	return string(data), err
+}
+
+// visibleTo условие на события, которые видны пользователю из параметра ?n
+// как автору, участнику или через общий доступ к календарю.
+func visibleTo(n int) string {
+	return fmt.Sprintf(
+		`(author_id = ?%d OR id IN (SELECT event_id FROM event_attendees WHERE user_id = ?%d) OR calendar_id IN (%s))`,
+		n,
+		n,
+		readableCalendars(n),
+	)
+}
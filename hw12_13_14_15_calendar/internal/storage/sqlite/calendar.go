@@ -0,0 +1,287 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *storage) CreateCalendar(ctx context.Context, calendar internalStorage.Calendar) error {
+	query := `INSERT INTO calendars (id, name, owner_id) VALUES (?, ?, ?)`
+	_, err := s.db.ExecContext(ctx, query, calendar.ID, calendar.Name, calendar.OwnerID)
+
+	return err
+}
+
+// UpdateCalendar изменяет название календаря, доступы сохраняются.
+func (s *storage) UpdateCalendar(ctx context.Context, calendar internalStorage.Calendar) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := s.ownCalendar(ctx, tx, calendar.OwnerID, calendar.ID); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, `UPDATE calendars SET name = ?2 WHERE id = ?1`, calendar.ID, calendar.Name)
+
+		return err
+	})
+}
+
+// DeleteCalendar удаляет календарь без событий вне корзины вместе с доступами,
+// события из корзины переходят в личный календарь автора.
+func (s *storage) DeleteCalendar(ctx context.Context, userID string, id string) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := s.ownCalendar(ctx, tx, userID, id); err != nil {
+			return err
+		}
+
+		var hasEvents bool
+		query := `SELECT EXISTS (SELECT 1 FROM events WHERE calendar_id = ? AND deleted_at IS NULL)`
+		if err := tx.QueryRowContext(ctx, query, id).Scan(&hasEvents); err != nil {
+			return err
+		}
+
+		if hasEvents {
+			return internalStorage.ErrCalendarNotEmpty
+		}
+
+		for _, query := range []string{
+			`UPDATE events SET calendar_id = NULL WHERE calendar_id = ?`,
+			`DELETE FROM calendar_shares WHERE calendar_id = ?`,
+			`DELETE FROM calendars WHERE id = ?`,
+		} {
+			if _, err := tx.ExecContext(ctx, query, id); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *storage) GetCalendar(ctx context.Context, userID string, id string) (internalStorage.Calendar, error) {
+	calendar, err := s.getCalendar(ctx, s.db, id)
+	if err != nil {
+		return internalStorage.Calendar{}, err
+	}
+
+	if !calendar.CanRead(userID) {
+		return internalStorage.Calendar{}, internalStorage.ErrCalendarAccessDenied
+	}
+
+	return calendar, nil
+}
+
+// ListCalendars возвращает собственные и открытые пользователю календари.
+func (s *storage) ListCalendars(ctx context.Context, userID string) ([]internalStorage.Calendar, error) {
+	query := `SELECT id, name, owner_id
+	FROM calendars
+	WHERE id IN (` + readableCalendars(1) + `)
+	ORDER BY name, id`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]internalStorage.Calendar, 0)
+	for rows.Next() {
+		var calendar internalStorage.Calendar
+		if err := rows.Scan(&calendar.ID, &calendar.Name, &calendar.OwnerID); err != nil {
+			return nil, err
+		}
+
+		result = append(result, calendar)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	return result, loadShares(ctx, s.db, result)
+}
+
+// ShareCalendar открывает или изменяет доступ пользователя к календарю.
+func (s *storage) ShareCalendar(
+	ctx context.Context,
+	userID string,
+	id string,
+	share internalStorage.CalendarShare,
+) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := s.ownCalendar(ctx, tx, userID, id); err != nil {
+			return err
+		}
+
+		query := `INSERT INTO calendar_shares (calendar_id, user_id, permission)
+		VALUES (?, ?, ?)
+		ON CONFLICT (calendar_id, user_id) DO UPDATE SET permission = excluded.permission`
+		_, err := tx.ExecContext(ctx, query, id, share.UserID, string(share.Permission))
+
+		return err
+	})
+}
+
+// UnshareCalendar закрывает доступ пользователя targetID к календарю.
+func (s *storage) UnshareCalendar(ctx context.Context, userID string, id string, targetID string) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := s.ownCalendar(ctx, tx, userID, id); err != nil {
+			return err
+		}
+
+		query := `DELETE FROM calendar_shares WHERE calendar_id = ? AND user_id = ?`
+		_, err := tx.ExecContext(ctx, query, id, targetID)
+
+		return err
+	})
+}
+
+// ownCalendar возвращает календарь, если им владеет пользователь.
+func (s *storage) ownCalendar(
+	ctx context.Context,
+	q querier,
+	userID string,
+	id string,
+) (internalStorage.Calendar, error) {
+	calendar, err := s.getCalendar(ctx, q, id)
+	if err != nil {
+		return internalStorage.Calendar{}, err
+	}
+
+	if calendar.OwnerID != userID {
+		return internalStorage.Calendar{}, internalStorage.ErrCalendarAccessDenied
+	}
+
+	return calendar, nil
+}
+
+// getCalendar возвращает календарь вместе с доступами.
+func (s *storage) getCalendar(ctx context.Context, q querier, id string) (internalStorage.Calendar, error) {
+	var calendar internalStorage.Calendar
+	query := `SELECT id, name, owner_id FROM calendars WHERE id = ?`
+
+	err := q.QueryRowContext(ctx, query, id).Scan(&calendar.ID, &calendar.Name, &calendar.OwnerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return internalStorage.Calendar{}, internalStorage.ErrCalendarNotFound
+	}
+	if err != nil {
+		return internalStorage.Calendar{}, err
+	}
+
+	calendars := []internalStorage.Calendar{calendar}
+	if err := loadShares(ctx, q, calendars); err != nil {
+		return internalStorage.Calendar{}, err
+	}
+
+	return calendars[0], nil
+}
+
+// loadShares заполняет доступы календарей одним запросом.
+func loadShares(ctx context.Context, q querier, calendars []internalStorage.Calendar) error {
+	if len(calendars) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(calendars))
+	for _, c := range calendars {
+		ids = append(ids, c.ID)
+	}
+
+	list, err := jsonList(ids)
+	if err != nil {
+		return err
+	}
+
+	query := `SELECT calendar_id, user_id, permission
+	FROM calendar_shares
+	WHERE calendar_id IN (SELECT value FROM json_each(?))
+	ORDER BY calendar_id, user_id`
+
+	rows, err := q.QueryContext(ctx, query, list)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	shares := make(map[string][]internalStorage.CalendarShare)
+	for rows.Next() {
+		var (
+			calendarID, permission string
+			share                  internalStorage.CalendarShare
+		)
+		if err := rows.Scan(&calendarID, &share.UserID, &permission); err != nil {
+			return err
+		}
+
+		share.Permission = internalStorage.Permission(permission)
+		shares[calendarID] = append(shares[calendarID], share)
+	}
+
+	for i := range calendars {
+		calendars[i].Shares = shares[calendars[i].ID]
+	}
+
+	return rows.Err()
+}
+
+// calendarIDs возвращает календари, события которых видны пользователю.
+func calendarIDs(ctx context.Context, q querier, userID string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, readableCalendars(1), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		result = append(result, id)
+	}
+
+	return result, rows.Err()
+}
+
+// isVisible проверяет, что событие видно пользователю как автору, участнику
+// или через общий доступ к календарю.
+func (s *storage) isVisible(
+	ctx context.Context,
+	q querier,
+	event internalStorage.Event,
+	userID string,
+) (bool, error) {
+	if event.IsVisibleTo(userID) {
+		return true, nil
+	}
+
+	if event.CalendarID == "" {
+		return false, nil
+	}
+
+	var isVisible bool
+	query := `SELECT EXISTS (SELECT 1 FROM (` + readableCalendars(1) + `) c WHERE c.id = ?2)`
+	err := q.QueryRowContext(ctx, query, userID, event.CalendarID).Scan(&isVisible)
+
+	return isVisible, err
+}
+
+// readableCalendars запрос календарей, которыми владеет пользователь из
+// параметра ?n или к которым он имеет общий доступ.
+func readableCalendars(n int) string {
+	return fmt.Sprintf(
+		`SELECT id FROM calendars WHERE owner_id = ?%d UNION SELECT calendar_id FROM calendar_shares WHERE user_id = ?%d`,
+		n,
+		n,
+	)
+}
+
+// calendarArg возвращает значение колонки calendar_id, личный календарь хранится как NULL.
+func calendarArg(event internalStorage.Event) sql.NullString {
+	return sql.NullString{String: event.CalendarID, Valid: event.CalendarID != ""}
+}
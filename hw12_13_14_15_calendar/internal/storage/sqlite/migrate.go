@@ -0,0 +1,35 @@
+package sqlitestorage
+
+import (
+	"database/sql"
+
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+	"github.com/pressly/goose"
+)
+
+const migrationPath = "./migrations/sqlite/"
+
+func init() {
+	internalStorage.Register(Type, internalStorage.Backend{
+		New: func(_ internalStorage.Options) internalStorage.Storage {
+			return New()
+		},
+		Migrate:       Migrate,
+		MigrationPath: migrationPath,
+	})
+}
+
+// Migrate применяет миграции goose из каталога dir.
+func Migrate(dsn string, dir string) error {
+	if err := goose.SetDialect(DialectName); err != nil {
+		return err
+	}
+
+	db, err := sql.Open(DriverName, WithDefaults(dsn))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return goose.Up(db, dir)
+}
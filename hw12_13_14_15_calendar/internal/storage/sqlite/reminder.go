@@ -0,0 +1,179 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+// RemindersForNotification возвращает напоминания, время срабатывания которых
+// наступило. Повторения разворачиваются в Go, для обычных событий время
+// срабатывания проверяется в запросе.
+func (s *storage) RemindersForNotification(ctx context.Context) ([]internalStorage.Reminder, error) {
+	now := time.Now()
+	query := `SELECT ` + eventColumns + `, r.remind_before, r.notified_until
+	FROM events
+	JOIN event_reminders r ON r.event_id = events.id
+	WHERE deleted_at IS NULL AND (
+	    (rrule IS NULL AND start_at - r.remind_before <= ?1
+	        AND (r.notified_until IS NULL OR r.notified_until < start_at - r.remind_before))
+	    OR (rrule IS NOT NULL
+	        AND (recurrence_end IS NULL OR r.notified_until IS NULL OR recurrence_end > r.notified_until))
+	)`
+
+	rows, err := s.db.QueryContext(ctx, query, unixTime(now))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]internalStorage.Reminder, 0)
+	for rows.Next() {
+		var (
+			offset        int64
+			notifiedUntil sql.NullInt64
+		)
+		event, err := scanEvent(rows, &offset, &notifiedUntil)
+		if err != nil {
+			return nil, err
+		}
+
+		if r, ok := event.DueReminder(time.Duration(offset), fromNullTime(notifiedUntil), now); ok {
+			result = append(result, r)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, loadReminderEvents(ctx, s.db, result)
+}
+
+// MarkRemindersSent запоминает время срабатывания отправленных напоминаний.
+func (s *storage) MarkRemindersSent(ctx context.Context, reminders []internalStorage.Reminder) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		query := `UPDATE event_reminders SET notified_until = ?3
+		WHERE event_id = ?1 AND remind_before = ?2 AND (notified_until IS NULL OR notified_until < ?3)`
+		for _, r := range reminders {
+			if _, err := tx.ExecContext(ctx, query, r.Event.ID, int64(r.Offset), unixTime(r.Date())); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// saveReminders приводит напоминания события к event.Reminders. Отметки об
+// отправке сохраняются для смещений, которые остались у события.
+func saveReminders(ctx context.Context, q querier, event internalStorage.Event) error {
+	offsets := make([]int64, 0, len(event.Reminders))
+	for _, offset := range event.Reminders {
+		offsets = append(offsets, int64(offset))
+	}
+
+	list, err := jsonList(offsets)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM event_reminders
+	WHERE event_id = ?1 AND remind_before NOT IN (SELECT value FROM json_each(?2))`
+	if _, err := q.ExecContext(ctx, query, event.ID, list); err != nil {
+		return err
+	}
+
+	query = `INSERT INTO event_reminders (event_id, remind_before) VALUES (?1, ?2)
+	ON CONFLICT (event_id, remind_before) DO NOTHING`
+	for _, offset := range offsets {
+		if _, err := q.ExecContext(ctx, query, event.ID, offset); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadRelated заполняет участников, напоминания и теги событий.
+func loadRelated(ctx context.Context, q querier, events []internalStorage.Event) error {
+	if err := loadAttendees(ctx, q, events); err != nil {
+		return err
+	}
+
+	if err := loadReminders(ctx, q, events); err != nil {
+		return err
+	}
+
+	if err := loadTags(ctx, q, events); err != nil {
+		return err
+	}
+
+	return loadResources(ctx, q, events)
+}
+
+// loadReminderEvents заполняет связанные данные событий из напоминаний.
+func loadReminderEvents(ctx context.Context, q querier, reminders []internalStorage.Reminder) error {
+	events := make([]internalStorage.Event, 0, len(reminders))
+	for _, r := range reminders {
+		events = append(events, r.Event)
+	}
+
+	if err := loadRelated(ctx, q, events); err != nil {
+		return err
+	}
+
+	for i := range reminders {
+		reminders[i].Event = events[i]
+	}
+
+	return nil
+}
+
+// loadReminders заполняет смещения напоминаний событий одним запросом.
+func loadReminders(ctx context.Context, q querier, events []internalStorage.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+
+	list, err := jsonList(ids)
+	if err != nil {
+		return err
+	}
+
+	query := `SELECT event_id, remind_before
+	FROM event_reminders
+	WHERE event_id IN (SELECT value FROM json_each(?))
+	ORDER BY event_id, remind_before DESC`
+
+	rows, err := q.QueryContext(ctx, query, list)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	reminders := make(map[string][]time.Duration)
+	for rows.Next() {
+		var (
+			eventID string
+			offset  int64
+		)
+		if err := rows.Scan(&eventID, &offset); err != nil {
+			return err
+		}
+
+		reminders[eventID] = append(reminders[eventID], time.Duration(offset))
+	}
+
+	for i := range events {
+		events[i].Reminders = reminders[events[i].ID]
+	}
+
+	return rows.Err()
+}
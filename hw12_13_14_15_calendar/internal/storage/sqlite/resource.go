@@ -0,0 +1,306 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+const resourceColumns = `id, name, kind, capacity, owner_id`
+
+func (s *storage) CreateResource(ctx context.Context, resource internalStorage.Resource) error {
+	query := `INSERT INTO resources (` + resourceColumns + `) VALUES (?, ?, ?, ?, ?)`
+	_, err := s.db.ExecContext(
+		ctx,
+		query,
+		resource.ID,
+		resource.Name,
+		string(resource.Kind),
+		resource.Capacity,
+		resource.OwnerID,
+	)
+
+	return err
+}
+
+// UpdateResource изменяет название, тип и вместимость ресурса.
+func (s *storage) UpdateResource(ctx context.Context, resource internalStorage.Resource) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := ownResource(ctx, tx, resource.OwnerID, resource.ID); err != nil {
+			return err
+		}
+
+		query := `UPDATE resources SET name = ?2, kind = ?3, capacity = ?4 WHERE id = ?1`
+		_, err := tx.ExecContext(ctx, query, resource.ID, resource.Name, string(resource.Kind), resource.Capacity)
+
+		return err
+	})
+}
+
+// DeleteResource удаляет ресурс, не забронированный событиями вне корзины,
+// и снимает его бронь с событий из корзины.
+func (s *storage) DeleteResource(ctx context.Context, userID string, id string) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := ownResource(ctx, tx, userID, id); err != nil {
+			return err
+		}
+
+		var inUse bool
+		query := `SELECT EXISTS (
+		    SELECT 1 FROM event_resources r JOIN events e ON e.id = r.event_id
+		    WHERE r.resource_id = ? AND e.deleted_at IS NULL
+		)`
+		if err := tx.QueryRowContext(ctx, query, id).Scan(&inUse); err != nil {
+			return err
+		}
+
+		if inUse {
+			return internalStorage.ErrResourceInUse
+		}
+
+		for _, query := range []string{
+			`DELETE FROM event_resources WHERE resource_id = ?`,
+			`DELETE FROM resources WHERE id = ?`,
+		} {
+			if _, err := tx.ExecContext(ctx, query, id); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *storage) GetResource(ctx context.Context, id string) (internalStorage.Resource, error) {
+	return getResource(ctx, s.db, id)
+}
+
+func (s *storage) ListResources(ctx context.Context) ([]internalStorage.Resource, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+resourceColumns+` FROM resources ORDER BY name, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]internalStorage.Resource, 0)
+	for rows.Next() {
+		resource, err := scanResource(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, resource)
+	}
+
+	return result, rows.Err()
+}
+
+func (s *storage) ResourceEvents(
+	ctx context.Context,
+	id string,
+	from time.Time,
+	to time.Time,
+) ([]internalStorage.Event, error) {
+	if _, err := getResource(ctx, s.db, id); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + eventColumns + `
+	FROM events
+	WHERE deleted_at IS NULL AND start_at < ?2 AND (
+	    (rrule IS NULL AND end_at >= ?1)
+	    OR (rrule IS NOT NULL AND (recurrence_end IS NULL OR recurrence_end >= ?1))
+	) AND id IN (SELECT event_id FROM event_resources WHERE resource_id = ?3)`
+
+	rows, err := s.db.QueryContext(ctx, query, unixTime(from), unixTime(to), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]internalStorage.Event, 0)
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := loadRelated(ctx, s.db, events); err != nil {
+		return nil, err
+	}
+
+	result := make([]internalStorage.Event, 0, len(events))
+	for _, event := range events {
+		result = append(result, event.OccurrencesOverlapping(from, to)...)
+	}
+
+	return result, nil
+}
+
+// ownResource проверяет, что ресурс существует и создан пользователем.
+func ownResource(ctx context.Context, q querier, userID string, id string) error {
+	resource, err := getResource(ctx, q, id)
+	if err != nil {
+		return err
+	}
+
+	if resource.OwnerID != userID {
+		return internalStorage.ErrResourceAccessDenied
+	}
+
+	return nil
+}
+
+// checkResources проверяет, что ресурсы события существуют и не забронированы
+// пересекающимися событиями вне корзины.
+func (s *storage) checkResources(ctx context.Context, q querier, event internalStorage.Event) error {
+	if len(event.Resources) == 0 {
+		return nil
+	}
+
+	list, err := jsonList(event.Resources)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	query := `SELECT count(*) FROM resources WHERE id IN (SELECT value FROM json_each(?))`
+	if err := q.QueryRowContext(ctx, query, list).Scan(&count); err != nil {
+		return err
+	}
+
+	if count != len(event.Resources) {
+		return internalStorage.ErrResourceNotFound
+	}
+
+	query = `SELECT ` + eventColumns + `
+	FROM events
+	WHERE id != ?3 AND deleted_at IS NULL AND (?2 IS NULL OR start_at <= ?2) AND (
+	    (rrule IS NULL AND end_at >= ?1)
+	    OR (rrule IS NOT NULL AND (recurrence_end IS NULL OR recurrence_end >= ?1))
+	) AND id IN (SELECT event_id FROM event_resources WHERE resource_id IN (SELECT value FROM json_each(?4)))`
+	rows, err := q.QueryContext(ctx, query, unixTime(event.StartAt), seriesEndArg(event), event.ID, list)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		other, err := scanEvent(rows)
+		if err != nil {
+			return err
+		}
+
+		if internalStorage.Conflicts(event, other) {
+			return internalStorage.ErrResourceBusy
+		}
+	}
+
+	return rows.Err()
+}
+
+// saveResources приводит бронь ресурсов события к event.Resources.
+func saveResources(ctx context.Context, q querier, event internalStorage.Event) error {
+	resources := event.Resources
+	if resources == nil {
+		resources = []string{}
+	}
+
+	list, err := jsonList(resources)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM event_resources
+	WHERE event_id = ?1 AND resource_id NOT IN (SELECT value FROM json_each(?2))`
+	if _, err := q.ExecContext(ctx, query, event.ID, list); err != nil {
+		return err
+	}
+
+	query = `INSERT INTO event_resources (event_id, resource_id) VALUES (?1, ?2)
+	ON CONFLICT (event_id, resource_id) DO NOTHING`
+	for _, id := range resources {
+		if _, err := q.ExecContext(ctx, query, event.ID, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadResources заполняет забронированные ресурсы событий одним запросом.
+func loadResources(ctx context.Context, q querier, events []internalStorage.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+
+	list, err := jsonList(ids)
+	if err != nil {
+		return err
+	}
+
+	query := `SELECT event_id, resource_id
+	FROM event_resources
+	WHERE event_id IN (SELECT value FROM json_each(?))
+	ORDER BY event_id, resource_id`
+
+	rows, err := q.QueryContext(ctx, query, list)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	resources := make(map[string][]string)
+	for rows.Next() {
+		var eventID, resourceID string
+		if err := rows.Scan(&eventID, &resourceID); err != nil {
+			return err
+		}
+
+		resources[eventID] = append(resources[eventID], resourceID)
+	}
+
+	for i := range events {
+		events[i].Resources = resources[events[i].ID]
+	}
+
+	return rows.Err()
+}
+
+func getResource(ctx context.Context, q querier, id string) (internalStorage.Resource, error) {
+	query := `SELECT ` + resourceColumns + ` FROM resources WHERE id = ?`
+
+	resource, err := scanResource(q.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return internalStorage.Resource{}, internalStorage.ErrResourceNotFound
+	}
+
+	return resource, err
+}
+
+func scanResource(row scanner) (internalStorage.Resource, error) {
+	var (
+		resource internalStorage.Resource
+		kind     string
+	)
+	if err := row.Scan(&resource.ID, &resource.Name, &kind, &resource.Capacity, &resource.OwnerID); err != nil {
+		return internalStorage.Resource{}, err
+	}
+	resource.Kind = internalStorage.ResourceKind(kind)
+
+	return resource, nil
+}
@@ -0,0 +1,60 @@
+package sqlitestorage
+
+import (
+	"context"
+	"fmt"
+
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+// SearchEvents отбирает в базе события, содержащие все слова запроса как
+// подстроки, а релевантность и совпадение целых слов проверяет SearchRank.
+// Отдельного индекса для поиска в SQLite нет.
+func (s *storage) SearchEvents(
+	ctx context.Context,
+	filter internalStorage.SearchFilter,
+) ([]internalStorage.SearchResult, error) {
+	terms := internalStorage.SearchTerms(filter.Query)
+	if len(terms) == 0 {
+		return nil, internalStorage.ErrInvalidQuery
+	}
+
+	args := make([]any, 0, len(terms)+3)
+	where := `deleted_at IS NULL`
+	for _, term := range terms {
+		args = append(args, term)
+		where += fmt.Sprintf(
+			` AND (%s(title, ?%d) OR %s(description, ?%d))`,
+			containsFoldFunc, len(args), containsFoldFunc, len(args),
+		)
+	}
+	if filter.UserID != "" {
+		args = append(args, filter.UserID)
+		where += ` AND ` + visibleTo(len(args))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, unixTime(filter.To))
+		where += fmt.Sprintf(` AND start_at < ?%d`, len(args))
+	}
+	if !filter.From.IsZero() {
+		args = append(args, unixTime(filter.From))
+		where += fmt.Sprintf(` AND (
+		    (rrule IS NULL AND end_at >= ?%d)
+		    OR (rrule IS NOT NULL AND (recurrence_end IS NULL OR recurrence_end >= ?%d))
+		)`, len(args), len(args))
+	}
+
+	events, err := s.scanEvents(ctx, s.db, `SELECT `+eventColumns+` FROM events WHERE `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]internalStorage.SearchResult, 0, len(events))
+	for _, event := range events {
+		if rank := event.SearchRank(terms); rank > 0 {
+			result = append(result, internalStorage.SearchResult{Event: event, Rank: rank})
+		}
+	}
+
+	return internalStorage.SortSearchResults(result, filter.PageLimit()), nil
+}
@@ -0,0 +1,796 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+	"modernc.org/sqlite" // Регистрирует драйвер DriverName.
+)
+
+const (
+	Type string = "sqlite"
+
+	// DriverName имя драйвера database/sql, DialectName - диалект goose.
+	DriverName  = "sqlite"
+	DialectName = "sqlite3"
+
+	eventColumns = `id, title, start_at, end_at, description, author_id, rrule, exdates, version, deleted_at, time_zone,
+	calendar_id, color, location, latitude, longitude, meeting_url, all_day`
+
+	exdatesSeparator = ","
+	busyTimeout      = "busy_timeout(5000)"
+
+	// containsFoldFunc ищет подстроку без учета регистра.
+	containsFoldFunc = "calendar_contains_fold"
+)
+
+// querier общий интерфейс соединения и транзакции.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+type storage struct {
+	db *sql.DB
+}
+
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction(
+		containsFoldFunc,
+		2,
+		func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			s, _ := args[0].(string)
+			substr, _ := args[1].(string)
+
+			return strings.Contains(strings.ToLower(s), strings.ToLower(substr)), nil
+		},
+	)
+}
+
+func New() internalStorage.Storage {
+	return &storage{}
+}
+
+// Connect открывает базу по DSN вида "file:calendar.db". Если в DSN не указано
+// иное, транзакции начинаются с BEGIN IMMEDIATE, а ожидание блокировки
+// ограничено 5 секундами.
+func (s *storage) Connect(ctx context.Context, dsn string) error {
+	db, err := sql.Open(DriverName, WithDefaults(dsn))
+	if err != nil {
+		return err
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return err
+	}
+	s.db = db
+
+	return nil
+}
+
+func (s *storage) Close(_ context.Context) error {
+	return s.db.Close()
+}
+
+// WithDefaults добавляет в DSN параметры блокировок, если они не заданы.
+func WithDefaults(dsn string) string {
+	path, rawQuery, _ := strings.Cut(dsn, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return dsn
+	}
+
+	if query.Get("_txlock") == "" {
+		query.Set("_txlock", "immediate")
+	}
+
+	hasBusyTimeout := false
+	for _, p := range query["_pragma"] {
+		if strings.HasPrefix(strings.ToLower(p), "busy_timeout") {
+			hasBusyTimeout = true
+		}
+	}
+	if !hasBusyTimeout {
+		query.Add("_pragma", busyTimeout)
+	}
+
+	return path + "?" + query.Encode()
+}
+
+// CreateEvent проверяет занятость времени и добавляет событие в одной транзакции,
+// BEGIN IMMEDIATE не дает параллельным запросам занять то же время.
+func (s *storage) CreateEvent(ctx context.Context, event internalStorage.Event) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		return s.createEvent(ctx, tx, event)
+	})
+}
+
+func (s *storage) createEvent(ctx context.Context, q querier, event internalStorage.Event) error {
+	isBusy, err := s.isDateBusy(ctx, q, event)
+	if err != nil {
+		return err
+	}
+
+	if isBusy {
+		return internalStorage.ErrDateBusy
+	}
+
+	if err := s.checkResources(ctx, q, event); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO events
+    (id, title, start_at, end_at, description, author_id, rrule, exdates, recurrence_end, version, time_zone,
+     calendar_id, color, location, latitude, longitude, meeting_url, all_day)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	rrule, exdates, recurrenceEnd := recurrenceArgs(event)
+	latitude, longitude := coordinatesArgs(event)
+	_, err = q.ExecContext(
+		ctx,
+		query,
+		event.ID,
+		event.Title,
+		unixTime(event.StartAt),
+		unixTime(event.EndAt),
+		event.Description,
+		event.AuthorID,
+		rrule,
+		exdates,
+		recurrenceEnd,
+		internalStorage.InitialVersion,
+		event.TimeZone,
+		calendarArg(event),
+		event.Color,
+		event.Place.Text,
+		latitude,
+		longitude,
+		event.MeetingURL,
+		event.AllDay,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := saveReminders(ctx, q, event); err != nil {
+		return err
+	}
+
+	if err := saveTags(ctx, q, event); err != nil {
+		return err
+	}
+
+	return saveResources(ctx, q, event)
+}
+
+func (s *storage) UpdateEvent(ctx context.Context, event internalStorage.Event) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		return s.updateEvent(ctx, tx, event)
+	})
+}
+
+func (s *storage) updateEvent(ctx context.Context, q querier, event internalStorage.Event) error {
+	if err := s.checkAuthor(ctx, q, event.AuthorID, event.ID, event.Version); err != nil {
+		return err
+	}
+
+	// Участники не изменяются, но занятость проверяется и для них.
+	events := []internalStorage.Event{event}
+	if err := loadAttendees(ctx, q, events); err != nil {
+		return err
+	}
+	event = events[0]
+
+	isBusy, err := s.isDateBusy(ctx, q, event)
+	if err != nil {
+		return err
+	}
+
+	if isBusy {
+		return internalStorage.ErrDateBusy
+	}
+
+	if err := s.checkResources(ctx, q, event); err != nil {
+		return err
+	}
+
+	query := `UPDATE events
+	SET title=?2, start_at=?3, end_at=?4, description=?5, author_id=?6,
+	    rrule=?7, exdates=?8, recurrence_end=?9, time_zone=?10, calendar_id=?11, color=?12,
+	    location=?13, latitude=?14, longitude=?15, meeting_url=?16, all_day=?17, version=version+1
+	WHERE id = ?1`
+
+	rrule, exdates, recurrenceEnd := recurrenceArgs(event)
+	latitude, longitude := coordinatesArgs(event)
+	_, err = q.ExecContext(
+		ctx,
+		query,
+		event.ID,
+		event.Title,
+		unixTime(event.StartAt),
+		unixTime(event.EndAt),
+		event.Description,
+		event.AuthorID,
+		rrule,
+		exdates,
+		recurrenceEnd,
+		event.TimeZone,
+		calendarArg(event),
+		event.Color,
+		event.Place.Text,
+		latitude,
+		longitude,
+		event.MeetingURL,
+		event.AllDay,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := saveReminders(ctx, q, event); err != nil {
+		return err
+	}
+
+	if err := saveTags(ctx, q, event); err != nil {
+		return err
+	}
+
+	return saveResources(ctx, q, event)
+}
+
+// DeleteEvent перемещает событие в корзину.
+func (s *storage) DeleteEvent(ctx context.Context, userID string, id string, version int64) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := s.checkAuthor(ctx, tx, userID, id, version); err != nil {
+			return err
+		}
+
+		query := `UPDATE events SET deleted_at = ?2, version = version + 1 WHERE id = ?1`
+		_, err := tx.ExecContext(ctx, query, id, unixTime(time.Now()))
+
+		return err
+	})
+}
+
+// RestoreEvent возвращает событие из корзины, если его время не занято.
+func (s *storage) RestoreEvent(ctx context.Context, userID string, id string) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		query := `SELECT ` + eventColumns + ` FROM events WHERE id = ? AND deleted_at IS NOT NULL`
+
+		event, err := scanEvent(tx.QueryRowContext(ctx, query, id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return internalStorage.ErrEventNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if event.AuthorID != userID {
+			return internalStorage.ErrAccessDenied
+		}
+
+		events := []internalStorage.Event{event}
+		if err := loadAttendees(ctx, tx, events); err != nil {
+			return err
+		}
+
+		if err := loadResources(ctx, tx, events); err != nil {
+			return err
+		}
+
+		isBusy, err := s.isDateBusy(ctx, tx, events[0])
+		if err != nil {
+			return err
+		}
+
+		if isBusy {
+			return internalStorage.ErrDateBusy
+		}
+
+		if err := s.checkResources(ctx, tx, events[0]); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE events SET deleted_at = NULL, version = version + 1 WHERE id = ?`, id)
+
+		return err
+	})
+}
+
+// ListDeleted возвращает события из корзины, последние удаленные - первыми.
+func (s *storage) ListDeleted(ctx context.Context, userID string) ([]internalStorage.Event, error) {
+	query := `SELECT ` + eventColumns + `
+	FROM events
+	WHERE author_id = ? AND deleted_at IS NOT NULL
+	ORDER BY deleted_at DESC`
+
+	return s.scanEvents(ctx, s.db, query, userID)
+}
+
+// PurgeDeleted окончательно удаляет события, перемещенные в корзину раньше before.
+func (s *storage) PurgeDeleted(ctx context.Context, before time.Time) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		for _, table := range []string{"event_attendees", "event_reminders", "event_tags", "event_resources"} {
+			query := `DELETE FROM ` + table + ` WHERE event_id IN (SELECT id FROM events WHERE deleted_at < ?)`
+			if _, err := tx.ExecContext(ctx, query, unixTime(before)); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.ExecContext(ctx, `DELETE FROM events WHERE deleted_at < ?`, unixTime(before))
+
+		return err
+	})
+}
+
+func (s *storage) GetEvent(ctx context.Context, userID string, id string) (internalStorage.Event, error) {
+	event, err := s.getEvent(ctx, s.db, id)
+	if err != nil {
+		return internalStorage.Event{}, err
+	}
+
+	isVisible, err := s.isVisible(ctx, s.db, event, userID)
+	if err != nil {
+		return internalStorage.Event{}, err
+	}
+
+	if !isVisible {
+		return internalStorage.Event{}, internalStorage.ErrAccessDenied
+	}
+
+	return event, nil
+}
+
+func (s *storage) EventsDay(ctx context.Context, userID string, date time.Time) ([]internalStorage.Event, error) {
+	from, to := internalStorage.DayRange(date)
+
+	return s.eventsByDates(ctx, userID, from, to)
+}
+
+func (s *storage) EventsWeek(ctx context.Context, userID string, date time.Time) ([]internalStorage.Event, error) {
+	from, to := internalStorage.WeekRange(date)
+
+	return s.eventsByDates(ctx, userID, from, to)
+}
+
+func (s *storage) EventsMonth(ctx context.Context, userID string, date time.Time) ([]internalStorage.Event, error) {
+	from, to := internalStorage.MonthRange(date)
+
+	return s.eventsByDates(ctx, userID, from, to)
+}
+
+// ClearOldEvents перемещает в корзину события, закончившиеся более года назад.
+func (s *storage) ClearOldEvents(ctx context.Context) error {
+	query := `UPDATE events SET deleted_at = ?2, version = version + 1
+	WHERE deleted_at IS NULL AND (
+	    (rrule IS NULL AND end_at < ?1)
+	    OR (rrule IS NOT NULL AND recurrence_end < ?1)
+	)`
+
+	now := time.Now()
+	_, err := s.db.ExecContext(ctx, query, unixTime(now.AddDate(-1, 0, 0)), unixTime(now))
+	return err
+}
+
+// eventsByDates возвращает события и повторения, пересекающиеся с интервалом
+// [from, to), собирая страницы ListEvents.
+func (s *storage) eventsByDates(
+	ctx context.Context,
+	userID string,
+	from time.Time,
+	to time.Time,
+) ([]internalStorage.Event, error) {
+	filter := internalStorage.ListFilter{UserID: userID, From: from, To: to, Overlapping: true}
+
+	return internalStorage.CollectEvents(ctx, s.ListEvents, filter)
+}
+
+// ListEvents выбирает обычные события страницей с сортировкой и курсором на
+// стороне базы, а повторяющиеся разворачивает в Go. Итоговая страница
+// собирается storage.PageEvents.
+func (s *storage) ListEvents(
+	ctx context.Context,
+	filter internalStorage.ListFilter,
+) (internalStorage.Page, error) {
+	cursor, err := internalStorage.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return internalStorage.Page{}, err
+	}
+
+	if filter.UserID != "" {
+		filter.Calendars, err = calendarIDs(ctx, s.db, filter.UserID)
+		if err != nil {
+			return internalStorage.Page{}, err
+		}
+	}
+
+	from := filter.Start(cursor)
+	where, args := listConditions(filter, from)
+
+	query := `SELECT ` + eventColumns + `
+	FROM events
+	WHERE ` + where + ` AND rrule IS NOT NULL AND (recurrence_end IS NULL OR recurrence_end >= ?1)`
+
+	recurring, err := s.queryEvents(ctx, query, args, filter, from)
+	if err != nil {
+		return internalStorage.Page{}, err
+	}
+
+	query = `SELECT ` + eventColumns + `
+	FROM events
+	WHERE ` + where + ` AND rrule IS NULL AND ` + startCondition(filter)
+	if !cursor.IsZero() {
+		args = append(args, unixTime(cursor.StartAt), cursor.ID)
+		query += fmt.Sprintf(` AND (start_at, id) > (?%d, ?%d)`, len(args)-1, len(args))
+	}
+	query += fmt.Sprintf(` ORDER BY start_at, id LIMIT %d`, filter.PageLimit()+1)
+
+	events, err := s.queryEvents(ctx, query, args, filter, from)
+	if err != nil {
+		return internalStorage.Page{}, err
+	}
+
+	return internalStorage.PageEvents(append(events, recurring...), filter)
+}
+
+// startCondition возвращает условие на начало обычных событий: начало в интервале
+// или, при Overlapping, пересечение с ним. ?1 - начало интервала.
+func startCondition(filter internalStorage.ListFilter) string {
+	if filter.Overlapping {
+		return `(end_at > ?1 OR start_at >= ?1)`
+	}
+
+	return `start_at >= ?1`
+}
+
+// listConditions возвращает условия фильтра, ?1 и ?2 - границы интервала.
+// Встроенные LIKE и lower в SQLite учитывают регистр только для ASCII,
+// поэтому текст ищется функцией containsFoldFunc.
+func listConditions(filter internalStorage.ListFilter, from time.Time) (string, []any) {
+	args := []any{unixTime(from), unixTime(filter.To)}
+	where := `deleted_at IS NULL AND start_at < ?2`
+	if filter.UserID != "" {
+		args = append(args, filter.UserID)
+		where += ` AND ` + visibleTo(len(args))
+	}
+	if filter.CalendarID != "" {
+		args = append(args, filter.CalendarID)
+		where += fmt.Sprintf(` AND calendar_id = ?%d`, len(args))
+	}
+	if filter.Query != "" {
+		args = append(args, filter.Query)
+		where += fmt.Sprintf(
+			` AND (%s(title, ?%d) OR %s(description, ?%d))`,
+			containsFoldFunc, len(args), containsFoldFunc, len(args),
+		)
+	}
+	if filter.HasNotification != nil {
+		if *filter.HasNotification {
+			where += ` AND id IN (SELECT event_id FROM event_reminders)`
+		} else {
+			where += ` AND id NOT IN (SELECT event_id FROM event_reminders)`
+		}
+	}
+
+	return where, args
+}
+
+// queryEvents возвращает повторения выбранных событий, которые могут попасть
+// в выборку filter начиная с from.
+func (s *storage) queryEvents(
+	ctx context.Context,
+	query string,
+	args []any,
+	filter internalStorage.ListFilter,
+	from time.Time,
+) ([]internalStorage.Event, error) {
+	events, err := s.scanEvents(ctx, s.db, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]internalStorage.Event, 0)
+	for _, event := range events {
+		result = append(result, filter.Occurrences(event, from)...)
+	}
+
+	return result, nil
+}
+
+// scanEvents выбирает события вместе с участниками.
+func (s *storage) scanEvents(
+	ctx context.Context,
+	q querier,
+	query string,
+	args ...any,
+) ([]internalStorage.Event, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]internalStorage.Event, 0)
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// В транзакции следующий запрос возможен только после закрытия строк.
+	rows.Close()
+
+	return result, loadRelated(ctx, q, result)
+}
+
+// isDateBusy проверяет занятость времени всех участников события.
+func (s *storage) isDateBusy(ctx context.Context, q querier, event internalStorage.Event) (bool, error) {
+	return s.isBusy(ctx, q, event, event.Participants())
+}
+
+// isBusy выбирает кандидатов среди событий, занимающих время пользователей users,
+// с нестрогими границами, точная проверка пересечения выполняется
+// storage.Conflicts так же, как в других хранилищах. Время автора проверяется
+// в календаре события, остальных участников - во всех календарях.
+func (s *storage) isBusy(
+	ctx context.Context,
+	q querier,
+	event internalStorage.Event,
+	users []string,
+) (bool, error) {
+	if len(users) == 0 {
+		return false, nil
+	}
+
+	author := sql.NullString{}
+	others := make([]string, 0, len(users))
+	for _, userID := range users {
+		if userID == event.AuthorID {
+			author = sql.NullString{String: event.AuthorID, Valid: true}
+			continue
+		}
+		others = append(others, userID)
+	}
+
+	list, err := jsonList(others)
+	if err != nil {
+		return false, err
+	}
+
+	query := `SELECT ` + eventColumns + `
+	FROM events
+	WHERE id != ?3 AND deleted_at IS NULL AND (?2 IS NULL OR start_at <= ?2) AND (
+	    (rrule IS NULL AND end_at >= ?1)
+	    OR (rrule IS NOT NULL AND (recurrence_end IS NULL OR recurrence_end >= ?1))
+	) AND (
+	    (calendar_id IS ?6 AND (
+	        author_id = ?7
+	        OR id IN (SELECT event_id FROM event_attendees WHERE user_id = ?7 AND status <> ?5)
+	    ))
+	    OR author_id IN (SELECT value FROM json_each(?4))
+	    OR id IN (
+	        SELECT event_id FROM event_attendees
+	        WHERE user_id IN (SELECT value FROM json_each(?4)) AND status <> ?5
+	    )
+	)`
+	rows, err := q.QueryContext(
+		ctx,
+		query,
+		unixTime(event.StartAt),
+		seriesEndArg(event),
+		event.ID,
+		list,
+		string(internalStorage.RSVPDeclined),
+		calendarArg(event),
+		author,
+	)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		other, err := scanEvent(rows)
+		if err != nil {
+			return false, err
+		}
+
+		if internalStorage.Conflicts(event, other) {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
+
+// checkAuthor проверяет, что событие существует вне корзины, принадлежит пользователю
+// и имеет ожидаемую версию (0 - любую).
+func (s *storage) checkAuthor(ctx context.Context, q querier, userID string, id string, version int64) error {
+	row := q.QueryRowContext(ctx, `SELECT author_id, version FROM events WHERE id = ? AND deleted_at IS NULL`, id)
+
+	var (
+		authorID       string
+		currentVersion int64
+	)
+	err := row.Scan(&authorID, &currentVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		return internalStorage.ErrEventNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if authorID != userID {
+		return internalStorage.ErrAccessDenied
+	}
+
+	if version != 0 && version != currentVersion {
+		return internalStorage.ErrVersionConflict
+	}
+
+	return nil
+}
+
+func (s *storage) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// scanEvent читает строку с колонками eventColumns, extra - дополнительные колонки после них.
+func scanEvent(row scanner, extra ...any) (internalStorage.Event, error) {
+	event := internalStorage.Event{}
+
+	var (
+		startAt, endAt int64
+		rrule          sql.NullString
+		exdates        sql.NullString
+		deletedAt      sql.NullInt64
+		calendarID     sql.NullString
+		latitude       sql.NullFloat64
+		longitude      sql.NullFloat64
+	)
+
+	dest := []any{
+		&event.ID,
+		&event.Title,
+		&startAt,
+		&endAt,
+		&event.Description,
+		&event.AuthorID,
+		&rrule,
+		&exdates,
+		&event.Version,
+		&deletedAt,
+		&event.TimeZone,
+		&calendarID,
+		&event.Color,
+		&event.Place.Text,
+		&latitude,
+		&longitude,
+		&event.MeetingURL,
+		&event.AllDay,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return event, err
+	}
+
+	event.StartAt = fromUnixTime(startAt)
+	event.EndAt = fromUnixTime(endAt)
+	event.DeletedAt = fromNullTime(deletedAt)
+	event.CalendarID = calendarID.String
+	if latitude.Valid && longitude.Valid {
+		event.Place.Coordinates = &internalStorage.Coordinates{Latitude: latitude.Float64, Longitude: longitude.Float64}
+	}
+
+	if rrule.Valid {
+		recurrence, err := internalStorage.ParseRecurrence(rrule.String)
+		if err != nil {
+			return event, err
+		}
+		if recurrence != nil && exdates.String != "" {
+			for _, v := range strings.Split(exdates.String, exdatesSeparator) {
+				t, err := time.Parse(time.RFC3339Nano, v)
+				if err != nil {
+					return event, err
+				}
+				recurrence.Exceptions = append(recurrence.Exceptions, t)
+			}
+		}
+		event.Recurrence = recurrence
+	}
+
+	return event.Localize(), nil
+}
+
+// coordinatesArgs возвращает значения колонок latitude и longitude, без координат - NULL.
+func coordinatesArgs(event internalStorage.Event) (sql.NullFloat64, sql.NullFloat64) {
+	c := event.Place.Coordinates
+	if c == nil {
+		return sql.NullFloat64{}, sql.NullFloat64{}
+	}
+
+	return sql.NullFloat64{Float64: c.Latitude, Valid: true}, sql.NullFloat64{Float64: c.Longitude, Valid: true}
+}
+
+// seriesEndArg возвращает верхнюю границу начала событий, с которыми может
+// пересечься event. У бесконечной серии границы нет: SeriesEnd ограничен
+// RecurrenceHorizon, а повторения продолжаются и после него.
+func seriesEndArg(event internalStorage.Event) sql.NullInt64 {
+	if event.IsInfinite() {
+		return sql.NullInt64{}
+	}
+
+	return nullTime(event.SeriesEnd())
+}
+
+func recurrenceArgs(event internalStorage.Event) (sql.NullString, sql.NullString, sql.NullInt64) {
+	if !event.IsRecurring() {
+		return sql.NullString{}, sql.NullString{}, sql.NullInt64{}
+	}
+
+	rrule := sql.NullString{String: event.Recurrence.String(), Valid: true}
+	exdates := make([]string, 0, len(event.Recurrence.Exceptions))
+	for _, t := range event.Recurrence.Exceptions {
+		exdates = append(exdates, t.UTC().Format(time.RFC3339Nano))
+	}
+
+	end := sql.NullInt64{}
+	if !event.IsInfinite() {
+		end = nullTime(event.SeriesEnd())
+	}
+
+	return rrule, sql.NullString{String: strings.Join(exdates, exdatesSeparator), Valid: true}, end
+}
+
+// unixTime переводит время в число наносекунд с начала эпохи Unix,
+// в таком виде время хранится в колонках INTEGER.
+func unixTime(t time.Time) int64 {
+	return t.UnixNano()
+}
+
+func fromUnixTime(n int64) time.Time {
+	return time.Unix(0, n).UTC()
+}
+
+func nullTime(t time.Time) sql.NullInt64 {
+	if t.IsZero() {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{Int64: unixTime(t), Valid: true}
+}
+
+func fromNullTime(n sql.NullInt64) time.Time {
+	if !n.Valid {
+		return time.Time{}
+	}
+
+	return fromUnixTime(n.Int64)
+}
@@ -0,0 +1,61 @@
+package sqlitestorage
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+const testMigrationPath = "../../../migrations/sqlite"
+
+func newTestStorage(t *testing.T) func() internalStorage.Storage {
+	t.Helper()
+
+	dir := t.TempDir()
+	n := 0
+
+	return func() internalStorage.Storage {
+		n++
+		dsn := "file:" + filepath.Join(dir, strconv.Itoa(n)+".db")
+
+		if err := Migrate(dsn, testMigrationPath); err != nil {
+			panic(err)
+		}
+
+		s := New()
+		if err := s.Connect(context.Background(), dsn); err != nil {
+			panic(err)
+		}
+		t.Cleanup(func() {
+			_ = s.Close(context.Background())
+		})
+
+		return s
+	}
+}
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, newTestStorage(t))
+}
+
+func TestWithDefaults(t *testing.T) {
+	results := []struct {
+		dsn      string
+		excepted string
+	}{
+		{"calendar.db", "calendar.db?_pragma=busy_timeout%285000%29&_txlock=immediate"},
+		{
+			"file:calendar.db?_txlock=deferred&_pragma=busy_timeout(100)",
+			"file:calendar.db?_pragma=busy_timeout%28100%29&_txlock=deferred",
+		},
+	}
+
+	for _, v := range results {
+		require.Equal(t, v.excepted, WithDefaults(v.dsn))
+	}
+}
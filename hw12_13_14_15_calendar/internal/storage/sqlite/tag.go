@@ -0,0 +1,79 @@
+package sqlitestorage
+
+import (
+	"context"
+
+	internalStorage "github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+)
+
+// saveTags приводит теги события к event.Tags.
+func saveTags(ctx context.Context, q querier, event internalStorage.Event) error {
+	tags := event.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	list, err := jsonList(tags)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM event_tags WHERE event_id = ?1 AND tag NOT IN (SELECT value FROM json_each(?2))`
+	if _, err := q.ExecContext(ctx, query, event.ID, list); err != nil {
+		return err
+	}
+
+	query = `INSERT INTO event_tags (event_id, tag) VALUES (?1, ?2)
+	ON CONFLICT (event_id, tag) DO NOTHING`
+	for _, tag := range tags {
+		if _, err := q.ExecContext(ctx, query, event.ID, tag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadTags заполняет теги событий одним запросом.
+func loadTags(ctx context.Context, q querier, events []internalStorage.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+
+	list, err := jsonList(ids)
+	if err != nil {
+		return err
+	}
+
+	query := `SELECT event_id, tag
+	FROM event_tags
+	WHERE event_id IN (SELECT value FROM json_each(?))
+	ORDER BY event_id, tag`
+
+	rows, err := q.QueryContext(ctx, query, list)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var eventID, tag string
+		if err := rows.Scan(&eventID, &tag); err != nil {
+			return err
+		}
+
+		tags[eventID] = append(tags[eventID], tag)
+	}
+
+	for i := range events {
+		events[i].Tags = tags[events[i].ID]
+	}
+
+	return rows.Err()
+}
@@ -7,20 +7,81 @@ import (
 )
 
 var (
-	ErrDateBusy      = errors.New("данное время уже занято другим событием")
-	ErrEventNotFound = errors.New("событие не найдено")
+	ErrDateBusy        = errors.New("данное время уже занято другим событием")
+	ErrEventNotFound   = errors.New("событие не найдено")
+	ErrAccessDenied    = errors.New("событие принадлежит другому пользователю")
+	ErrVersionConflict = errors.New("событие было изменено другим запросом")
 )
 
+// InitialVersion версия, с которой CreateEvent сохраняет событие.
+const InitialVersion int64 = 1
+
+// Storage хранилище событий, календарей, ресурсов и журнала изменений.
 type Storage interface {
+	// CreateEvent сохраняет событие, занимающее время автора, участников и ресурсов.
 	CreateEvent(ctx context.Context, event Event) error
+	// UpdateEvent изменяет событие от имени автора.
 	UpdateEvent(ctx context.Context, event Event) error
-	DeleteEvent(ctx context.Context, id string) error
-	EventsDay(ctx context.Context, date time.Time) ([]Event, error)
-	EventsWeek(ctx context.Context, date time.Time) ([]Event, error)
-	EventsMonth(ctx context.Context, date time.Time) ([]Event, error)
-	EventsForNotification(ctx context.Context) ([]Event, error)
-	ClearNotificationDates(ctx context.Context, id []string) error
+	// DeleteEvent перемещает событие автора в корзину.
+	DeleteEvent(ctx context.Context, userID string, id string, version int64) error
+	// RestoreEvent возвращает событие из корзины.
+	RestoreEvent(ctx context.Context, userID string, id string) error
+	// InviteAttendees приглашает участников в событие автора.
+	InviteAttendees(ctx context.Context, userID string, id string, attendees []Attendee) error
+	// RespondInvitation сохраняет ответ участника на приглашение.
+	RespondInvitation(ctx context.Context, userID string, id string, status RSVPStatus) error
+	// ListDeleted возвращает события пользователя из корзины.
+	ListDeleted(ctx context.Context, userID string) ([]Event, error)
+	// PurgeDeleted окончательно удаляет события, попавшие в корзину раньше before.
+	PurgeDeleted(ctx context.Context, before time.Time) error
+	// GetEvent возвращает событие, видимое пользователю.
+	GetEvent(ctx context.Context, userID string, id string) (Event, error)
+	// EventsDay возвращает события и повторения, пересекающиеся с DayRange.
+	EventsDay(ctx context.Context, userID string, date time.Time) ([]Event, error)
+	// EventsWeek возвращает события и повторения, пересекающиеся с WeekRange.
+	EventsWeek(ctx context.Context, userID string, date time.Time) ([]Event, error)
+	// EventsMonth возвращает события и повторения, пересекающиеся с MonthRange.
+	EventsMonth(ctx context.Context, userID string, date time.Time) ([]Event, error)
+	// ListEvents возвращает страницу событий по фильтру.
+	ListEvents(ctx context.Context, filter ListFilter) (Page, error)
+	// SearchEvents ищет события по словам в названии и описании.
+	SearchEvents(ctx context.Context, filter SearchFilter) ([]SearchResult, error)
+	// RemindersForNotification возвращает сработавшие и еще не отправленные напоминания.
+	RemindersForNotification(ctx context.Context) ([]Reminder, error)
+	// MarkRemindersSent отмечает отправку напоминаний.
+	MarkRemindersSent(ctx context.Context, reminders []Reminder) error
+	// ClearOldEvents перемещает в корзину события, закончившиеся более года назад.
 	ClearOldEvents(ctx context.Context) error
+	// AddAuditRecord дополняет журнал изменений событий.
+	AddAuditRecord(ctx context.Context, record AuditRecord) error
+	// EventHistory возвращает журнал изменений события.
+	EventHistory(ctx context.Context, eventID string) ([]AuditRecord, error)
+	// CreateCalendar сохраняет календарь.
+	CreateCalendar(ctx context.Context, calendar Calendar) error
+	// UpdateCalendar переименовывает календарь владельца.
+	UpdateCalendar(ctx context.Context, calendar Calendar) error
+	// DeleteCalendar удаляет календарь владельца без событий вне корзины.
+	DeleteCalendar(ctx context.Context, userID string, id string) error
+	// GetCalendar возвращает календарь, доступный пользователю.
+	GetCalendar(ctx context.Context, userID string, id string) (Calendar, error)
+	// ListCalendars возвращает календари пользователя и открытые ему календари.
+	ListCalendars(ctx context.Context, userID string) ([]Calendar, error)
+	// ShareCalendar открывает или изменяет доступ пользователя к календарю.
+	ShareCalendar(ctx context.Context, userID string, id string, share CalendarShare) error
+	// UnshareCalendar закрывает доступ пользователя к календарю.
+	UnshareCalendar(ctx context.Context, userID string, id string, targetID string) error
+	// CreateResource сохраняет ресурс.
+	CreateResource(ctx context.Context, resource Resource) error
+	// UpdateResource изменяет ресурс.
+	UpdateResource(ctx context.Context, resource Resource) error
+	// DeleteResource удаляет ресурс, не забронированный событиями вне корзины.
+	DeleteResource(ctx context.Context, userID string, id string) error
+	// GetResource возвращает ресурс.
+	GetResource(ctx context.Context, id string) (Resource, error)
+	// ListResources возвращает все ресурсы.
+	ListResources(ctx context.Context) ([]Resource, error)
+	// ResourceEvents возвращает события, бронирующие ресурс в [from, to).
+	ResourceEvents(ctx context.Context, id string, from time.Time, to time.Time) ([]Event, error)
 	Connect(ctx context.Context, dsn string) error
 	Close(ctx context.Context) error
 }
@@ -0,0 +1,134 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// RunDateBusy проверяет, что хранилище возвращает storage.ErrDateBusy
+// только для пересекающихся полуинтервалов, в том числе для многодневных
+// событий и событий, переходящих через полночь. newStorage должен возвращать
+// пустое подключенное хранилище.
+func RunDateBusy(t *testing.T, newStorage func() storage.Storage) {
+	t.Helper()
+
+	day := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(days int, hour, minute int) time.Time {
+		return day.AddDate(0, 0, days).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	span := func(start, end time.Time) storage.Event {
+		return storage.Event{Title: "test", StartAt: start, EndAt: end, AuthorID: testUserID}
+	}
+
+	results := []struct {
+		name     string
+		existing storage.Event
+		event    storage.Event
+		busy     bool
+	}{
+		{"same interval", span(at(0, 10, 0), at(0, 12, 0)), span(at(0, 10, 0), at(0, 12, 0)), true},
+		{"contains existing", span(at(0, 10, 0), at(0, 12, 0)), span(at(0, 9, 0), at(0, 13, 0)), true},
+		{"inside existing", span(at(0, 10, 0), at(0, 12, 0)), span(at(0, 10, 30), at(0, 11, 0)), true},
+		{"overlaps start", span(at(0, 10, 0), at(0, 12, 0)), span(at(0, 9, 0), at(0, 10, 1)), true},
+		{"overlaps end", span(at(0, 10, 0), at(0, 12, 0)), span(at(0, 11, 59), at(0, 13, 0)), true},
+		{"adjacent before", span(at(0, 10, 0), at(0, 12, 0)), span(at(0, 9, 0), at(0, 10, 0)), false},
+		{"adjacent after", span(at(0, 10, 0), at(0, 12, 0)), span(at(0, 12, 0), at(0, 13, 0)), false},
+		{"across midnight", span(at(0, 23, 0), at(1, 1, 0)), span(at(1, 0, 30), at(1, 2, 0)), true},
+		{"after midnight event", span(at(0, 23, 0), at(1, 1, 0)), span(at(1, 1, 0), at(1, 2, 0)), false},
+		{"inside multi-day", span(at(0, 10, 0), at(3, 10, 0)), span(at(2, 12, 0), at(2, 13, 0)), true},
+		{"contains multi-day", span(at(1, 10, 0), at(3, 10, 0)), span(at(0, 10, 0), at(5, 10, 0)), true},
+		{"after multi-day", span(at(0, 10, 0), at(3, 10, 0)), span(at(3, 10, 0), at(3, 11, 0)), false},
+		{"zero duration inside", span(at(0, 10, 0), at(0, 12, 0)), span(at(0, 10, 0), at(0, 10, 0)), true},
+		{"zero duration at end", span(at(0, 10, 0), at(0, 12, 0)), span(at(0, 12, 0), at(0, 12, 0)), false},
+	}
+
+	for _, v := range results {
+		v := v
+		t.Run(v.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newStorage()
+
+			v.existing.ID = uuid.NewString()
+			v.event.ID = uuid.NewString()
+			require.NoError(t, s.CreateEvent(ctx, v.existing))
+			requireBusy(t, v.busy, s.CreateEvent(ctx, v.event))
+
+			if !v.busy {
+				require.NoError(t, s.DeleteEvent(ctx, testUserID, v.event.ID, 0))
+			}
+
+			// Перенос другого события должен проверяться так же, как создание.
+			moved := v.existing
+			moved.ID = uuid.NewString()
+			moved.StartAt = v.existing.StartAt.AddDate(1, 0, 0)
+			moved.EndAt = v.existing.EndAt.AddDate(1, 0, 0)
+			require.NoError(t, s.CreateEvent(ctx, moved))
+
+			moved.StartAt, moved.EndAt = v.event.StartAt, v.event.EndAt
+			requireBusy(t, v.busy, s.UpdateEvent(ctx, moved))
+		})
+	}
+
+	t.Run("infinite series past horizon", func(t *testing.T) {
+		ctx := context.Background()
+		s := newStorage()
+
+		recurrence, err := storage.ParseRecurrence("FREQ=DAILY")
+		require.NoError(t, err)
+		series := span(time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC), time.Date(2023, 1, 2, 11, 0, 0, 0, time.UTC))
+		series.ID = uuid.NewString()
+		series.Recurrence = recurrence
+		require.NoError(t, s.CreateEvent(ctx, series))
+
+		late := time.Date(2026, 6, 1, 10, 30, 0, 0, time.UTC)
+		for _, start := range []time.Time{late.AddDate(-3, 0, 0), late} {
+			event := span(start, start.Add(time.Hour))
+			event.ID = uuid.NewString()
+			requireBusy(t, true, s.CreateEvent(ctx, event))
+		}
+
+		// Обе серии бесконечны и начинаются позже RecurrenceHorizon друг от друга.
+		weekly, err := storage.ParseRecurrence("FREQ=WEEKLY")
+		require.NoError(t, err)
+		event := span(late, late.Add(time.Hour))
+		event.ID = uuid.NewString()
+		event.Recurrence = weekly
+		requireBusy(t, true, s.CreateEvent(ctx, event))
+
+		// Событие после RecurrenceHorizon уже сохранено, серия добавляется второй.
+		s = newStorage()
+		event = span(late, late.Add(time.Hour))
+		event.ID = uuid.NewString()
+		require.NoError(t, s.CreateEvent(ctx, event))
+		requireBusy(t, true, s.CreateEvent(ctx, series))
+	})
+
+	t.Run("another user", func(t *testing.T) {
+		ctx := context.Background()
+		s := newStorage()
+
+		event := span(at(0, 10, 0), at(0, 12, 0))
+		event.ID = uuid.NewString()
+		require.NoError(t, s.CreateEvent(ctx, event))
+
+		event.ID = uuid.NewString()
+		event.AuthorID = otherUserID
+		require.NoError(t, s.CreateEvent(ctx, event))
+	})
+}
+
+func requireBusy(t *testing.T, busy bool, err error) {
+	t.Helper()
+
+	if !busy {
+		require.NoError(t, err)
+		return
+	}
+
+	requireErrorIs(t, err, storage.ErrDateBusy)
+}
@@ -0,0 +1,1185 @@
+// Package storagetest содержит общие тесты, которые должна проходить
+// любая реализация storage.Storage.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Al-Sher/hw_otus/hw12_13_14_15_calendar/internal/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testUserID  = "a3e5a8a4-1b1c-4f5e-9d3b-4f7a0c2d6e01"
+	otherUserID = "a3e5a8a4-1b1c-4f5e-9d3b-4f7a0c2d6e02"
+	thirdUserID = "a3e5a8a4-1b1c-4f5e-9d3b-4f7a0c2d6e03"
+)
+
+// Run проверяет все методы storage.Storage. newStorage вызывается в каждом
+// подтесте и должен возвращать пустое хранилище с выполненным Connect.
+func Run(t *testing.T, newStorage func() storage.Storage) {
+	t.Helper()
+
+	t.Run("crud", func(t *testing.T) {
+		testCRUD(t, newStorage())
+	})
+	t.Run("listings", func(t *testing.T) {
+		testListings(t, newStorage())
+	})
+	t.Run("recurring listings", func(t *testing.T) {
+		testRecurringListings(t, newStorage())
+	})
+	t.Run("multi-day listings", func(t *testing.T) {
+		testMultiDayListings(t, newStorage())
+	})
+	t.Run("time zones", func(t *testing.T) {
+		testTimeZones(t, newStorage())
+	})
+	t.Run("list events", func(t *testing.T) {
+		testListEvents(t, newStorage())
+	})
+	t.Run("search", func(t *testing.T) {
+		testSearch(t, newStorage())
+	})
+	t.Run("notifications", func(t *testing.T) {
+		testNotifications(t, newStorage())
+	})
+	t.Run("clear old events", func(t *testing.T) {
+		testClearOldEvents(t, newStorage())
+	})
+	t.Run("trash", func(t *testing.T) {
+		testTrash(t, newStorage())
+	})
+	t.Run("audit", func(t *testing.T) {
+		testAudit(t, newStorage())
+	})
+	t.Run("attendees", func(t *testing.T) {
+		testAttendees(t, newStorage())
+	})
+	t.Run("calendars", func(t *testing.T) {
+		testCalendars(t, newStorage())
+	})
+	t.Run("resources", func(t *testing.T) {
+		testResources(t, newStorage())
+	})
+	t.Run("date busy", func(t *testing.T) {
+		RunDateBusy(t, newStorage)
+	})
+	t.Run("close", func(t *testing.T) {
+		require.NoError(t, newStorage().Close(context.Background()))
+	})
+}
+
+func testCRUD(t *testing.T, s storage.Storage) {
+	t.Helper()
+
+	ctx := context.Background()
+	start := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	event := newEvent(start, time.Hour)
+	event.Description = "test description"
+	event.Tags = []string{"backend", "работа"}
+	event.Color = "#ff8800"
+	event.Place = storage.Location{
+		Text:        "Переговорная 3",
+		Coordinates: &storage.Coordinates{Latitude: 55.7558, Longitude: 37.6173},
+	}
+	event.MeetingURL = "https://meet.example.com/abc"
+
+	require.NoError(t, s.CreateEvent(ctx, event))
+
+	events, err := s.EventsDay(ctx, testUserID, start)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(events))
+	requireEvent(t, event, events[0])
+
+	t.Run("get", func(t *testing.T) {
+		actual, err := s.GetEvent(ctx, testUserID, event.ID)
+		require.NoError(t, err)
+		requireEvent(t, event, actual)
+		require.Equal(t, storage.InitialVersion, actual.Version)
+
+		_, err = s.GetEvent(ctx, otherUserID, event.ID)
+		requireErrorIs(t, err, storage.ErrAccessDenied)
+
+		_, err = s.GetEvent(ctx, testUserID, uuid.NewString())
+		requireErrorIs(t, err, storage.ErrEventNotFound)
+	})
+	t.Run("update", func(t *testing.T) {
+		event.Title = "test with update"
+		event.StartAt = start.AddDate(0, 0, 1)
+		event.EndAt = event.StartAt.Add(30 * time.Minute)
+		event.Tags = []string{"работа"}
+		event.Color = ""
+		event.Place = storage.Location{Text: "Онлайн"}
+		event.MeetingURL = ""
+		require.NoError(t, s.UpdateEvent(ctx, event))
+
+		events, err := s.EventsDay(ctx, testUserID, start)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(events))
+
+		events, err = s.EventsDay(ctx, testUserID, event.StartAt)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(events))
+		requireEvent(t, event, events[0])
+	})
+	t.Run("version", func(t *testing.T) {
+		actual, err := s.GetEvent(ctx, testUserID, event.ID)
+		require.NoError(t, err)
+		require.Equal(t, storage.InitialVersion+1, actual.Version)
+
+		stale := actual
+		stale.Version = storage.InitialVersion
+		stale.Title = "stale update"
+		requireErrorIs(t, s.UpdateEvent(ctx, stale), storage.ErrVersionConflict)
+		requireErrorIs(t, s.DeleteEvent(ctx, testUserID, event.ID, stale.Version), storage.ErrVersionConflict)
+
+		actual.Title = "versioned update"
+		require.NoError(t, s.UpdateEvent(ctx, actual))
+
+		updated, err := s.GetEvent(ctx, testUserID, event.ID)
+		require.NoError(t, err)
+		require.Equal(t, actual.Title, updated.Title)
+		require.Equal(t, actual.Version+1, updated.Version)
+	})
+	t.Run("update not found", func(t *testing.T) {
+		notFound := newEvent(start, time.Hour)
+		requireErrorIs(t, s.UpdateEvent(ctx, notFound), storage.ErrEventNotFound)
+	})
+	t.Run("another user", func(t *testing.T) {
+		other := event
+		other.AuthorID = otherUserID
+		requireErrorIs(t, s.UpdateEvent(ctx, other), storage.ErrAccessDenied)
+		requireErrorIs(t, s.DeleteEvent(ctx, otherUserID, event.ID, 0), storage.ErrAccessDenied)
+
+		events, err := s.EventsDay(ctx, otherUserID, event.StartAt)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(events))
+	})
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, s.DeleteEvent(ctx, testUserID, event.ID, 0))
+
+		events, err := s.EventsDay(ctx, testUserID, event.StartAt)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(events))
+
+		requireErrorIs(t, s.DeleteEvent(ctx, testUserID, event.ID, 0), storage.ErrEventNotFound)
+
+		_, err = s.GetEvent(ctx, testUserID, event.ID)
+		requireErrorIs(t, err, storage.ErrEventNotFound)
+	})
+}
+
+func testListings(t *testing.T, s storage.Storage) {
+	t.Helper()
+
+	ctx := context.Background()
+	day := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	starts := []time.Time{
+		day,
+		day.Add(23*time.Hour + 30*time.Minute),
+		day.AddDate(0, 0, 1),
+		day.AddDate(0, 0, 6).Add(23 * time.Hour),
+		day.AddDate(0, 0, 7),
+		day.AddDate(0, 0, 29).Add(23 * time.Hour),
+		day.AddDate(0, 1, 0),
+	}
+
+	events := make([]storage.Event, 0, len(starts))
+	for _, start := range starts {
+		event := newEvent(start, 30*time.Minute)
+		require.NoError(t, s.CreateEvent(ctx, event))
+		events = append(events, event)
+	}
+
+	other := newEvent(day.Add(12*time.Hour), time.Hour)
+	other.AuthorID = otherUserID
+	require.NoError(t, s.CreateEvent(ctx, other))
+
+	// Границы периода выравниваются по началу суток.
+	date := day.Add(15 * time.Hour)
+	results := []struct {
+		name     string
+		list     func(context.Context, string, time.Time) ([]storage.Event, error)
+		excepted []storage.Event
+	}{
+		{"day", s.EventsDay, events[:2]},
+		{"week", s.EventsWeek, events[:4]},
+		{"month", s.EventsMonth, events[:6]},
+	}
+
+	for _, v := range results {
+		t.Run(v.name, func(t *testing.T) {
+			actual, err := v.list(ctx, testUserID, date)
+			require.NoError(t, err)
+			require.ElementsMatch(t, ids(v.excepted), ids(actual))
+		})
+	}
+}
+
+func testRecurringListings(t *testing.T, s storage.Storage) {
+	t.Helper()
+
+	ctx := context.Background()
+	// Четверг.
+	start := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	recurrence, err := storage.ParseRecurrence("FREQ=WEEKLY;BYDAY=MO,TH;COUNT=4")
+	require.NoError(t, err)
+
+	event := newEvent(start, 15*time.Minute)
+	event.Recurrence = recurrence
+	require.NoError(t, s.CreateEvent(ctx, event))
+
+	events, err := s.EventsWeek(ctx, testUserID, start)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(events))
+
+	events, err = s.EventsMonth(ctx, testUserID, start)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(events))
+
+	events, err = s.EventsDay(ctx, testUserID, start.AddDate(0, 0, 11))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(events))
+	require.Equal(t, event.ID, events[0].ID)
+	require.True(t, start.AddDate(0, 0, 11).Equal(events[0].StartAt))
+	require.Equal(t, 15*time.Minute, events[0].EndAt.Sub(events[0].StartAt))
+}
+
+func testMultiDayListings(t *testing.T, s storage.Storage) {
+	t.Helper()
+
+	ctx := context.Background()
+	day := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	conference := newEvent(day, 3*24*time.Hour)
+	conference.AllDay = true
+	require.NoError(t, s.CreateEvent(ctx, conference))
+
+	overnight := newEvent(day.AddDate(0, 0, 4).Add(22*time.Hour), 4*time.Hour)
+	require.NoError(t, s.CreateEvent(ctx, overnight))
+
+	recurrence, err := storage.ParseRecurrence("FREQ=WEEKLY;COUNT=2")
+	require.NoError(t, err)
+	shift := newEvent(day.AddDate(0, 0, 11).Add(22*time.Hour), 4*time.Hour)
+	shift.Recurrence = recurrence
+	require.NoError(t, s.CreateEvent(ctx, shift))
+
+	actual, err := s.GetEvent(ctx, testUserID, conference.ID)
+	require.NoError(t, err)
+	requireEvent(t, conference, actual)
+
+	results := []struct {
+		name     string
+		list     func(context.Context, string, time.Time) ([]storage.Event, error)
+		date     time.Time
+		excepted []storage.Event
+	}{
+		{"first day", s.EventsDay, day, []storage.Event{conference}},
+		{"last day", s.EventsDay, day.AddDate(0, 0, 2), []storage.Event{conference}},
+		{"after end", s.EventsDay, day.AddDate(0, 0, 3), []storage.Event{}},
+		{"overnight", s.EventsDay, day.AddDate(0, 0, 5), []storage.Event{overnight}},
+		{"week", s.EventsWeek, day.AddDate(0, 0, 2), []storage.Event{conference, overnight}},
+		{"month", s.EventsMonth, day.AddDate(0, 0, 1), []storage.Event{conference, overnight, shift, shift}},
+		{"recurring overnight", s.EventsDay, day.AddDate(0, 0, 19), []storage.Event{shift}},
+	}
+
+	for _, v := range results {
+		t.Run(v.name, func(t *testing.T) {
+			actual, err := v.list(ctx, testUserID, v.date)
+			require.NoError(t, err)
+			require.ElementsMatch(t, ids(v.excepted), ids(actual))
+		})
+	}
+
+	// Повторение возвращается со своим временем начала, а не с началом суток.
+	events, err := s.EventsDay(ctx, testUserID, day.AddDate(0, 0, 19))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(events))
+	require.True(t, day.AddDate(0, 0, 18).Add(22*time.Hour).Equal(events[0].StartAt))
+}
+
+func testTimeZones(t *testing.T, s storage.Storage) {
+	t.Helper()
+
+	ctx := context.Background()
+	berlin, err := storage.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	moscow, err := storage.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	// Ежедневно в 10:00 по Берлину, 31.03.2024 переход на летнее время.
+	event := newEvent(time.Date(2024, 3, 29, 10, 0, 0, 0, berlin), time.Hour)
+	event.TimeZone = "Europe/Berlin"
+	event.Recurrence = &storage.Recurrence{Frequency: storage.FrequencyDaily, Interval: 1, Count: 5}
+	require.NoError(t, s.CreateEvent(ctx, event))
+
+	saved, err := s.GetEvent(ctx, testUserID, event.ID)
+	require.NoError(t, err)
+	requireEvent(t, event, saved)
+	require.Equal(t, "Europe/Berlin", saved.TimeZone)
+	require.Equal(t, berlin, saved.StartAt.Location())
+
+	events, err := s.EventsDay(ctx, testUserID, time.Date(2024, 3, 31, 0, 0, 0, 0, berlin))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(events))
+	require.True(t, time.Date(2024, 3, 31, 8, 0, 0, 0, time.UTC).Equal(events[0].StartAt))
+
+	// Событие в 00:30 по Москве 10 марта относится к 9 марта в UTC.
+	late := newEvent(time.Date(2024, 3, 10, 0, 30, 0, 0, moscow), time.Hour)
+	late.TimeZone = "Europe/Moscow"
+	require.NoError(t, s.CreateEvent(ctx, late))
+
+	events, err = s.EventsDay(ctx, testUserID, time.Date(2024, 3, 10, 0, 0, 0, 0, moscow))
+	require.NoError(t, err)
+	require.Equal(t, []string{late.ID}, ids(events))
+
+	events, err = s.EventsDay(ctx, testUserID, time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, []string{late.ID}, ids(events))
+}
+
+func testListEvents(t *testing.T, s storage.Storage) {
+	t.Helper()
+
+	ctx := context.Background()
+	day := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	meeting := newEvent(day.Add(9*time.Hour), time.Hour)
+	meeting.Title = "Встреча с командой"
+	meeting.Reminders = []time.Duration{time.Hour}
+	call := newEvent(day.Add(23*time.Hour), 2*time.Hour)
+	call.Description = "Обсудить ВСТРЕЧУ 100%"
+	daily := newEvent(day.Add(12*time.Hour), 30*time.Minute)
+	daily.Recurrence = &storage.Recurrence{Frequency: storage.FrequencyDaily, Interval: 1, Count: 5}
+	daily.Reminders = []time.Duration{10 * time.Minute}
+	late := newEvent(day.AddDate(0, 0, 3).Add(15*time.Hour), time.Hour)
+	outside := newEvent(day.AddDate(0, 0, 10), time.Hour)
+	// Начинается одновременно с meeting, порядок определяется ID.
+	other := newEvent(meeting.StartAt, time.Hour)
+	other.AuthorID = otherUserID
+	other.Title = "встреча"
+
+	for _, event := range []storage.Event{meeting, call, daily, late, outside, other} {
+		require.NoError(t, s.CreateEvent(ctx, event))
+	}
+
+	filter := storage.ListFilter{UserID: testUserID, From: day, To: day.AddDate(0, 0, 7)}
+	list := func(t *testing.T, filter storage.ListFilter) []storage.Event {
+		t.Helper()
+
+		page, err := s.ListEvents(ctx, filter)
+		require.NoError(t, err)
+
+		return page.Events
+	}
+	starts := func(events []storage.Event) []time.Time {
+		result := make([]time.Time, 0, len(events))
+		for _, e := range events {
+			result = append(result, e.StartAt.UTC())
+		}
+
+		return result
+	}
+
+	t.Run("range", func(t *testing.T) {
+		events := list(t, filter)
+		require.Equal(t, 8, len(events))
+		require.Equal(t, []time.Time{
+			meeting.StartAt, daily.StartAt, call.StartAt, daily.StartAt.AddDate(0, 0, 1),
+			daily.StartAt.AddDate(0, 0, 2), daily.StartAt.AddDate(0, 0, 3), late.StartAt,
+			daily.StartAt.AddDate(0, 0, 4),
+		}, starts(events))
+	})
+	t.Run("pagination", func(t *testing.T) {
+		all := list(t, filter)
+		paged := make([]storage.Event, 0, len(all))
+		filter := filter
+		filter.Limit = 3
+		for pages := 1; ; pages++ {
+			page, err := s.ListEvents(ctx, filter)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(page.Events), 3)
+			paged = append(paged, page.Events...)
+			if page.NextCursor == "" {
+				require.Equal(t, 3, pages)
+				break
+			}
+			filter.Cursor = page.NextCursor
+		}
+		require.Equal(t, starts(all), starts(paged))
+		require.Equal(t, ids(all), ids(paged))
+	})
+	t.Run("same start", func(t *testing.T) {
+		filter := storage.ListFilter{From: day, To: day.Add(10 * time.Hour), Limit: 1}
+		first, err := s.ListEvents(ctx, filter)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(first.Events))
+		require.NotEmpty(t, first.NextCursor)
+
+		filter.Cursor = first.NextCursor
+		second, err := s.ListEvents(ctx, filter)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(second.Events))
+		require.Empty(t, second.NextCursor)
+		require.ElementsMatch(t, []string{meeting.ID, other.ID}, ids(append(first.Events, second.Events...)))
+		require.Less(t, first.Events[0].ID, second.Events[0].ID)
+	})
+	t.Run("query", func(t *testing.T) {
+		filter := filter
+		filter.Query = "встреч"
+		require.ElementsMatch(t, []string{meeting.ID, call.ID}, ids(list(t, filter)))
+
+		filter.Query = "100%"
+		require.Equal(t, []string{call.ID}, ids(list(t, filter)))
+
+		filter.Query = "_"
+		require.Equal(t, 0, len(list(t, filter)))
+	})
+	t.Run("has notification", func(t *testing.T) {
+		with, without := true, false
+		filter := filter
+		filter.HasNotification = &with
+		events := list(t, filter)
+		require.Equal(t, 6, len(events))
+		for _, e := range events {
+			require.Contains(t, []string{meeting.ID, daily.ID}, e.ID)
+		}
+
+		filter.HasNotification = &without
+		require.ElementsMatch(t, []string{call.ID, late.ID}, ids(list(t, filter)))
+	})
+	t.Run("overlapping", func(t *testing.T) {
+		// call начинается накануне и заканчивается в 01:00.
+		next := day.AddDate(0, 0, 1)
+		filter := storage.ListFilter{UserID: testUserID, From: next, To: next.Add(12 * time.Hour)}
+		require.Equal(t, 0, len(list(t, filter)))
+
+		filter.Overlapping = true
+		require.Equal(t, []string{call.ID}, ids(list(t, filter)))
+	})
+	t.Run("invalid cursor", func(t *testing.T) {
+		filter := filter
+		filter.Cursor = "invalid"
+		_, err := s.ListEvents(ctx, filter)
+		requireErrorIs(t, err, storage.ErrInvalidCursor)
+	})
+}
+
+func testSearch(t *testing.T, s storage.Storage) {
+	t.Helper()
+
+	ctx := context.Background()
+	day := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	review := newEvent(day.Add(9*time.Hour), time.Hour)
+	review.Title = "Обзор бюджета"
+	review.Description = "Квартальный отчет"
+	planning := newEvent(day.AddDate(0, 0, 1).Add(9*time.Hour), time.Hour)
+	planning.Title = "Планирование"
+	planning.Description = "Обсудить бюджет и обзор задач"
+	weekly := newEvent(day.Add(12*time.Hour), 30*time.Minute)
+	weekly.Title = "Обзор спринта"
+	weekly.Recurrence = &storage.Recurrence{Frequency: storage.FrequencyWeekly, Interval: 1, Count: 3}
+	deleted := newEvent(day.AddDate(0, 0, 2), time.Hour)
+	deleted.Title = "Обзор бюджета"
+	other := newEvent(day.Add(15*time.Hour), time.Hour)
+	other.AuthorID = otherUserID
+	other.Title = "Обзор бюджета"
+
+	for _, event := range []storage.Event{review, planning, weekly, deleted, other} {
+		require.NoError(t, s.CreateEvent(ctx, event))
+	}
+	require.NoError(t, s.DeleteEvent(ctx, testUserID, deleted.ID, 0))
+
+	search := func(t *testing.T, filter storage.SearchFilter) []string {
+		t.Helper()
+
+		results, err := s.SearchEvents(ctx, filter)
+		require.NoError(t, err)
+
+		result := make([]string, 0, len(results))
+		for i, r := range results {
+			require.Greater(t, r.Rank, 0.0)
+			if i > 0 {
+				require.LessOrEqual(t, r.Rank, results[i-1].Rank)
+			}
+			result = append(result, r.Event.ID)
+		}
+
+		return result
+	}
+
+	t.Run("rank", func(t *testing.T) {
+		filter := storage.SearchFilter{UserID: testUserID, Query: "ОБЗОР"}
+		ids := search(t, filter)
+		require.Equal(t, 3, len(ids))
+		require.Equal(t, planning.ID, ids[2])
+
+		filter.Query = "бюджета обзор"
+		require.Equal(t, []string{review.ID}, search(t, filter))
+	})
+	t.Run("all terms", func(t *testing.T) {
+		filter := storage.SearchFilter{UserID: testUserID, Query: "обзор отпуска"}
+		require.Equal(t, 0, len(search(t, filter)))
+
+		filter.Query = "обзо"
+		require.Equal(t, 0, len(search(t, filter)))
+	})
+	t.Run("range", func(t *testing.T) {
+		filter := storage.SearchFilter{UserID: testUserID, Query: "обзор", From: day.AddDate(0, 0, 1)}
+		require.ElementsMatch(t, []string{planning.ID, weekly.ID}, search(t, filter))
+
+		filter.From = day.AddDate(0, 0, 15)
+		require.Equal(t, 0, len(search(t, filter)))
+
+		filter = storage.SearchFilter{UserID: testUserID, Query: "обзор", To: day.AddDate(0, 0, 1)}
+		require.ElementsMatch(t, []string{review.ID, weekly.ID}, search(t, filter))
+	})
+	t.Run("visibility", func(t *testing.T) {
+		filter := storage.SearchFilter{UserID: otherUserID, Query: "обзор"}
+		require.Equal(t, []string{other.ID}, search(t, filter))
+	})
+	t.Run("limit", func(t *testing.T) {
+		filter := storage.SearchFilter{UserID: testUserID, Query: "обзор", Limit: 1}
+		require.Equal(t, 1, len(search(t, filter)))
+	})
+	t.Run("update", func(t *testing.T) {
+		renamed := review
+		renamed.Title = "Отчет"
+		require.NoError(t, s.UpdateEvent(ctx, renamed))
+
+		filter := storage.SearchFilter{UserID: testUserID, Query: "бюджета"}
+		require.Equal(t, 0, len(search(t, filter)))
+		filter.Query = "отчет"
+		require.Equal(t, []string{review.ID}, search(t, filter))
+	})
+	t.Run("invalid query", func(t *testing.T) {
+		_, err := s.SearchEvents(ctx, storage.SearchFilter{UserID: testUserID, Query: " - "})
+		requireErrorIs(t, err, storage.ErrInvalidQuery)
+	})
+}
+
+func testNotifications(t *testing.T, s storage.Storage) {
+	t.Helper()
+
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	due := newEvent(now.Add(time.Hour), time.Hour)
+	due.Reminders = []time.Duration{24 * time.Hour, 2 * time.Hour, 15 * time.Minute}
+	future := newEvent(now.AddDate(0, 0, 2), time.Hour)
+	future.Reminders = []time.Duration{24 * time.Hour}
+	withoutNotification := newEvent(now.Add(-3*time.Hour), time.Hour)
+	recurring := newEvent(now.AddDate(0, 0, -3).Add(-5*time.Hour), time.Hour)
+	recurring.Reminders = []time.Duration{time.Hour}
+	recurring.Recurrence = &storage.Recurrence{Frequency: storage.FrequencyDaily, Interval: 1}
+
+	for _, event := range []storage.Event{due, future, withoutNotification, recurring} {
+		require.NoError(t, s.CreateEvent(ctx, event))
+	}
+
+	actual, err := s.GetEvent(ctx, testUserID, due.ID)
+	require.NoError(t, err)
+	require.Equal(t, due.Reminders, actual.Reminders)
+
+	reminders, err := s.RemindersForNotification(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{due.ID + "/24h0m0s", due.ID + "/2h0m0s", recurring.ID + "/1h0m0s"}, keys(reminders))
+
+	for _, r := range reminders {
+		require.False(t, r.Date().After(now.Add(time.Minute)))
+		if r.Event.ID == recurring.ID {
+			require.True(t, r.Event.StartAt.After(now.Add(-24*time.Hour)))
+		}
+	}
+
+	require.NoError(t, s.MarkRemindersSent(ctx, reminders))
+
+	reminders, err = s.RemindersForNotification(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(reminders))
+
+	t.Run("rescheduled", func(t *testing.T) {
+		// Напоминание за сутки о перенесенном событии срабатывает снова,
+		// за два часа - еще не наступило.
+		moved := due
+		moved.StartAt = now.Add(3 * time.Hour)
+		moved.EndAt = moved.StartAt.Add(time.Hour)
+		require.NoError(t, s.UpdateEvent(ctx, moved))
+
+		reminders, err := s.RemindersForNotification(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{due.ID + "/24h0m0s"}, keys(reminders))
+		require.True(t, moved.StartAt.Equal(reminders[0].Event.StartAt))
+		require.NoError(t, s.MarkRemindersSent(ctx, reminders))
+	})
+	t.Run("changed offsets", func(t *testing.T) {
+		changed := due
+		changed.StartAt = now.Add(3 * time.Hour)
+		changed.EndAt = changed.StartAt.Add(time.Hour)
+		changed.Reminders = []time.Duration{24 * time.Hour, 4 * time.Hour}
+		require.NoError(t, s.UpdateEvent(ctx, changed))
+
+		actual, err := s.GetEvent(ctx, testUserID, due.ID)
+		require.NoError(t, err)
+		require.Equal(t, changed.Reminders, actual.Reminders)
+
+		reminders, err := s.RemindersForNotification(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{due.ID + "/4h0m0s"}, keys(reminders))
+	})
+}
+
+func testClearOldEvents(t *testing.T, s storage.Storage) {
+	t.Helper()
+
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	daily := func(rule string) *storage.Recurrence {
+		r, err := storage.ParseRecurrence(rule)
+		require.NoError(t, err)
+
+		return r
+	}
+
+	old := newEvent(now.AddDate(-2, 0, 0), time.Hour)
+	recent := newEvent(now.AddDate(0, -1, 0), time.Hour)
+	// Началось больше года назад, но закончилось меньше года назад.
+	long := newEvent(now.AddDate(-1, 0, 0).Add(-time.Hour), 2*time.Hour)
+	oldSeries := newEvent(now.AddDate(-3, 0, 0).Add(2*time.Hour), time.Hour)
+	oldSeries.Recurrence = daily("FREQ=DAILY;COUNT=5")
+	infinite := newEvent(now.AddDate(-3, 0, 0).Add(4*time.Hour), time.Hour)
+	infinite.Recurrence = daily("FREQ=DAILY")
+
+	for _, event := range []storage.Event{old, recent, long, oldSeries, infinite} {
+		require.NoError(t, s.CreateEvent(ctx, event))
+	}
+
+	require.NoError(t, s.ClearOldEvents(ctx))
+
+	for _, event := range []storage.Event{old, oldSeries} {
+		requireErrorIs(t, s.DeleteEvent(ctx, testUserID, event.ID, 0), storage.ErrEventNotFound)
+	}
+
+	deleted, err := s.ListDeleted(ctx, testUserID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{old.ID, oldSeries.ID}, ids(deleted))
+
+	for _, event := range []storage.Event{recent, long, infinite} {
+		require.NoError(t, s.DeleteEvent(ctx, testUserID, event.ID, 0))
+	}
+}
+
+func testTrash(t *testing.T, s storage.Storage) {
+	t.Helper()
+
+	ctx := context.Background()
+	start := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	event := newEvent(start, time.Hour)
+	event.Reminders = []time.Duration{time.Hour}
+
+	require.NoError(t, s.CreateEvent(ctx, event))
+	require.NoError(t, s.DeleteEvent(ctx, testUserID, event.ID, 0))
+
+	t.Run("hidden", func(t *testing.T) {
+		_, err := s.GetEvent(ctx, testUserID, event.ID)
+		requireErrorIs(t, err, storage.ErrEventNotFound)
+
+		events, err := s.EventsDay(ctx, testUserID, start)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(events))
+
+		page, err := s.ListEvents(ctx, storage.ListFilter{UserID: testUserID, From: start, To: start.Add(time.Hour)})
+		require.NoError(t, err)
+		require.Equal(t, 0, len(page.Events))
+
+		reminders, err := s.RemindersForNotification(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(reminders))
+	})
+	t.Run("list deleted", func(t *testing.T) {
+		deleted, err := s.ListDeleted(ctx, testUserID)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(deleted))
+		requireEvent(t, event, deleted[0])
+		require.False(t, deleted[0].DeletedAt.IsZero())
+
+		deleted, err = s.ListDeleted(ctx, otherUserID)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(deleted))
+	})
+	t.Run("restore", func(t *testing.T) {
+		requireErrorIs(t, s.RestoreEvent(ctx, otherUserID, event.ID), storage.ErrAccessDenied)
+		requireErrorIs(t, s.RestoreEvent(ctx, testUserID, uuid.NewString()), storage.ErrEventNotFound)
+
+		require.NoError(t, s.RestoreEvent(ctx, testUserID, event.ID))
+		requireErrorIs(t, s.RestoreEvent(ctx, testUserID, event.ID), storage.ErrEventNotFound)
+
+		actual, err := s.GetEvent(ctx, testUserID, event.ID)
+		require.NoError(t, err)
+		requireEvent(t, event, actual)
+		require.True(t, actual.DeletedAt.IsZero())
+		require.Equal(t, storage.InitialVersion+2, actual.Version)
+
+		deleted, err := s.ListDeleted(ctx, testUserID)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(deleted))
+	})
+	t.Run("restore busy", func(t *testing.T) {
+		require.NoError(t, s.DeleteEvent(ctx, testUserID, event.ID, 0))
+
+		other := newEvent(start.Add(30*time.Minute), time.Hour)
+		require.NoError(t, s.CreateEvent(ctx, other))
+		requireErrorIs(t, s.RestoreEvent(ctx, testUserID, event.ID), storage.ErrDateBusy)
+
+		require.NoError(t, s.DeleteEvent(ctx, testUserID, other.ID, 0))
+	})
+	t.Run("purge", func(t *testing.T) {
+		require.NoError(t, s.PurgeDeleted(ctx, time.Now().Add(-time.Hour)))
+
+		deleted, err := s.ListDeleted(ctx, testUserID)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(deleted))
+
+		require.NoError(t, s.PurgeDeleted(ctx, time.Now().Add(time.Minute)))
+
+		deleted, err = s.ListDeleted(ctx, testUserID)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(deleted))
+		requireErrorIs(t, s.RestoreEvent(ctx, testUserID, event.ID), storage.ErrEventNotFound)
+	})
+}
+
+func testAudit(t *testing.T, s storage.Storage) {
+	t.Helper()
+
+	ctx := context.Background()
+	at := time.Date(2023, 6, 1, 9, 0, 0, 0, time.UTC)
+	event := newEvent(at.Add(time.Hour), time.Hour)
+	event.Version = storage.InitialVersion
+	event.Recurrence = &storage.Recurrence{Frequency: storage.FrequencyDaily, Interval: 1, Count: 3}
+	updated := event
+	updated.Title = "test with update"
+	updated.Version++
+	other := newEvent(at, time.Hour)
+
+	records := []storage.AuditRecord{
+		{EventID: event.ID, Action: storage.AuditCreate, ActorID: testUserID, At: at, After: &event},
+		{EventID: other.ID, Action: storage.AuditCreate, ActorID: testUserID, At: at, After: &other},
+		{EventID: event.ID, Action: storage.AuditUpdate, ActorID: testUserID, At: at.Add(time.Minute), Before: &event,
+			After: &updated},
+		{EventID: event.ID, Action: storage.AuditDelete, ActorID: testUserID, At: at.Add(time.Hour), Before: &updated},
+	}
+	for _, record := range records {
+		require.NoError(t, s.AddAuditRecord(ctx, record))
+	}
+
+	history, err := s.EventHistory(ctx, event.ID)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(history))
+	for i, expected := range []storage.AuditRecord{records[0], records[2], records[3]} {
+		actual := history[i]
+		require.Equal(t, expected.EventID, actual.EventID)
+		require.Equal(t, expected.Action, actual.Action)
+		require.Equal(t, expected.ActorID, actual.ActorID)
+		require.True(t, expected.At.Equal(actual.At))
+		require.Equal(t, expected.Before == nil, actual.Before == nil)
+		require.Equal(t, expected.After == nil, actual.After == nil)
+		if i > 0 {
+			require.Greater(t, actual.ID, history[i-1].ID)
+		}
+	}
+
+	requireEvent(t, updated, *history[1].After)
+	require.Equal(t, updated.Version, history[1].After.Version)
+	require.Equal(t, event.Recurrence.String(), history[1].Before.Recurrence.String())
+	require.Equal(t, updated.ID, history[2].Snapshot().ID)
+
+	history, err = s.EventHistory(ctx, uuid.NewString())
+	require.NoError(t, err)
+	require.Equal(t, 0, len(history))
+}
+
+func testAttendees(t *testing.T, s storage.Storage) {
+	t.Helper()
+
+	ctx := context.Background()
+	start := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	event := newEvent(start, time.Hour)
+	event.Reminders = []time.Duration{time.Hour}
+	busy := newEvent(start.Add(30*time.Minute), time.Hour)
+	busy.AuthorID = thirdUserID
+	require.NoError(t, s.CreateEvent(ctx, event))
+	require.NoError(t, s.CreateEvent(ctx, busy))
+
+	attendee := func(t *testing.T, userID string) storage.Attendee {
+		t.Helper()
+
+		actual, err := s.GetEvent(ctx, testUserID, event.ID)
+		require.NoError(t, err)
+		a, ok := actual.Attendee(userID)
+		require.True(t, ok)
+
+		return a
+	}
+	required := []storage.Attendee{{UserID: otherUserID, Role: storage.RoleRequired}}
+
+	t.Run("invite", func(t *testing.T) {
+		invite := []storage.Attendee{{UserID: thirdUserID, Role: storage.RoleRequired}}
+		requireErrorIs(t, s.InviteAttendees(ctx, testUserID, event.ID, invite), storage.ErrDateBusy)
+		requireErrorIs(t, s.InviteAttendees(ctx, otherUserID, event.ID, required), storage.ErrAccessDenied)
+		requireErrorIs(t, s.InviteAttendees(ctx, testUserID, uuid.NewString(), required), storage.ErrEventNotFound)
+
+		require.NoError(t, s.InviteAttendees(ctx, testUserID, event.ID, required))
+		actual, err := s.GetEvent(ctx, otherUserID, event.ID)
+		require.NoError(t, err)
+		require.Equal(t, storage.InitialVersion+1, actual.Version)
+		require.Equal(t, []storage.Attendee{
+			{UserID: otherUserID, Role: storage.RoleRequired, Status: storage.RSVPNeedsAction},
+		}, actual.Attendees)
+
+		_, err = s.GetEvent(ctx, thirdUserID, event.ID)
+		requireErrorIs(t, err, storage.ErrAccessDenied)
+	})
+	t.Run("listings", func(t *testing.T) {
+		events, err := s.EventsDay(ctx, otherUserID, start)
+		require.NoError(t, err)
+		require.Equal(t, []string{event.ID}, ids(events))
+		require.Equal(t, 1, len(events[0].Attendees))
+
+		page, err := s.ListEvents(ctx, storage.ListFilter{UserID: otherUserID, From: start, To: start.Add(time.Hour)})
+		require.NoError(t, err)
+		require.Equal(t, []string{event.ID}, ids(page.Events))
+
+		reminders, err := s.RemindersForNotification(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{event.ID + "/1h0m0s"}, keys(reminders))
+		require.Equal(t, 1, len(reminders[0].Event.Attendees))
+	})
+	t.Run("respond", func(t *testing.T) {
+		conflict := newEvent(start.Add(15*time.Minute), 30*time.Minute)
+		conflict.AuthorID = otherUserID
+		requireErrorIs(t, s.CreateEvent(ctx, conflict), storage.ErrDateBusy)
+
+		requireErrorIs(t, s.RespondInvitation(ctx, thirdUserID, event.ID, storage.RSVPAccepted), storage.ErrNotInvited)
+		require.NoError(t, s.RespondInvitation(ctx, otherUserID, event.ID, storage.RSVPAccepted))
+		require.Equal(t, storage.RSVPAccepted, attendee(t, otherUserID).Status)
+
+		require.NoError(t, s.RespondInvitation(ctx, otherUserID, event.ID, storage.RSVPDeclined))
+		require.NoError(t, s.CreateEvent(ctx, conflict))
+		requireErrorIs(t, s.RespondInvitation(ctx, otherUserID, event.ID, storage.RSVPTentative), storage.ErrDateBusy)
+		require.Equal(t, storage.RSVPDeclined, attendee(t, otherUserID).Status)
+	})
+	t.Run("update keeps attendees", func(t *testing.T) {
+		updated := event
+		updated.Title = "test with update"
+		require.NoError(t, s.UpdateEvent(ctx, updated))
+		require.Equal(t, storage.RSVPDeclined, attendee(t, otherUserID).Status)
+
+		optional := []storage.Attendee{{UserID: otherUserID, Role: storage.RoleOptional}}
+		require.NoError(t, s.InviteAttendees(ctx, testUserID, event.ID, optional))
+		a := attendee(t, otherUserID)
+		require.Equal(t, storage.RoleOptional, a.Role)
+		require.Equal(t, storage.RSVPDeclined, a.Status)
+	})
+}
+
+func testCalendars(t *testing.T, s storage.Storage) {
+	t.Helper()
+
+	ctx := context.Background()
+	start := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	work := storage.Calendar{ID: uuid.NewString(), Name: "Работа", OwnerID: testUserID}
+	home := storage.Calendar{ID: uuid.NewString(), Name: "Дом", OwnerID: testUserID}
+	require.NoError(t, s.CreateCalendar(ctx, work))
+	require.NoError(t, s.CreateCalendar(ctx, home))
+
+	event := newEvent(start, time.Hour)
+	event.CalendarID = work.ID
+	personal := newEvent(start.Add(2*time.Hour), time.Hour)
+	require.NoError(t, s.CreateEvent(ctx, event))
+	require.NoError(t, s.CreateEvent(ctx, personal))
+
+	t.Run("crud", func(t *testing.T) {
+		calendars, err := s.ListCalendars(ctx, testUserID)
+		require.NoError(t, err)
+		require.Equal(t, []storage.Calendar{home, work}, calendars)
+
+		renamed := work
+		renamed.Name = "Офис"
+		renamed.OwnerID = otherUserID
+		requireErrorIs(t, s.UpdateCalendar(ctx, renamed), storage.ErrCalendarAccessDenied)
+		renamed.OwnerID = testUserID
+		require.NoError(t, s.UpdateCalendar(ctx, renamed))
+		work = renamed
+
+		actual, err := s.GetCalendar(ctx, testUserID, work.ID)
+		require.NoError(t, err)
+		require.Equal(t, work, actual)
+
+		_, err = s.GetCalendar(ctx, otherUserID, work.ID)
+		requireErrorIs(t, err, storage.ErrCalendarAccessDenied)
+		_, err = s.GetCalendar(ctx, testUserID, uuid.NewString())
+		requireErrorIs(t, err, storage.ErrCalendarNotFound)
+
+		actualEvent, err := s.GetEvent(ctx, testUserID, event.ID)
+		require.NoError(t, err)
+		require.Equal(t, work.ID, actualEvent.CalendarID)
+	})
+	t.Run("share", func(t *testing.T) {
+		share := storage.CalendarShare{UserID: otherUserID, Permission: storage.PermissionRead}
+		requireErrorIs(t, s.ShareCalendar(ctx, otherUserID, work.ID, share), storage.ErrCalendarAccessDenied)
+		requireErrorIs(t, s.ShareCalendar(ctx, testUserID, uuid.NewString(), share), storage.ErrCalendarNotFound)
+
+		_, err := s.GetEvent(ctx, otherUserID, event.ID)
+		requireErrorIs(t, err, storage.ErrAccessDenied)
+
+		require.NoError(t, s.ShareCalendar(ctx, testUserID, work.ID, share))
+		share.Permission = storage.PermissionWrite
+		require.NoError(t, s.ShareCalendar(ctx, testUserID, work.ID, share))
+
+		actual, err := s.GetCalendar(ctx, otherUserID, work.ID)
+		require.NoError(t, err)
+		require.Equal(t, []storage.CalendarShare{share}, actual.Shares)
+		require.True(t, actual.CanWrite(otherUserID))
+
+		calendars, err := s.ListCalendars(ctx, otherUserID)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(calendars))
+		require.Equal(t, work.ID, calendars[0].ID)
+
+		_, err = s.GetEvent(ctx, otherUserID, event.ID)
+		require.NoError(t, err)
+		events, err := s.EventsDay(ctx, otherUserID, start)
+		require.NoError(t, err)
+		require.Equal(t, []string{event.ID}, ids(events))
+
+		page, err := s.ListEvents(ctx, storage.ListFilter{UserID: otherUserID, From: start, To: start.AddDate(0, 0, 1)})
+		require.NoError(t, err)
+		require.Equal(t, []string{event.ID}, ids(page.Events))
+	})
+	t.Run("list by calendar", func(t *testing.T) {
+		filter := storage.ListFilter{UserID: testUserID, From: start, To: start.AddDate(0, 0, 1)}
+		page, err := s.ListEvents(ctx, filter)
+		require.NoError(t, err)
+		require.Equal(t, []string{event.ID, personal.ID}, ids(page.Events))
+
+		filter.CalendarID = work.ID
+		page, err = s.ListEvents(ctx, filter)
+		require.NoError(t, err)
+		require.Equal(t, []string{event.ID}, ids(page.Events))
+	})
+	t.Run("busy in calendar", func(t *testing.T) {
+		other := newEvent(start.Add(30*time.Minute), time.Hour)
+		other.CalendarID = work.ID
+		requireErrorIs(t, s.CreateEvent(ctx, other), storage.ErrDateBusy)
+
+		other.CalendarID = home.ID
+		require.NoError(t, s.CreateEvent(ctx, other))
+	})
+	t.Run("attendee busy in other calendars", func(t *testing.T) {
+		own := newEvent(start.Add(4*time.Hour), time.Hour)
+		own.AuthorID = thirdUserID
+		require.NoError(t, s.CreateEvent(ctx, own))
+
+		// Личная встреча участника занимает его время и в чужом календаре.
+		team := newEvent(own.StartAt.Add(30*time.Minute), time.Hour)
+		team.CalendarID = work.ID
+		require.NoError(t, s.CreateEvent(ctx, team))
+		invite := []storage.Attendee{{UserID: thirdUserID, Role: storage.RoleRequired}}
+		requireErrorIs(t, s.InviteAttendees(ctx, testUserID, team.ID, invite), storage.ErrDateBusy)
+
+		require.NoError(t, s.DeleteEvent(ctx, testUserID, team.ID, 0))
+	})
+	t.Run("unshare", func(t *testing.T) {
+		require.NoError(t, s.UnshareCalendar(ctx, testUserID, work.ID, otherUserID))
+
+		_, err := s.GetEvent(ctx, otherUserID, event.ID)
+		requireErrorIs(t, err, storage.ErrAccessDenied)
+		calendars, err := s.ListCalendars(ctx, otherUserID)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(calendars))
+	})
+	t.Run("delete", func(t *testing.T) {
+		requireErrorIs(t, s.DeleteCalendar(ctx, otherUserID, work.ID), storage.ErrCalendarAccessDenied)
+		requireErrorIs(t, s.DeleteCalendar(ctx, testUserID, work.ID), storage.ErrCalendarNotEmpty)
+
+		require.NoError(t, s.DeleteEvent(ctx, testUserID, event.ID, 0))
+		require.NoError(t, s.DeleteCalendar(ctx, testUserID, work.ID))
+		_, err := s.GetCalendar(ctx, testUserID, work.ID)
+		requireErrorIs(t, err, storage.ErrCalendarNotFound)
+
+		require.NoError(t, s.RestoreEvent(ctx, testUserID, event.ID))
+		actual, err := s.GetEvent(ctx, testUserID, event.ID)
+		require.NoError(t, err)
+		require.Equal(t, "", actual.CalendarID)
+	})
+}
+
+func testResources(t *testing.T, s storage.Storage) {
+	t.Helper()
+
+	ctx := context.Background()
+	start := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	room := storage.Resource{
+		ID:       uuid.NewString(),
+		Name:     "Переговорная",
+		Kind:     storage.ResourceRoom,
+		Capacity: 6,
+		OwnerID:  testUserID,
+	}
+	projector := storage.Resource{
+		ID:      uuid.NewString(),
+		Name:    "Проектор",
+		Kind:    storage.ResourceEquipment,
+		OwnerID: testUserID,
+	}
+	require.NoError(t, s.CreateResource(ctx, room))
+	require.NoError(t, s.CreateResource(ctx, projector))
+
+	event := newEvent(start, time.Hour)
+	event.Resources = []string{room.ID}
+	require.NoError(t, s.CreateEvent(ctx, event))
+
+	t.Run("crud", func(t *testing.T) {
+		resources, err := s.ListResources(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []storage.Resource{room, projector}, resources)
+
+		updated := room
+		updated.Capacity = 10
+		updated.OwnerID = otherUserID
+		requireErrorIs(t, s.UpdateResource(ctx, updated), storage.ErrResourceAccessDenied)
+		updated.OwnerID = testUserID
+		require.NoError(t, s.UpdateResource(ctx, updated))
+		room = updated
+
+		actual, err := s.GetResource(ctx, room.ID)
+		require.NoError(t, err)
+		require.Equal(t, room, actual)
+
+		_, err = s.GetResource(ctx, uuid.NewString())
+		requireErrorIs(t, err, storage.ErrResourceNotFound)
+
+		actualEvent, err := s.GetEvent(ctx, testUserID, event.ID)
+		require.NoError(t, err)
+		require.Equal(t, []string{room.ID}, actualEvent.Resources)
+	})
+	t.Run("busy", func(t *testing.T) {
+		// Время другого пользователя свободно, но переговорная уже забронирована.
+		other := newEvent(start.Add(30*time.Minute), time.Hour)
+		other.AuthorID = otherUserID
+		other.Resources = []string{projector.ID, room.ID}
+		requireErrorIs(t, s.CreateEvent(ctx, other), storage.ErrResourceBusy)
+
+		other.Resources = []string{uuid.NewString()}
+		requireErrorIs(t, s.CreateEvent(ctx, other), storage.ErrResourceNotFound)
+
+		other.Resources = []string{projector.ID}
+		require.NoError(t, s.CreateEvent(ctx, other))
+
+		moved := event
+		moved.Resources = []string{projector.ID, room.ID}
+		requireErrorIs(t, s.UpdateEvent(ctx, moved), storage.ErrResourceBusy)
+		require.NoError(t, s.UpdateEvent(ctx, event))
+	})
+	t.Run("events", func(t *testing.T) {
+		overnight := newEvent(start.Add(12*time.Hour), 4*time.Hour)
+		overnight.Resources = []string{room.ID}
+		require.NoError(t, s.CreateEvent(ctx, overnight))
+
+		daily := newEvent(start.Add(4*time.Hour), time.Hour)
+		daily.Resources = []string{room.ID}
+		daily.Recurrence = &storage.Recurrence{Frequency: storage.FrequencyDaily, Interval: 1}
+		require.NoError(t, s.CreateEvent(ctx, daily))
+
+		from, to := storage.DayRange(start)
+		events, err := s.ResourceEvents(ctx, room.ID, from, to)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{event.ID, daily.ID, overnight.ID}, ids(events))
+
+		// Ночное событие началось накануне.
+		events, err = s.ResourceEvents(ctx, room.ID, to, to.AddDate(0, 0, 1))
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{daily.ID, overnight.ID}, ids(events))
+
+		_, err = s.ResourceEvents(ctx, uuid.NewString(), from, to)
+		requireErrorIs(t, err, storage.ErrResourceNotFound)
+	})
+	t.Run("delete", func(t *testing.T) {
+		requireErrorIs(t, s.DeleteResource(ctx, otherUserID, projector.ID), storage.ErrResourceAccessDenied)
+		requireErrorIs(t, s.DeleteResource(ctx, testUserID, projector.ID), storage.ErrResourceInUse)
+
+		events, err := s.ResourceEvents(ctx, projector.ID, start, start.AddDate(0, 0, 1))
+		require.NoError(t, err)
+		require.Equal(t, 1, len(events))
+		require.NoError(t, s.DeleteEvent(ctx, otherUserID, events[0].ID, 0))
+		require.NoError(t, s.DeleteResource(ctx, testUserID, projector.ID))
+		_, err = s.GetResource(ctx, projector.ID)
+		requireErrorIs(t, err, storage.ErrResourceNotFound)
+
+		require.NoError(t, s.RestoreEvent(ctx, otherUserID, events[0].ID))
+		actual, err := s.GetEvent(ctx, otherUserID, events[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(actual.Resources))
+	})
+}
+
+func newEvent(start time.Time, duration time.Duration) storage.Event {
+	return storage.Event{
+		ID:       uuid.NewString(),
+		Title:    "test",
+		StartAt:  start,
+		EndAt:    start.Add(duration),
+		AuthorID: testUserID,
+	}
+}
+
+// requireEvent сравнивает события без учета часового пояса.
+func requireEvent(t *testing.T, excepted, actual storage.Event) {
+	t.Helper()
+
+	require.Equal(t, excepted.ID, actual.ID)
+	require.Equal(t, excepted.Title, actual.Title)
+	require.Equal(t, excepted.Description, actual.Description)
+	require.Equal(t, excepted.AuthorID, actual.AuthorID)
+	require.True(t, excepted.StartAt.Equal(actual.StartAt))
+	require.True(t, excepted.EndAt.Equal(actual.EndAt))
+	require.Equal(t, excepted.Reminders, actual.Reminders)
+	require.Equal(t, excepted.Tags, actual.Tags)
+	require.Equal(t, excepted.Color, actual.Color)
+	require.Equal(t, excepted.Place, actual.Place)
+	require.Equal(t, excepted.MeetingURL, actual.MeetingURL)
+	require.Equal(t, excepted.Resources, actual.Resources)
+	require.Equal(t, excepted.AllDay, actual.AllDay)
+}
+
+func requireErrorIs(t *testing.T, err error, target error) {
+	t.Helper()
+
+	require.Truef(t, errors.Is(err, target), "actual error %q, excepted %q", err, target)
+}
+
+func ids(events []storage.Event) []string {
+	result := make([]string, 0, len(events))
+	for _, e := range events {
+		result = append(result, e.ID)
+	}
+
+	return result
+}
+
+// keys возвращает напоминания в виде "ID события/смещение".
+func keys(reminders []storage.Reminder) []string {
+	result := make([]string, 0, len(reminders))
+	for _, r := range reminders {
+		result = append(result, r.Event.ID+"/"+r.Offset.String())
+	}
+
+	return result
+}
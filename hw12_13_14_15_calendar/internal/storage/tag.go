@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxTags ограничивает число тегов у одного события.
+	MaxTags = 10
+	// MaxTagLength ограничивает длину тега в символах.
+	MaxTagLength = 32
+)
+
+var (
+	ErrInvalidTag   = errors.New("невалидный тег")
+	ErrInvalidColor = errors.New("невалидный цвет")
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// NormalizeTags приводит теги к нижнему регистру без пробелов по краям и
+// возвращает их без повторов в алфавитном порядке.
+func NormalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+
+		if !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+
+	if len(result) > MaxTags {
+		return nil, ErrInvalidTag
+	}
+
+	sort.Strings(result)
+
+	return result, nil
+}
+
+// NormalizeTag приводит тег к каноничному виду. Тег не может быть пустым,
+// длиннее MaxTagLength символов или содержать запятую.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" || utf8.RuneCountInString(tag) > MaxTagLength || strings.ContainsRune(tag, ',') {
+		return "", ErrInvalidTag
+	}
+
+	return tag, nil
+}
+
+// NormalizeColor проверяет цвет события в формате #rrggbb и приводит его к
+// нижнему регистру. Пустой цвет означает цвет по умолчанию.
+func NormalizeColor(color string) (string, error) {
+	color = strings.ToLower(strings.TrimSpace(color))
+	if color != "" && !colorPattern.MatchString(color) {
+		return "", ErrInvalidColor
+	}
+
+	return color, nil
+}
+
+// HasTag проверяет, что событие отмечено тегом в каноничном виде.
+func (e Event) HasTag(tag string) bool {
+	return slices.Contains(e.Tags, tag)
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := NormalizeTags([]string{" Работа ", "backend", "работа"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"backend", "работа"}, tags)
+
+	tags, err = NormalizeTags(nil)
+	require.NoError(t, err)
+	require.Nil(t, tags)
+
+	for _, tag := range []string{" ", "a,b", strings.Repeat("я", MaxTagLength+1)} {
+		_, err = NormalizeTags([]string{tag})
+		require.True(t, errors.Is(err, ErrInvalidTag), tag)
+	}
+
+	tooMany := make([]string, 0, MaxTags+1)
+	for i := 0; i <= MaxTags; i++ {
+		tooMany = append(tooMany, strings.Repeat("a", i+1))
+	}
+	_, err = NormalizeTags(tooMany)
+	require.True(t, errors.Is(err, ErrInvalidTag))
+}
+
+func TestNormalizeColor(t *testing.T) {
+	color, err := NormalizeColor(" #FF8800 ")
+	require.NoError(t, err)
+	require.Equal(t, "#ff8800", color)
+
+	color, err = NormalizeColor("")
+	require.NoError(t, err)
+	require.Equal(t, "", color)
+
+	for _, color := range []string{"red", "#fff", "ff8800", "#gg8800"} {
+		_, err = NormalizeColor(color)
+		require.True(t, errors.Is(err, ErrInvalidColor), color)
+	}
+}
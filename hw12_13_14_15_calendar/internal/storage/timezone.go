@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	// База часовых поясов встраивается, чтобы не зависеть от tzdata в образе.
+	_ "time/tzdata"
+)
+
+var ErrInvalidTimeZone = errors.New("невалидный часовой пояс")
+
+// locations кэш загруженных часовых поясов, time.LoadLocation каждый раз читает базу.
+var locations sync.Map
+
+// LoadLocation возвращает часовой пояс IANA по имени, пустое имя означает UTC.
+// Local не допускается, так как зависит от настроек сервера.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	if name == "Local" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTimeZone, name)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTimeZone, name)
+	}
+	locations.Store(name, loc)
+
+	return loc, nil
+}
+
+// Location возвращает часовой пояс события: TimeZone, если он задан,
+// иначе пояс StartAt. Для неизвестного пояса возвращается UTC.
+func (e Event) Location() *time.Location {
+	if e.TimeZone == "" {
+		return e.StartAt.Location()
+	}
+
+	loc, err := LoadLocation(e.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// Localize переводит времена события в его часовой пояс. Хранилища сохраняют
+// моменты времени без пояса и вызывают Localize при чтении.
+func (e Event) Localize() Event {
+	if e.TimeZone == "" {
+		return e
+	}
+
+	loc := e.Location()
+	e.StartAt = e.StartAt.In(loc)
+	e.EndAt = e.EndAt.In(loc)
+	if e.IsRecurring() && len(e.Recurrence.Exceptions) > 0 {
+		r := *e.Recurrence
+		r.Exceptions = make([]time.Time, 0, len(e.Recurrence.Exceptions))
+		for _, t := range e.Recurrence.Exceptions {
+			r.Exceptions = append(r.Exceptions, t.In(loc))
+		}
+		e.Recurrence = &r
+	}
+
+	return e
+}
+
+func (e Event) localStart() time.Time {
+	return e.StartAt.In(e.Location())
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadLocation(t *testing.T) {
+	loc, err := LoadLocation("")
+	require.NoError(t, err)
+	require.Equal(t, time.UTC, loc)
+
+	loc, err = LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	require.Equal(t, "Europe/Berlin", loc.String())
+
+	for _, name := range []string{"Local", "Mars/Olympus"} {
+		_, err = LoadLocation(name)
+		require.True(t, errors.Is(err, ErrInvalidTimeZone), name)
+	}
+}
+
+func TestTimeZoneOccurrences(t *testing.T) {
+	berlin, err := LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	// Повторение каждый день в 10:00 по Берлину через переход на летнее время 31.03.2024.
+	e := Event{
+		ID:         "1",
+		StartAt:    time.Date(2024, 3, 29, 9, 0, 0, 0, time.UTC),
+		EndAt:      time.Date(2024, 3, 29, 10, 0, 0, 0, time.UTC),
+		TimeZone:   "Europe/Berlin",
+		Recurrence: &Recurrence{Frequency: FrequencyDaily, Interval: 1},
+	}
+
+	occurrences := e.Occurrences(e.StartAt, e.StartAt.AddDate(0, 0, 3))
+	require.Equal(t, 4, len(occurrences))
+	for _, o := range occurrences {
+		require.Equal(t, 10, o.StartAt.In(berlin).Hour())
+	}
+	require.True(t, occurrences[2].StartAt.Equal(time.Date(2024, 3, 31, 8, 0, 0, 0, time.UTC)))
+
+	// Границы суток задаются часовым поясом даты: в 00:30 по Москве 10 марта в UTC еще 9 марта.
+	moscow, err := LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	event := Event{StartAt: time.Date(2024, 3, 9, 21, 30, 0, 0, time.UTC)}
+	from, to := DayRange(time.Date(2024, 3, 10, 0, 0, 0, 0, moscow))
+	require.Equal(t, 1, len(event.Occurrences(from, to)))
+	from, to = DayRange(time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC))
+	require.Equal(t, 0, len(event.Occurrences(from, to)))
+
+	localized := e.Localize()
+	require.Equal(t, berlin, localized.StartAt.Location())
+	require.True(t, localized.StartAt.Equal(e.StartAt))
+}
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE events
+    ADD COLUMN rrule text,
+    ADD COLUMN exdates timestamp[],
+    ADD COLUMN recurrence_end timestamp,
+    ADD COLUMN notified_until timestamp;
+
+CREATE INDEX ix_events_recurring ON events (start_at) WHERE rrule IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX ix_events_recurring;
+
+ALTER TABLE events
+    DROP COLUMN rrule,
+    DROP COLUMN exdates,
+    DROP COLUMN recurrence_end,
+    DROP COLUMN notified_until;
+-- +goose StatementEnd
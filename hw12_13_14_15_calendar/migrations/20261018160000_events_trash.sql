@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE events ADD COLUMN deleted_at timestamp;
+CREATE INDEX ix_events_deleted ON events (deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX ix_events_deleted;
+ALTER TABLE events DROP COLUMN deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE event_history (
+                               id bigserial primary key,
+                               event_id uuid not null,
+                               action text not null,
+                               actor_id uuid not null,
+                               created_at timestamp not null,
+                               before jsonb,
+                               after jsonb
+);
+
+CREATE INDEX ix_event_history_event ON event_history (event_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE event_history;
+-- +goose StatementEnd
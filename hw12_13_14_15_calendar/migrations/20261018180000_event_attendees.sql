@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE event_attendees (
+                                 event_id uuid not null references events (id) on delete cascade,
+                                 user_id uuid not null,
+                                 role text not null,
+                                 status text not null,
+                                 primary key (event_id, user_id)
+);
+
+CREATE INDEX ix_event_attendees_user ON event_attendees (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE event_attendees;
+-- +goose StatementEnd
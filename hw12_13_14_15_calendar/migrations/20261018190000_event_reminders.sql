@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Смещение remind_before хранится в наносекундах, notified_until - время срабатывания
+-- последнего отправленного напоминания с этим смещением.
+CREATE TABLE event_reminders (
+                                 event_id uuid not null references events (id) on delete cascade,
+                                 remind_before bigint not null,
+                                 notified_until timestamp,
+                                 primary key (event_id, remind_before)
+);
+
+INSERT INTO event_reminders (event_id, remind_before, notified_until)
+SELECT id, (extract(epoch FROM start_at - notification_date) * 1000000000)::bigint, notified_until
+FROM events
+WHERE notification_date IS NOT NULL AND notification_date <= start_at;
+
+ALTER TABLE events
+    DROP COLUMN notification_date,
+    DROP COLUMN notified_until;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE events
+    ADD COLUMN notification_date timestamp,
+    ADD COLUMN notified_until timestamp;
+
+UPDATE events
+SET notification_date = events.start_at - r.remind_before / 1000 * interval '1 microsecond',
+    notified_until = r.notified_until
+FROM (
+    SELECT DISTINCT ON (event_id) event_id, remind_before, notified_until
+    FROM event_reminders
+    ORDER BY event_id, remind_before DESC
+) r
+WHERE r.event_id = events.id;
+
+DROP TABLE event_reminders;
+-- +goose StatementEnd
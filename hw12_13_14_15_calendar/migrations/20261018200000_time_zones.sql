@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Значения timestamp записаны без пояса: первые версии сохраняли местное время
+-- клиента, более поздние - время UTC. По данным пояс определить нельзя, поэтому
+-- для непустой базы он задается явно, например для записей по Москве:
+--     PGOPTIONS='-c calendar.source_time_zone=Europe/Moscow' make migrate
+-- Если база заполнялась только после перехода на UTC, укажите UTC. Без
+-- настройки миграция пустой базы выполняется, а непустой - завершается ошибкой.
+DO $$
+BEGIN
+    IF coalesce(current_setting('calendar.source_time_zone', true), '') = ''
+        AND (EXISTS (SELECT 1 FROM events) OR EXISTS (SELECT 1 FROM event_history)) THEN
+        RAISE EXCEPTION 'не задан часовой пояс существующих значений timestamp'
+            USING HINT = 'задайте calendar.source_time_zone, например PGOPTIONS=''-c calendar.source_time_zone=UTC''';
+    END IF;
+END
+$$;
+
+-- Значения timestamp преобразуются в timestamptz в часовом поясе сессии.
+SELECT set_config('TimeZone', coalesce(nullif(current_setting('calendar.source_time_zone', true), ''), 'UTC'), true);
+
+ALTER TABLE events
+    ALTER COLUMN start_at TYPE timestamptz,
+    ALTER COLUMN end_at TYPE timestamptz,
+    ALTER COLUMN exdates TYPE timestamptz[],
+    ALTER COLUMN recurrence_end TYPE timestamptz,
+    ALTER COLUMN deleted_at TYPE timestamptz,
+    ADD COLUMN time_zone text not null default '';
+
+ALTER TABLE event_reminders ALTER COLUMN notified_until TYPE timestamptz;
+ALTER TABLE event_history ALTER COLUMN created_at TYPE timestamptz;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SET LOCAL TIME ZONE 'UTC';
+
+ALTER TABLE event_history ALTER COLUMN created_at TYPE timestamp;
+ALTER TABLE event_reminders ALTER COLUMN notified_until TYPE timestamp;
+
+ALTER TABLE events
+    DROP COLUMN time_zone,
+    ALTER COLUMN start_at TYPE timestamp,
+    ALTER COLUMN end_at TYPE timestamp,
+    ALTER COLUMN exdates TYPE timestamp[],
+    ALTER COLUMN recurrence_end TYPE timestamp,
+    ALTER COLUMN deleted_at TYPE timestamp;
+-- +goose StatementEnd
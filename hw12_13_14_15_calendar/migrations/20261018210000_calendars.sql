@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE calendars (
+                           id uuid primary key,
+                           name text not null,
+                           owner_id uuid not null
+);
+
+CREATE INDEX ix_calendars_owner ON calendars (owner_id);
+
+CREATE TABLE calendar_shares (
+                                 calendar_id uuid not null references calendars (id) on delete cascade,
+                                 user_id uuid not null,
+                                 permission text not null,
+                                 primary key (calendar_id, user_id)
+);
+
+CREATE INDEX ix_calendar_shares_user ON calendar_shares (user_id);
+
+-- События из корзины удаленного календаря переходят в личный календарь автора.
+ALTER TABLE events ADD COLUMN calendar_id uuid references calendars (id) on delete set null;
+
+CREATE INDEX ix_events_calendar ON events (calendar_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE events DROP COLUMN calendar_id;
+DROP TABLE calendar_shares;
+DROP TABLE calendars;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Конфигурация simple не зависит от языка: названия событий бывают и на
+-- русском, и на английском, а стемминг одного языка портит слова другого.
+ALTER TABLE events ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', title), 'A') || setweight(to_tsvector('simple', description), 'B')
+) STORED;
+
+CREATE INDEX ix_events_search ON events USING gin (search_vector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX ix_events_search;
+ALTER TABLE events DROP COLUMN search_vector;
+-- +goose StatementEnd
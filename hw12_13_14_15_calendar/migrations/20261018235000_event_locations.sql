@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Координаты места проведения заполняются вместе или не заполняются вовсе.
+ALTER TABLE events
+    ADD COLUMN location text not null default '',
+    ADD COLUMN latitude double precision,
+    ADD COLUMN longitude double precision,
+    ADD COLUMN meeting_url text not null default '',
+    ADD CONSTRAINT ck_events_coordinates CHECK ((latitude IS NULL) = (longitude IS NULL));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE events
+    DROP CONSTRAINT ck_events_coordinates,
+    DROP COLUMN meeting_url,
+    DROP COLUMN longitude,
+    DROP COLUMN latitude,
+    DROP COLUMN location;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE resources (
+                           id uuid primary key,
+                           name text not null,
+                           kind text not null,
+                           capacity integer not null default 0,
+                           owner_id uuid not null
+);
+
+-- Бронь снимается вместе с ресурсом: удалить можно только ресурс без событий вне корзины.
+CREATE TABLE event_resources (
+                                 event_id uuid not null references events (id) on delete cascade,
+                                 resource_id uuid not null references resources (id) on delete cascade,
+                                 primary key (event_id, resource_id)
+);
+
+CREATE INDEX ix_event_resources_resource ON event_resources (resource_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE event_resources;
+DROP TABLE resources;
+-- +goose StatementEnd
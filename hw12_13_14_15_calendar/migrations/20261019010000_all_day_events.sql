@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE events ADD COLUMN all_day boolean not null default false;
+-- Выборка за период ищет события, пересекающиеся с ним, а не только начинающиеся в нем.
+CREATE INDEX ix_events_end ON events (end_at) WHERE rrule IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX ix_events_end;
+ALTER TABLE events DROP COLUMN all_day;
+-- +goose StatementEnd
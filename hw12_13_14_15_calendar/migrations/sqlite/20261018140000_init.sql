@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Время хранится в наносекундах с начала эпохи Unix.
+CREATE TABLE events (
+                        id text primary key,
+                        title text not null default '',
+                        start_at integer not null,
+                        end_at integer not null,
+                        description text not null default '',
+                        author_id text not null,
+                        notification_date integer,
+                        rrule text,
+                        exdates text,
+                        recurrence_end integer,
+                        notified_until integer
+);
+
+CREATE INDEX ix_events_author_start ON events (author_id, start_at);
+CREATE INDEX ix_events_notification ON events (notification_date) WHERE notification_date IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE events;
+-- +goose StatementEnd
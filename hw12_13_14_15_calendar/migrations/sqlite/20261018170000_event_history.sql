@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE event_history (
+                               id integer primary key autoincrement,
+                               event_id text not null,
+                               action text not null,
+                               actor_id text not null,
+                               created_at integer not null,
+                               before text,
+                               after text
+);
+
+CREATE INDEX ix_event_history_event ON event_history (event_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE event_history;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Внешние ключи в SQLite по умолчанию выключены, участники удаляются вместе с событием в PurgeDeleted.
+CREATE TABLE event_attendees (
+                                 event_id text not null,
+                                 user_id text not null,
+                                 role text not null,
+                                 status text not null,
+                                 primary key (event_id, user_id)
+);
+
+CREATE INDEX ix_event_attendees_user ON event_attendees (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE event_attendees;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Смещение remind_before хранится в наносекундах, notified_until - время срабатывания
+-- последнего отправленного напоминания с этим смещением.
+CREATE TABLE event_reminders (
+                                 event_id text not null,
+                                 remind_before integer not null,
+                                 notified_until integer,
+                                 primary key (event_id, remind_before)
+);
+
+INSERT INTO event_reminders (event_id, remind_before, notified_until)
+SELECT id, start_at - notification_date, notified_until
+FROM events
+WHERE notification_date IS NOT NULL AND notification_date <= start_at;
+
+DROP INDEX ix_events_notification;
+ALTER TABLE events DROP COLUMN notification_date;
+ALTER TABLE events DROP COLUMN notified_until;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE events ADD COLUMN notification_date integer;
+ALTER TABLE events ADD COLUMN notified_until integer;
+
+UPDATE events
+SET notification_date = start_at - (SELECT max(remind_before) FROM event_reminders WHERE event_id = events.id),
+    notified_until = (SELECT max(notified_until) FROM event_reminders WHERE event_id = events.id);
+
+CREATE INDEX ix_events_notification ON events (notification_date) WHERE notification_date IS NOT NULL;
+DROP TABLE event_reminders;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Времена хранятся в наносекундах Unix, часовой пояс нужен для повторений и отображения.
+ALTER TABLE events ADD COLUMN time_zone text not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE events DROP COLUMN time_zone;
+-- +goose StatementEnd
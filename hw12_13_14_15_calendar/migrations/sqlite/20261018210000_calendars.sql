@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Внешние ключи в SQLite по умолчанию выключены, доступы и события
+-- календаря обрабатываются в DeleteCalendar.
+CREATE TABLE calendars (
+                           id text primary key,
+                           name text not null,
+                           owner_id text not null
+);
+
+CREATE INDEX ix_calendars_owner ON calendars (owner_id);
+
+CREATE TABLE calendar_shares (
+                                 calendar_id text not null,
+                                 user_id text not null,
+                                 permission text not null,
+                                 primary key (calendar_id, user_id)
+);
+
+CREATE INDEX ix_calendar_shares_user ON calendar_shares (user_id);
+
+ALTER TABLE events ADD COLUMN calendar_id text;
+
+CREATE INDEX ix_events_calendar ON events (calendar_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX ix_events_calendar;
+ALTER TABLE events DROP COLUMN calendar_id;
+DROP TABLE calendar_shares;
+DROP TABLE calendars;
+-- +goose StatementEnd
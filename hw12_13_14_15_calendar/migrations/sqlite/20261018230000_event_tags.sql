@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE event_tags (
+                            event_id text not null,
+                            tag text not null,
+                            primary key (event_id, tag)
+);
+
+CREATE INDEX ix_event_tags_tag ON event_tags (tag);
+
+ALTER TABLE events ADD COLUMN color text not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE events DROP COLUMN color;
+DROP INDEX ix_event_tags_tag;
+DROP TABLE event_tags;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE events ADD COLUMN location text not null default '';
+ALTER TABLE events ADD COLUMN latitude real;
+ALTER TABLE events ADD COLUMN longitude real;
+ALTER TABLE events ADD COLUMN meeting_url text not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE events DROP COLUMN meeting_url;
+ALTER TABLE events DROP COLUMN longitude;
+ALTER TABLE events DROP COLUMN latitude;
+ALTER TABLE events DROP COLUMN location;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Внешние ключи в SQLite по умолчанию выключены, бронь удаленного ресурса
+-- снимается в DeleteResource.
+CREATE TABLE resources (
+                           id text primary key,
+                           name text not null,
+                           kind text not null,
+                           capacity integer not null default 0,
+                           owner_id text not null
+);
+
+CREATE TABLE event_resources (
+                                 event_id text not null,
+                                 resource_id text not null,
+                                 primary key (event_id, resource_id)
+);
+
+CREATE INDEX ix_event_resources_resource ON event_resources (resource_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE event_resources;
+DROP TABLE resources;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE events ADD COLUMN all_day boolean not null default false;
+CREATE INDEX ix_events_end ON events (end_at) WHERE rrule IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX ix_events_end;
+ALTER TABLE events DROP COLUMN all_day;
+-- +goose StatementEnd